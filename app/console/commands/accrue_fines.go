@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+
+	"goravel/app/repositories"
+	"goravel/app/services"
+)
+
+type AccrueFines struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *AccrueFines) Signature() string {
+	return "fines:accrue"
+}
+
+// Description The console command description.
+func (receiver *AccrueFines) Description() string {
+	return "Charge loans still out past their due date the late fees accrued since the last run"
+}
+
+// Extend The console command extend.
+func (receiver *AccrueFines) Extend() command.Extend {
+	return command.Extend{Category: "fines"}
+}
+
+// Handle Execute the console command.
+func (receiver *AccrueFines) Handle(ctx console.Context) error {
+	charged, err := services.NewFineService(repositories.NewFineRepository()).AccrueLateFees()
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Failed to accrue late fees: %v", err))
+		return err
+	}
+
+	ctx.Info(fmt.Sprintf("Charged late fees on %d loans", charged))
+	return nil
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+
+	"goravel/app/repositories"
+	"goravel/app/services"
+)
+
+type ExpireReservations struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *ExpireReservations) Signature() string {
+	return "reservations:expire"
+}
+
+// Description The console command description.
+func (receiver *ExpireReservations) Description() string {
+	return "Expire holds not picked up in time and pass their copies to the next patron in line"
+}
+
+// Extend The console command extend.
+func (receiver *ExpireReservations) Extend() command.Extend {
+	return command.Extend{Category: "reservations"}
+}
+
+// Handle Execute the console command.
+func (receiver *ExpireReservations) Handle(ctx console.Context) error {
+	expired, err := services.NewReservationService(repositories.NewReservationRepository()).ExpireHolds()
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Failed to expire holds: %v", err))
+		return err
+	}
+
+	ctx.Info(fmt.Sprintf("Expired %d uncollected holds", expired))
+	return nil
+}
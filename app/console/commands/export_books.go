@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+)
+
+type ExportBooks struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *ExportBooks) Signature() string {
+	return "books:export"
+}
+
+// Description The console command description.
+func (receiver *ExportBooks) Description() string {
+	return "Write the book catalog to a CSV file that books:import can read"
+}
+
+// Extend The console command extend.
+func (receiver *ExportBooks) Extend() command.Extend {
+	return command.Extend{Category: "books"}
+}
+
+// Handle Execute the console command.
+func (receiver *ExportBooks) Handle(ctx console.Context) error {
+	path := ctx.Argument(0)
+	if path == "" {
+		err := errors.New("usage: books:export <file.csv>")
+		ctx.Error(err.Error())
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Failed to create %s: %v", path, err))
+		return err
+	}
+	defer file.Close()
+
+	if err := newBookImportService().Export(file); err != nil {
+		ctx.Error(fmt.Sprintf("Failed to export books: %v", err))
+		return err
+	}
+
+	ctx.Info(fmt.Sprintf("Exported the catalog to %s", path))
+	return nil
+}
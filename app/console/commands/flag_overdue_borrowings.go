@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+
+	"goravel/app/repositories"
+	"goravel/app/services"
+)
+
+type FlagOverdueBorrowings struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *FlagOverdueBorrowings) Signature() string {
+	return "borrowings:flag-overdue"
+}
+
+// Description The console command description.
+func (receiver *FlagOverdueBorrowings) Description() string {
+	return "Mark loans past their due date overdue and dispatch an event for each"
+}
+
+// Extend The console command extend.
+func (receiver *FlagOverdueBorrowings) Extend() command.Extend {
+	return command.Extend{Category: "borrowings"}
+}
+
+// Handle Execute the console command.
+func (receiver *FlagOverdueBorrowings) Handle(ctx console.Context) error {
+	service := services.NewBorrowingService(
+		repositories.NewBorrowingRepository(),
+		repositories.NewBookCopyRepository(),
+		repositories.NewUserRepository(),
+		services.NewLoanPolicyService(repositories.NewLoanPolicyRepository(), repositories.NewCategoryRepository()),
+	)
+
+	flagged, err := service.FlagOverdueBorrowings()
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Failed to flag overdue loans: %v", err))
+		return err
+	}
+
+	ctx.Info(fmt.Sprintf("Flagged %d overdue loans", flagged))
+	return nil
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+
+	"goravel/app/repositories"
+	"goravel/app/services"
+)
+
+type ImportBooks struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *ImportBooks) Signature() string {
+	return "books:import"
+}
+
+// Description The console command description.
+func (receiver *ImportBooks) Description() string {
+	return "Create and update books from a catalog CSV file"
+}
+
+// Extend The console command extend.
+func (receiver *ImportBooks) Extend() command.Extend {
+	return command.Extend{
+		Category: "books",
+		Flags: []command.Flag{
+			&command.BoolFlag{
+				Name:  "dry-run",
+				Usage: "validate the file and report what would change without saving",
+			},
+		},
+	}
+}
+
+// Handle Execute the console command.
+func (receiver *ImportBooks) Handle(ctx console.Context) error {
+	path := ctx.Argument(0)
+	if path == "" {
+		err := errors.New("usage: books:import [--dry-run] <file.csv>")
+		ctx.Error(err.Error())
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Failed to open %s: %v", path, err))
+		return err
+	}
+	defer file.Close()
+
+	report, err := newBookImportService().Import(file, ctx.OptionBool("dry-run"))
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Failed to import books: %v", err))
+		return err
+	}
+
+	for _, rowError := range report.Errors {
+		ctx.Warning(fmt.Sprintf("Row %d: %v", rowError.Row, rowError.Errors))
+	}
+	ctx.Info(report.String())
+	return nil
+}
+
+// newBookImportService wires the import service with the full book service,
+// so imported books get their authors, tags, copies and search terms.
+func newBookImportService() services.BookImportService {
+	repo := repositories.NewBookRepository()
+	books := services.NewBookService(
+		repo,
+		services.NewBookSearchService(repo),
+		repositories.NewAuthorRepository(),
+		repositories.NewCategoryRepository(),
+		repositories.NewTagRepository(),
+	)
+	copies := services.NewBookCopyService(repositories.NewBookCopyRepository(), repo)
+	return services.NewBookImportService(books, repo, copies)
+}
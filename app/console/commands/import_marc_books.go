@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+
+	"goravel/app/services"
+)
+
+type ImportMARCBooks struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *ImportMARCBooks) Signature() string {
+	return "books:import-marc"
+}
+
+// Description The console command description.
+func (receiver *ImportMARCBooks) Description() string {
+	return "Create and update books from a MARC21 or MARCXML file"
+}
+
+// Extend The console command extend.
+func (receiver *ImportMARCBooks) Extend() command.Extend {
+	return command.Extend{
+		Category: "books",
+		Flags: []command.Flag{
+			&command.BoolFlag{
+				Name:  "dry-run",
+				Usage: "validate the records and report what would change without saving",
+			},
+			&command.StringFlag{
+				Name:  "format",
+				Usage: "marc or marcxml, guessed from the file extension when omitted",
+			},
+		},
+	}
+}
+
+// Handle Execute the console command.
+func (receiver *ImportMARCBooks) Handle(ctx console.Context) error {
+	path := ctx.Argument(0)
+	if path == "" {
+		err := errors.New("usage: books:import-marc [--dry-run] [--format=marc|marcxml] <file>")
+		ctx.Error(err.Error())
+		return err
+	}
+
+	format := ctx.Option("format")
+	if format == "" {
+		format = services.MARCFormatISO2709
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".xml", ".marcxml":
+			format = services.MARCFormatXML
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Failed to open %s: %v", path, err))
+		return err
+	}
+	defer file.Close()
+
+	report, err := newBookImportService().ImportMARC(file, format, ctx.OptionBool("dry-run"))
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Failed to import books: %v", err))
+		return err
+	}
+
+	for _, rowError := range report.Errors {
+		ctx.Warning(fmt.Sprintf("Record %d: %v", rowError.Row, rowError.Errors))
+	}
+	ctx.Info(report.String())
+	return nil
+}
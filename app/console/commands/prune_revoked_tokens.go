@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+
+	"goravel/app/repositories"
+)
+
+type PruneRevokedTokens struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *PruneRevokedTokens) Signature() string {
+	return "auth:prune-revoked-tokens"
+}
+
+// Description The console command description.
+func (receiver *PruneRevokedTokens) Description() string {
+	return "Delete revoked JWT entries whose tokens have already expired"
+}
+
+// Extend The console command extend.
+func (receiver *PruneRevokedTokens) Extend() command.Extend {
+	return command.Extend{Category: "auth"}
+}
+
+// Handle Execute the console command.
+func (receiver *PruneRevokedTokens) Handle(ctx console.Context) error {
+	pruned, err := repositories.NewTokenRepository().PruneExpiredTokens()
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Failed to prune revoked tokens: %v", err))
+		return err
+	}
+
+	ctx.Info(fmt.Sprintf("Pruned %d expired revoked tokens", pruned))
+	return nil
+}
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+
+	"goravel/app/repositories"
+	"goravel/app/services"
+)
+
+type ReindexBookSearch struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *ReindexBookSearch) Signature() string {
+	return "books:search-reindex"
+}
+
+// Description The console command description.
+func (receiver *ReindexBookSearch) Description() string {
+	return "Rebuild the catalog search terms of every book"
+}
+
+// Extend The console command extend.
+func (receiver *ReindexBookSearch) Extend() command.Extend {
+	return command.Extend{Category: "books"}
+}
+
+// Handle Execute the console command.
+func (receiver *ReindexBookSearch) Handle(ctx console.Context) error {
+	repo := repositories.NewBookRepository()
+	indexed, err := services.NewBookSearchService(repo).Reindex()
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Failed to reindex books: %v", err))
+		return err
+	}
+
+	ctx.Info(fmt.Sprintf("Indexed %d books", indexed))
+	return nil
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+	"github.com/goravel/framework/facades"
+
+	"goravel/app/repositories"
+	"goravel/app/services"
+)
+
+type RotateJwtKeys struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *RotateJwtKeys) Signature() string {
+	return "jwt:rotate-keys"
+}
+
+// Description The console command description.
+func (receiver *RotateJwtKeys) Description() string {
+	return "Generate a new active JWT signing key and retire the current one"
+}
+
+// Extend The console command extend.
+func (receiver *RotateJwtKeys) Extend() command.Extend {
+	return command.Extend{
+		Category: "jwt",
+		Flags: []command.Flag{
+			&command.StringFlag{
+				Name:  "algorithm",
+				Usage: "signing algorithm, RS256 or EdDSA (defaults to jwt.algorithm)",
+			},
+			&command.IntFlag{
+				Name:  "keep",
+				Usage: "number of retired keys to keep for verifying older tokens",
+				Value: 2,
+			},
+		},
+	}
+}
+
+// Handle Execute the console command.
+func (receiver *RotateJwtKeys) Handle(ctx console.Context) error {
+	algorithm := ctx.Option("algorithm")
+	if algorithm == "" {
+		algorithm = facades.Config().GetString("jwt.algorithm", services.AlgorithmRS256)
+	}
+
+	keyRing := services.NewKeyRingService(repositories.NewKeyRingRepository())
+	key, err := keyRing.Rotate(algorithm, ctx.OptionInt("keep"))
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Failed to rotate JWT keys: %v", err))
+		return err
+	}
+
+	ctx.Info(fmt.Sprintf("Active JWT signing key is now %s (%s)", key.KID, key.Method.Alg()))
+	return nil
+}
@@ -3,15 +3,33 @@ package console
 import (
 	"github.com/goravel/framework/contracts/console"
 	"github.com/goravel/framework/contracts/schedule"
+	"github.com/goravel/framework/facades"
+
+	"goravel/app/console/commands"
 )
 
 type Kernel struct {
 }
 
 func (kernel Kernel) Schedule() []schedule.Event {
-	return []schedule.Event{}
+	return []schedule.Event{
+		facades.Schedule().Command("auth:prune-revoked-tokens").Hourly(),
+		facades.Schedule().Command("reservations:expire").Hourly(),
+		facades.Schedule().Command("borrowings:flag-overdue").Daily(),
+		facades.Schedule().Command("fines:accrue").Daily(),
+	}
 }
 
 func (kernel Kernel) Commands() []console.Command {
-	return []console.Command{}
+	return []console.Command{
+		&commands.PruneRevokedTokens{},
+		&commands.RotateJwtKeys{},
+		&commands.ReindexBookSearch{},
+		&commands.ImportBooks{},
+		&commands.ImportMARCBooks{},
+		&commands.ExportBooks{},
+		&commands.ExpireReservations{},
+		&commands.FlagOverdueBorrowings{},
+		&commands.AccrueFines{},
+	}
 }
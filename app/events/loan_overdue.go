@@ -0,0 +1,12 @@
+package events
+
+import "github.com/goravel/framework/contracts/event"
+
+// LoanOverdue is dispatched once for each loan that turns overdue. Its args
+// are the borrowing, user and book IDs, followed by the due date.
+type LoanOverdue struct {
+}
+
+func (receiver *LoanOverdue) Handle(args []event.Arg) ([]event.Arg, error) {
+	return args, nil
+}
@@ -0,0 +1,27 @@
+package helpers
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/goravel/framework/contracts/http"
+
+	"goravel/app/models"
+)
+
+const (
+	// ClaimsKey is the context key under which middleware.Auth stores the verified JWT claims.
+	ClaimsKey = "jwt_claims"
+	// UserKey is the context key under which middleware.Auth stores the authenticated user.
+	UserKey = "auth_user"
+)
+
+// Claims returns the claims stored by middleware.Auth, or nil on unauthenticated routes.
+func Claims(ctx http.Context) jwt.MapClaims {
+	claims, _ := ctx.Value(ClaimsKey).(jwt.MapClaims)
+	return claims
+}
+
+// CurrentUser returns the user resolved by middleware.Auth, or nil on unauthenticated routes.
+func CurrentUser(ctx http.Context) *models.User {
+	user, _ := ctx.Value(UserKey).(*models.User)
+	return user
+}
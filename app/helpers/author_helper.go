@@ -0,0 +1,109 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"goravel/app/models"
+)
+
+// authorSeparators splits a free-text author string on semicolons,
+// ampersands, slashes and the words "and", "dan" and "with".
+var authorSeparators = regexp.MustCompile(`(?i)\s*(?:;|&|/|\s+and\s+|\s+dan\s+|\s+with\s+)\s*`)
+
+// SplitAuthorNames splits a free-text author string such as
+// "Jane Doe & John Roe" into individual names. Commas only separate names
+// when every part is a full name, so "Toer, Pramoedya" stays one name while
+// "Jane Doe, John Roe" becomes two.
+func SplitAuthorNames(raw string) []string {
+	var names []string
+	for _, part := range authorSeparators.Split(raw, -1) {
+		pieces := strings.Split(part, ",")
+		listed := len(pieces) > 1
+		for _, piece := range pieces {
+			if !strings.Contains(strings.TrimSpace(piece), " ") {
+				listed = false
+			}
+		}
+		if !listed {
+			pieces = []string{part}
+		}
+
+		for _, piece := range pieces {
+			name := strings.Join(strings.Fields(piece), " ")
+			if name == "" || containsFold(names, name) {
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// CreditLine joins the names of a book's authors in order, e.g.
+// "Jane Doe, John Roe". Books credited only to editors or translators list
+// those instead.
+func CreditLine(credits []models.BookAuthor) string {
+	var authors, others []string
+	for _, credit := range credits {
+		if credit.Author == nil {
+			continue
+		}
+		if credit.Role == models.ContributorAuthor && !containsFold(authors, credit.Author.Name) {
+			authors = append(authors, credit.Author.Name)
+		} else if credit.Role != models.ContributorAuthor && !containsFold(others, credit.Author.Name) {
+			others = append(others, credit.Author.Name)
+		}
+	}
+
+	if len(authors) == 0 {
+		authors = others
+	}
+	return strings.Join(authors, ", ")
+}
+
+// FormatContributors lists a book's contributors with their roles, e.g.
+// "Toer, Pramoedya (author); Max Lane (translator)", the form
+// ParseContributors reads back.
+func FormatContributors(credits []models.BookAuthor) string {
+	parts := make([]string, 0, len(credits))
+	for _, credit := range credits {
+		if credit.Author == nil {
+			continue
+		}
+		parts = append(parts, credit.Author.Name+" ("+credit.Role+")")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ParseContributors reads a list written by FormatContributors. A name
+// without a role in parentheses is credited as an author.
+func ParseContributors(raw string) ([]models.BookAuthor, error) {
+	var credits []models.BookAuthor
+	for _, part := range strings.Split(raw, ";") {
+		name, role := strings.TrimSpace(part), models.ContributorAuthor
+		if open := strings.LastIndex(name, " ("); open >= 0 && strings.HasSuffix(name, ")") {
+			name, role = strings.TrimSpace(name[:open]), strings.ToLower(name[open+2:len(name)-1])
+			if !slices.Contains(models.ContributorRoles, role) {
+				return nil, fmt.Errorf("role must be one of %s", strings.Join(models.ContributorRoles, ", "))
+			}
+		}
+		name = strings.Join(strings.Fields(name), " ")
+		if name == "" {
+			continue
+		}
+		credits = append(credits, models.BookAuthor{Role: role, Author: &models.Author{Name: name}})
+	}
+	return credits, nil
+}
+
+func containsFold(names []string, name string) bool {
+	for _, existing := range names {
+		if strings.EqualFold(existing, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package helpers
+
+import (
+	"goravel/app/models"
+)
+
+type AuthorResponse map[string]any
+
+func ToAuthorResponse(author *models.Author) AuthorResponse {
+	return AuthorResponse{
+		"id":          author.ID,
+		"name":        author.Name,
+		"biography":   author.Biography,
+		"birth_year":  author.BirthYear,
+		"death_year":  author.DeathYear,
+		"nationality": author.Nationality,
+		"website":     author.Website,
+	}
+}
+
+func ToAuthorResponseList(authors []models.Author) []AuthorResponse {
+	response := []AuthorResponse{}
+	for _, author := range authors {
+		response = append(response, ToAuthorResponse(&author))
+	}
+	return response
+}
+
+// ToBookAuthorResponseList renders a book's contributors in order, with
+// just enough of each author to link to them.
+func ToBookAuthorResponseList(credits []models.BookAuthor) []map[string]any {
+	response := []map[string]any{}
+	for _, credit := range credits {
+		entry := map[string]any{
+			"id":       credit.AuthorID,
+			"role":     credit.Role,
+			"position": credit.Position,
+		}
+		if credit.Author != nil {
+			entry["name"] = credit.Author.Name
+		}
+		response = append(response, entry)
+	}
+	return response
+}
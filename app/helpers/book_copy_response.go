@@ -0,0 +1,26 @@
+package helpers
+
+import (
+	"goravel/app/models"
+)
+
+type BookCopyResponse map[string]any
+
+func ToBookCopyResponse(bookCopy *models.BookCopy) BookCopyResponse {
+	return BookCopyResponse{
+		"id":             bookCopy.ID,
+		"book_id":        bookCopy.BookID,
+		"barcode":        bookCopy.Barcode,
+		"shelf_location": bookCopy.ShelfLocation,
+		"condition":      bookCopy.Condition,
+		"status":         bookCopy.Status,
+	}
+}
+
+func ToBookCopyResponseList(copies []models.BookCopy) []BookCopyResponse {
+	response := []BookCopyResponse{}
+	for _, bookCopy := range copies {
+		response = append(response, ToBookCopyResponse(&bookCopy))
+	}
+	return response
+}
@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"path"
+	"strconv"
+
+	"github.com/goravel/framework/facades"
+)
+
+// CoverDisk is the filesystem disk book covers are stored on.
+const CoverDisk = "public"
+
+// CoverSize is a thumbnail generated for every cover, scaled to Width pixels
+// wide.
+type CoverSize struct {
+	Name  string
+	Width int
+}
+
+var CoverSizes = []CoverSize{
+	{Name: "small", Width: 160},
+	{Name: "medium", Width: 320},
+	{Name: "large", Width: 640},
+}
+
+// CoverPath returns where the size variant of cover is stored. The original
+// image is the "original" variant.
+func CoverPath(cover, size string) string {
+	return path.Join(path.Dir(cover), size+path.Ext(cover))
+}
+
+// CoverURLs maps "original" and every thumbnail size to its public URL, or
+// returns nil when there is no cover.
+func CoverURLs(cover string) map[string]string {
+	if cover == "" {
+		return nil
+	}
+
+	disk := facades.Storage().Disk(CoverDisk)
+	urls := map[string]string{"original": disk.Url(cover)}
+	for _, size := range CoverSizes {
+		urls[size.Name] = disk.Url(CoverPath(cover, size.Name))
+	}
+	return urls
+}
+
+// CoverDirectory is the directory holding every cover ever stored for a
+// book.
+func CoverDirectory(bookID uint) string {
+	return "covers/" + strconv.FormatUint(uint64(bookID), 10)
+}
@@ -10,9 +10,15 @@ func ToBookResponse(book *models.Book) BookResponse {
 	return BookResponse{
 		"id":             book.ID,
 		"author":         book.Author,
+		"authors":        ToBookAuthorResponseList(book.Authors),
+		"categories":     ToCategoryResponseList(book.Categories),
+		"tags":           ToTagResponseList(book.Tags),
 		"title":          book.Title,
 		"published_year": book.PublishedYear,
 		"stock":          book.Stock,
+		"isbn_10":        book.ISBN10,
+		"isbn_13":        book.ISBN13,
+		"cover":          CoverURLs(book.Cover),
 	}
 }
 
@@ -7,12 +7,34 @@ import (
 type BorrowingResponse map[string]any
 
 func ToBorrowingResponse(borrowing *models.Borrowing) BorrowingResponse {
-	return BorrowingResponse{
-		"id":      borrowing.ID,
-		"user_id": borrowing.UserID,
-		"book_id": borrowing.BookID,
-		"status":  borrowing.Status,
+	response := BorrowingResponse{
+		"id":           borrowing.ID,
+		"user_id":      borrowing.UserID,
+		"book_id":      borrowing.BookID,
+		"book_copy_id": borrowing.BookCopyID,
+		"borrow_date":  borrowing.BorrowDate,
+		"return_date":  borrowing.ReturnDate,
+		"due_at":       borrowing.DueAt,
+		"renewals":     borrowing.Renewals,
+		"status":       borrowing.Status,
 	}
+	if borrowing.History != nil {
+		response["history"] = ToBorrowingEventResponseList(borrowing.History)
+	}
+	return response
+}
+
+func ToBorrowingEventResponseList(events []models.BorrowingEvent) []map[string]any {
+	response := []map[string]any{}
+	for _, event := range events {
+		response = append(response, map[string]any{
+			"event":           event.Event,
+			"previous_due_at": event.PreviousDueAt,
+			"due_at":          event.DueAt,
+			"created_at":      event.CreatedAt,
+		})
+	}
+	return response
 }
 
 func ToBorrowingResponseList(borrowings []models.Borrowing) []BorrowingResponse {
@@ -0,0 +1,43 @@
+package helpers
+
+import (
+	"goravel/app/models"
+)
+
+type CategoryResponse map[string]any
+
+func ToCategoryResponse(category *models.Category) CategoryResponse {
+	return CategoryResponse{
+		"id":        category.ID,
+		"parent_id": category.ParentID,
+		"name":      category.Name,
+		"slug":      category.Slug,
+		"code":      category.Code,
+	}
+}
+
+func ToCategoryResponseList(categories []models.Category) []CategoryResponse {
+	response := []CategoryResponse{}
+	for _, category := range categories {
+		response = append(response, ToCategoryResponse(&category))
+	}
+	return response
+}
+
+type TagResponse map[string]any
+
+func ToTagResponse(tag *models.Tag) TagResponse {
+	return TagResponse{
+		"id":   tag.ID,
+		"name": tag.Name,
+		"slug": tag.Slug,
+	}
+}
+
+func ToTagResponseList(tags []models.Tag) []TagResponse {
+	response := []TagResponse{}
+	for _, tag := range tags {
+		response = append(response, ToTagResponse(&tag))
+	}
+	return response
+}
@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"goravel/app/models"
+)
+
+type FineEntryResponse map[string]any
+
+func ToFineEntryResponse(entry *models.FineEntry) FineEntryResponse {
+	var reason any
+	if entry.Reason != "" {
+		reason = entry.Reason
+	}
+	return FineEntryResponse{
+		"id":           entry.ID,
+		"user_id":      entry.UserID,
+		"borrowing_id": entry.BorrowingID,
+		"type":         entry.Type,
+		"reason":       reason,
+		"amount":       entry.Amount,
+		"note":         entry.Note,
+		"recorded_by":  entry.RecordedBy,
+		"created_at":   entry.CreatedAt,
+	}
+}
+
+// ToFineStatementResponse lists the entries in ledger order, each with the
+// balance that stood after it.
+func ToFineStatementResponse(entries []models.FineEntry) []FineEntryResponse {
+	response := []FineEntryResponse{}
+	var balance int64
+	for _, entry := range entries {
+		balance += entry.Amount
+		line := ToFineEntryResponse(&entry)
+		line["balance"] = balance
+		response = append(response, line)
+	}
+	return response
+}
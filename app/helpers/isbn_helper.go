@@ -0,0 +1,90 @@
+package helpers
+
+import (
+	"strings"
+)
+
+// NormalizeISBN strips hyphens and spaces from raw and upper-cases a
+// trailing X. It reports whether the result is a valid ISBN-10 or ISBN-13.
+func NormalizeISBN(raw string) (string, bool) {
+	isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(raw)))
+
+	switch len(isbn) {
+	case 10:
+		if !allDigits(isbn[:9]) || (isbn[9] != 'X' && !allDigits(isbn[9:])) {
+			return isbn, false
+		}
+		return isbn, isbn10CheckDigit(isbn[:9]) == isbn[9]
+	case 13:
+		if !allDigits(isbn) || !(strings.HasPrefix(isbn, "978") || strings.HasPrefix(isbn, "979")) {
+			return isbn, false
+		}
+		return isbn, isbn13CheckDigit(isbn[:12]) == isbn[12]
+	}
+	return isbn, false
+}
+
+// ISBNForms returns both forms of a valid ISBN. Books numbered in the 979
+// range have no ISBN-10, so isbn10 is empty for them.
+func ISBNForms(raw string) (isbn10, isbn13 string, ok bool) {
+	isbn, ok := NormalizeISBN(raw)
+	if !ok {
+		return "", "", false
+	}
+
+	if len(isbn) == 10 {
+		return isbn, ISBN10To13(isbn), true
+	}
+	isbn10, _ = ISBN13To10(isbn)
+	return isbn10, isbn, true
+}
+
+// ISBN10To13 converts a normalized, valid ISBN-10 to its ISBN-13.
+func ISBN10To13(isbn10 string) string {
+	body := "978" + isbn10[:9]
+	return body + string(isbn13CheckDigit(body))
+}
+
+// ISBN13To10 converts a normalized, valid ISBN-13 to its ISBN-10. Only the
+// 978 prefix has ISBN-10 equivalents.
+func ISBN13To10(isbn13 string) (string, bool) {
+	if !strings.HasPrefix(isbn13, "978") {
+		return "", false
+	}
+
+	body := isbn13[3:12]
+	return body + string(isbn10CheckDigit(body)), true
+}
+
+func isbn10CheckDigit(body string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(body[i]-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
+
+func isbn13CheckDigit(body string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(body[i]-'0') * weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func allDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,30 @@
+package helpers
+
+import (
+	"goravel/app/models"
+)
+
+type LoanPolicyResponse map[string]any
+
+func ToLoanPolicyResponse(policy *models.LoanPolicy) LoanPolicyResponse {
+	var role any
+	if policy.Role != "" {
+		role = policy.Role
+	}
+	return LoanPolicyResponse{
+		"id":           policy.ID,
+		"role":         role,
+		"category_id":  policy.CategoryID,
+		"loan_days":    policy.LoanDays,
+		"max_loans":    policy.MaxLoans,
+		"max_renewals": policy.MaxRenewals,
+	}
+}
+
+func ToLoanPolicyResponseList(policies []models.LoanPolicy) []LoanPolicyResponse {
+	response := []LoanPolicyResponse{}
+	for _, policy := range policies {
+		response = append(response, ToLoanPolicyResponse(&policy))
+	}
+	return response
+}
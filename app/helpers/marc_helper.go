@@ -0,0 +1,337 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ISO 2709 delimiters.
+const (
+	marcSubfieldDelimiter = 0x1F
+	marcFieldTerminator   = 0x1E
+	marcRecordTerminator  = 0x1D
+)
+
+// MARCXMLNamespace is the namespace of MARC21 slim records.
+const MARCXMLNamespace = "http://www.loc.gov/MARC21/slim"
+
+var ErrInvalidMARC = errors.New("invalid MARC record")
+
+// MARCRecord is a MARC21 bibliographic record. Control fields (001-009)
+// carry Value; data fields carry indicators and subfields.
+type MARCRecord struct {
+	Leader string
+	Fields []MARCField
+}
+
+type MARCField struct {
+	Tag       string
+	Value     string
+	Ind1      byte
+	Ind2      byte
+	Subfields []MARCSubfield
+}
+
+type MARCSubfield struct {
+	Code  byte
+	Value string
+}
+
+// IsControl reports whether the field is a control field, which has a value
+// instead of indicators and subfields.
+func (f MARCField) IsControl() bool {
+	return strings.HasPrefix(f.Tag, "00")
+}
+
+// Subfield returns the first subfield with code, or "".
+func (f MARCField) Subfield(code byte) string {
+	for _, subfield := range f.Subfields {
+		if subfield.Code == code {
+			return subfield.Value
+		}
+	}
+	return ""
+}
+
+// FieldsByTag returns the record's fields with tag, in order.
+func (r *MARCRecord) FieldsByTag(tag string) []MARCField {
+	var fields []MARCField
+	for _, field := range r.Fields {
+		if field.Tag == tag {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// MARCReader reads ISO 2709 records one at a time. Records are expected in
+// UTF-8; MARC-8 records are read byte for byte without transcoding.
+type MARCReader struct {
+	reader *bufio.Reader
+}
+
+func NewMARCReader(r io.Reader) *MARCReader {
+	return &MARCReader{reader: bufio.NewReader(r)}
+}
+
+// Read returns the next record, or io.EOF after the last one.
+func (m *MARCReader) Read() (*MARCRecord, error) {
+	data, err := m.reader.ReadBytes(marcRecordTerminator)
+	if errors.Is(err, io.EOF) {
+		// Files often end with a newline after the last record.
+		if len(bytes.TrimSpace(data)) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("%w: missing record terminator", ErrInvalidMARC)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseMARC21(bytes.TrimLeft(data, "\r\n"))
+}
+
+func parseMARC21(data []byte) (*MARCRecord, error) {
+	if len(data) < 25 {
+		return nil, fmt.Errorf("%w: record too short", ErrInvalidMARC)
+	}
+
+	record := &MARCRecord{Leader: string(data[:24])}
+	base, ok := marcNumber(data[12:17])
+	if !ok || base < 25 || base > len(data) {
+		return nil, fmt.Errorf("%w: bad base address of data", ErrInvalidMARC)
+	}
+
+	// The directory runs from the leader to the field terminator before the
+	// base address, twelve bytes per field.
+	directory := data[24 : base-1]
+	if len(directory)%12 != 0 {
+		return nil, fmt.Errorf("%w: bad directory length", ErrInvalidMARC)
+	}
+	for i := 0; i < len(directory); i += 12 {
+		entry := directory[i : i+12]
+		length, ok1 := marcNumber(entry[3:7])
+		start, ok2 := marcNumber(entry[7:12])
+		if !ok1 || !ok2 || length < 1 || base+start+length > len(data) {
+			return nil, fmt.Errorf("%w: bad directory entry %q", ErrInvalidMARC, entry)
+		}
+
+		field := MARCField{Tag: string(entry[:3])}
+		body := bytes.TrimSuffix(data[base+start:base+start+length], []byte{marcFieldTerminator})
+		if field.IsControl() {
+			field.Value = string(body)
+		} else {
+			if len(body) < 2 {
+				return nil, fmt.Errorf("%w: field %s has no indicators", ErrInvalidMARC, field.Tag)
+			}
+			field.Ind1, field.Ind2 = body[0], body[1]
+			for _, part := range bytes.Split(body[2:], []byte{marcSubfieldDelimiter}) {
+				if len(part) == 0 {
+					continue
+				}
+				field.Subfields = append(field.Subfields, MARCSubfield{Code: part[0], Value: string(part[1:])})
+			}
+		}
+		record.Fields = append(record.Fields, field)
+	}
+
+	return record, nil
+}
+
+// marcNumber reads a fixed-width number from the leader or directory. Only
+// digits are accepted, so a field can't claim a negative offset or length.
+func marcNumber(digits []byte) (int, bool) {
+	n, err := strconv.ParseUint(string(digits), 10, 32)
+	return int(n), err == nil
+}
+
+// WriteMARC21 writes record in ISO 2709, computing the leader's record
+// length and base address and marking it as Unicode.
+func WriteMARC21(w io.Writer, record *MARCRecord) error {
+	var directory, body bytes.Buffer
+	for _, field := range record.Fields {
+		start := body.Len()
+		if field.IsControl() {
+			body.WriteString(field.Value)
+		} else {
+			body.WriteByte(indicator(field.Ind1))
+			body.WriteByte(indicator(field.Ind2))
+			for _, subfield := range field.Subfields {
+				body.WriteByte(marcSubfieldDelimiter)
+				body.WriteByte(subfield.Code)
+				body.WriteString(subfield.Value)
+			}
+		}
+		body.WriteByte(marcFieldTerminator)
+
+		length := body.Len() - start
+		if length > 9999 || start > 99999 {
+			return fmt.Errorf("%w: field %s does not fit", ErrInvalidMARC, field.Tag)
+		}
+		fmt.Fprintf(&directory, "%3s%04d%05d", field.Tag, length, start)
+	}
+	directory.WriteByte(marcFieldTerminator)
+	body.WriteByte(marcRecordTerminator)
+
+	base := 24 + directory.Len()
+	total := base + body.Len()
+	if total > 99999 {
+		return fmt.Errorf("%w: record longer than 99999 bytes", ErrInvalidMARC)
+	}
+
+	leader := []byte(normalizeLeader(record.Leader))
+	copy(leader[0:5], fmt.Sprintf("%05d", total))
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+
+	for _, part := range [][]byte{leader, directory.Bytes(), body.Bytes()} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type marcXMLRecord struct {
+	XMLName       xml.Name           `xml:"record"`
+	Leader        string             `xml:"leader"`
+	ControlFields []marcXMLControl   `xml:"controlfield"`
+	DataFields    []marcXMLDataField `xml:"datafield"`
+}
+
+type marcXMLControl struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcXMLDataField struct {
+	Tag       string            `xml:"tag,attr"`
+	Ind1      string            `xml:"ind1,attr"`
+	Ind2      string            `xml:"ind2,attr"`
+	Subfields []marcXMLSubfield `xml:"subfield"`
+}
+
+type marcXMLSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// MARCXMLReader reads the records of a MARCXML document one at a time,
+// whether they are wrapped in a collection or not.
+type MARCXMLReader struct {
+	decoder *xml.Decoder
+}
+
+func NewMARCXMLReader(r io.Reader) *MARCXMLReader {
+	return &MARCXMLReader{decoder: xml.NewDecoder(r)}
+}
+
+// Read returns the next record, or io.EOF after the last one.
+func (m *MARCXMLReader) Read() (*MARCRecord, error) {
+	for {
+		token, err := m.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+
+		var raw marcXMLRecord
+		if err := m.decoder.DecodeElement(&raw, &start); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMARC, err)
+		}
+
+		// Control fields come first in MARC, so keep them ahead of the data
+		// fields they were interleaved with.
+		record := &MARCRecord{Leader: normalizeLeader(raw.Leader)}
+		for _, control := range raw.ControlFields {
+			record.Fields = append(record.Fields, MARCField{Tag: control.Tag, Value: control.Value})
+		}
+		for _, data := range raw.DataFields {
+			field := MARCField{Tag: data.Tag, Ind1: indicator(firstByte(data.Ind1)), Ind2: indicator(firstByte(data.Ind2))}
+			for _, subfield := range data.Subfields {
+				if subfield.Code == "" {
+					continue
+				}
+				field.Subfields = append(field.Subfields, MARCSubfield{Code: subfield.Code[0], Value: subfield.Value})
+			}
+			record.Fields = append(record.Fields, field)
+		}
+		return record, nil
+	}
+}
+
+// WriteMARCXML writes records as a MARCXML collection.
+func WriteMARCXML(w io.Writer, records ...*MARCRecord) error {
+	if _, err := io.WriteString(w, xml.Header+`<collection xmlns="`+MARCXMLNamespace+`">`+"\n"); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("  ", "  ")
+	for _, record := range records {
+		raw := marcXMLRecord{Leader: normalizeLeader(record.Leader)}
+		for _, field := range record.Fields {
+			if field.IsControl() {
+				raw.ControlFields = append(raw.ControlFields, marcXMLControl{Tag: field.Tag, Value: field.Value})
+				continue
+			}
+			data := marcXMLDataField{Tag: field.Tag, Ind1: string(indicator(field.Ind1)), Ind2: string(indicator(field.Ind2))}
+			for _, subfield := range field.Subfields {
+				data.Subfields = append(data.Subfields, marcXMLSubfield{Code: string(subfield.Code), Value: subfield.Value})
+			}
+			raw.DataFields = append(raw.DataFields, data)
+		}
+		if err := encoder.Encode(raw); err != nil {
+			return err
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n</collection>\n")
+	return err
+}
+
+// normalizeLeader pads or trims a leader to 24 bytes and fills in the parts
+// every MARC21 record shares: Unicode coding, two indicators, two-byte
+// subfield codes and the 4500 entry map.
+func normalizeLeader(leader string) string {
+	padded := []byte(fmt.Sprintf("%-24.24s", leader))
+	copy(padded[0:5], "00000")
+	if padded[5] == ' ' {
+		padded[5] = 'n'
+	}
+	if padded[6] == ' ' {
+		padded[6] = 'a'
+	}
+	if padded[7] == ' ' {
+		padded[7] = 'm'
+	}
+	padded[9] = 'a'
+	copy(padded[10:12], "22")
+	copy(padded[12:17], "00000")
+	copy(padded[20:24], "4500")
+	return string(padded)
+}
+
+func indicator(b byte) byte {
+	if b == 0 {
+		return ' '
+	}
+	return b
+}
+
+func firstByte(s string) byte {
+	if s == "" {
+		return ' '
+	}
+	return s[0]
+}
@@ -0,0 +1,211 @@
+package helpers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/goravel/framework/support/carbon"
+
+	"goravel/app/models"
+)
+
+// Media types OPDS clients use to tell the kind of feed they are given.
+const (
+	OPDSNavigationType  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	OPDSAcquisitionType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	OpenSearchType      = "application/opensearchdescription+xml"
+)
+
+// OPDS link relations used by the catalog feeds.
+const (
+	OPDSRelStart      = "start"
+	OPDSRelSubsection = "subsection"
+	OPDSRelNew        = "http://opds-spec.org/sort/new"
+	OPDSRelBorrow     = "http://opds-spec.org/acquisition/borrow"
+	OPDSRelSearch     = "search"
+)
+
+const (
+	atomNamespace       = "http://www.w3.org/2005/Atom"
+	opdsNamespace       = "http://opds-spec.org/2010/catalog"
+	dcNamespace         = "http://purl.org/dc/terms/"
+	openSearchNamespace = "http://a9.com/-/spec/opensearch/1.1/"
+)
+
+// OPDSFeed is an OPDS 1.2 catalog: an Atom feed whose entries either lead to
+// other feeds (navigation) or describe books (acquisition).
+type OPDSFeed struct {
+	XMLName xml.Name `xml:"feed"`
+	// The namespace attributes are filled in by WriteOPDS.
+	Xmlns           string      `xml:"xmlns,attr"`
+	XmlnsDC         string      `xml:"xmlns:dc,attr"`
+	XmlnsOPDS       string      `xml:"xmlns:opds,attr"`
+	XmlnsOpenSearch string      `xml:"xmlns:opensearch,attr"`
+	ID              string      `xml:"id"`
+	Title           string      `xml:"title"`
+	Updated         string      `xml:"updated"`
+	TotalResults    *int64      `xml:"opensearch:totalResults,omitempty"`
+	ItemsPerPage    int         `xml:"opensearch:itemsPerPage,omitempty"`
+	Links           []OPDSLink  `xml:"link"`
+	Entries         []OPDSEntry `xml:"entry"`
+}
+
+type OPDSLink struct {
+	Rel          string            `xml:"rel,attr,omitempty"`
+	Href         string            `xml:"href,attr"`
+	Type         string            `xml:"type,attr,omitempty"`
+	Title        string            `xml:"title,attr,omitempty"`
+	Availability *OPDSAvailability `xml:"opds:availability,omitempty"`
+	Copies       *OPDSCopies       `xml:"opds:copies,omitempty"`
+}
+
+// OPDSAvailability and OPDSCopies describe whether a borrow link can be
+// followed right now.
+type OPDSAvailability struct {
+	Status string `xml:"status,attr"`
+}
+
+type OPDSCopies struct {
+	Available int `xml:"available,attr"`
+}
+
+type OPDSEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Authors    []OPDSAuthor   `xml:"author,omitempty"`
+	Issued     string         `xml:"dc:issued,omitempty"`
+	Identifier string         `xml:"dc:identifier,omitempty"`
+	Categories []OPDSCategory `xml:"category,omitempty"`
+	Content    *OPDSContent   `xml:"content,omitempty"`
+	Links      []OPDSLink     `xml:"link"`
+}
+
+type OPDSAuthor struct {
+	Name string `xml:"name"`
+}
+
+type OPDSCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr,omitempty"`
+}
+
+type OPDSContent struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// ToOPDSEntry describes book as an acquisition entry. Books are lent rather
+// than downloaded, so the entry carries a borrow link whose availability
+// follows the book's stock; borrowHref is where that link points.
+func ToOPDSEntry(book *models.Book, borrowHref string) OPDSEntry {
+	entry := OPDSEntry{
+		ID:      "urn:book:" + strconv.FormatUint(uint64(book.ID), 10),
+		Title:   book.Title,
+		Updated: OPDSTime(book.UpdatedAt),
+	}
+
+	for _, credit := range book.Authors {
+		if credit.Author != nil && credit.Role == models.ContributorAuthor {
+			entry.Authors = append(entry.Authors, OPDSAuthor{Name: credit.Author.Name})
+		}
+	}
+	if len(entry.Authors) == 0 && book.Author != "" {
+		entry.Authors = []OPDSAuthor{{Name: book.Author}}
+	}
+	if book.PublishedYear > 0 {
+		entry.Issued = fmt.Sprintf("%04d", book.PublishedYear)
+	}
+	if book.ISBN13 != nil {
+		entry.Identifier = "urn:isbn:" + *book.ISBN13
+	}
+	for _, category := range book.Categories {
+		entry.Categories = append(entry.Categories, OPDSCategory{Term: category.Slug, Label: category.Name})
+	}
+	for _, tag := range book.Tags {
+		entry.Categories = append(entry.Categories, OPDSCategory{Term: tag.Slug, Label: tag.Name})
+	}
+
+	status, summary := "available", strconv.Itoa(book.Stock)+" copies available"
+	switch book.Stock {
+	case 0:
+		status, summary = "unavailable", "No copies available"
+	case 1:
+		summary = "1 copy available"
+	}
+	entry.Content = &OPDSContent{Type: "text", Text: summary}
+	entry.Links = []OPDSLink{{
+		Rel:          OPDSRelBorrow,
+		Href:         borrowHref,
+		Type:         "application/json",
+		Availability: &OPDSAvailability{Status: status},
+		Copies:       &OPDSCopies{Available: book.Stock},
+	}}
+
+	return entry
+}
+
+// OPDSTime formats a model timestamp the way Atom expects, falling back to
+// the epoch for rows without one.
+func OPDSTime(t *carbon.DateTime) string {
+	if t == nil || t.IsZero() {
+		return time.Unix(0, 0).UTC().Format(time.RFC3339)
+	}
+	return t.StdTime().UTC().Format(time.RFC3339)
+}
+
+// WriteOPDS writes feed as an XML document with the OPDS namespaces declared.
+func WriteOPDS(w io.Writer, feed *OPDSFeed) error {
+	feed.Xmlns = atomNamespace
+	feed.XmlnsDC = dcNamespace
+	feed.XmlnsOPDS = opdsNamespace
+	feed.XmlnsOpenSearch = openSearchNamespace
+	if feed.Updated == "" {
+		feed.Updated = time.Now().UTC().Format(time.RFC3339)
+	}
+	return writeXML(w, feed)
+}
+
+// OpenSearchDescription tells clients how to query the catalog. Template
+// contains {searchTerms}, which clients replace with the user's query.
+type OpenSearchDescription struct {
+	XMLName        xml.Name `xml:"OpenSearchDescription"`
+	Xmlns          string   `xml:"xmlns,attr"`
+	ShortName      string   `xml:"ShortName"`
+	Description    string   `xml:"Description"`
+	InputEncoding  string   `xml:"InputEncoding"`
+	OutputEncoding string   `xml:"OutputEncoding"`
+	URL            struct {
+		Type     string `xml:"type,attr"`
+		Template string `xml:"template,attr"`
+	} `xml:"Url"`
+}
+
+func WriteOpenSearch(w io.Writer, shortName, description, template string) error {
+	doc := OpenSearchDescription{
+		Xmlns:          openSearchNamespace,
+		ShortName:      shortName,
+		Description:    description,
+		InputEncoding:  "UTF-8",
+		OutputEncoding: "UTF-8",
+	}
+	doc.URL.Type = OPDSAcquisitionType
+	doc.URL.Template = template
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,70 @@
+package helpers
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/goravel/framework/contracts/http"
+)
+
+// Meta describes a page of results. Offset pagination fills CurrentPage and
+// LastPage; cursor pagination fills NextCursor instead. A listing that
+// supports both also gives NextCursor on its offset pages, so a client can
+// switch to the cursor from the first page on.
+type Meta struct {
+	Total       int64     `json:"total"`
+	PerPage     int       `json:"per_page"`
+	CurrentPage int       `json:"current_page,omitempty"`
+	LastPage    int       `json:"last_page,omitempty"`
+	NextCursor  string    `json:"next_cursor,omitempty"`
+	Links       MetaLinks `json:"links"`
+}
+
+type MetaLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// PageMeta builds the meta block for offset pagination, linking to the pages
+// around page with the rest of the query string kept as is.
+func PageMeta(ctx http.Context, total int64, page, perPage int) *Meta {
+	lastPage := int((total + int64(perPage) - 1) / int64(perPage))
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	meta := &Meta{Total: total, PerPage: perPage, CurrentPage: page, LastPage: lastPage}
+	if page < lastPage {
+		meta.Links.Next = PageURL(ctx, map[string]string{"page": strconv.Itoa(page + 1)})
+	}
+	if page > 1 {
+		meta.Links.Prev = PageURL(ctx, map[string]string{"page": strconv.Itoa(min(page-1, lastPage))})
+	}
+	return meta
+}
+
+// CursorMeta builds the meta block for cursor pagination. Cursors only run
+// forward, so there is no previous link.
+func CursorMeta(ctx http.Context, total int64, perPage int, nextCursor string) *Meta {
+	meta := &Meta{Total: total, PerPage: perPage, NextCursor: nextCursor}
+	if nextCursor != "" {
+		meta.Links.Next = PageURL(ctx, map[string]string{"cursor": nextCursor})
+	}
+	return meta
+}
+
+// PageURL returns the current request URL with the given query parameters
+// replaced.
+func PageURL(ctx http.Context, params map[string]string) string {
+	current, err := url.Parse(ctx.Request().FullUrl())
+	if err != nil {
+		return ""
+	}
+
+	query := current.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	current.RawQuery = query.Encode()
+	return current.String()
+}
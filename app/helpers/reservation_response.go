@@ -0,0 +1,33 @@
+package helpers
+
+import (
+	"goravel/app/models"
+)
+
+type ReservationResponse map[string]any
+
+func ToReservationResponse(reservation *models.Reservation) ReservationResponse {
+	response := ReservationResponse{
+		"id":           reservation.ID,
+		"user_id":      reservation.UserID,
+		"book_id":      reservation.BookID,
+		"book_copy_id": reservation.BookCopyID,
+		"status":       reservation.Status,
+		"position":     nil,
+		"ready_at":     reservation.ReadyAt,
+		"expires_at":   reservation.ExpiresAt,
+		"created_at":   reservation.CreatedAt,
+	}
+	if reservation.Status == models.ReservationWaiting && reservation.Position > 0 {
+		response["position"] = reservation.Position
+	}
+	return response
+}
+
+func ToReservationResponseList(reservations []models.Reservation) []ReservationResponse {
+	response := []ReservationResponse{}
+	for _, reservation := range reservations {
+		response = append(response, ToReservationResponse(&reservation))
+	}
+	return response
+}
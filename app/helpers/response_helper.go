@@ -10,6 +10,7 @@ type JsonResponse struct {
 	Data       any    `json:"data,omitempty"`
 	Error      any    `json:"error,omitempty"`
 	Errors     any    `json:"errors,omitempty"`
+	Meta       *Meta  `json:"meta,omitempty"`
 }
 
 func Success(ctx http.Context, message string, data any) http.Response {
@@ -20,6 +21,16 @@ func Success(ctx http.Context, message string, data any) http.Response {
 	})
 }
 
+// Paginated responds with one page of data and the meta block describing it.
+func Paginated(ctx http.Context, message string, data any, meta *Meta) http.Response {
+	return ctx.Response().Json(200, JsonResponse{
+		StatusCode: 200,
+		Message:    message,
+		Data:       data,
+		Meta:       meta,
+	})
+}
+
 func Created(ctx http.Context, message string, data any) http.Response {
 	return ctx.Response().Json(201, JsonResponse{
 		StatusCode: 201,
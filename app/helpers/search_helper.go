@@ -0,0 +1,152 @@
+package helpers
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SearchTokens splits a query into normalized terms: lower case, diacritics
+// removed and Indonesian affixes stripped, so "Pelangi", "pélangi" and
+// "pelanginya" all look for the same stem.
+func SearchTokens(text string) []string {
+	var tokens []string
+	for _, word := range splitWords(text) {
+		if folded := foldWord(word); folded != "" {
+			tokens = append(tokens, stemIndonesian(folded))
+		}
+	}
+	return tokens
+}
+
+// IndexTokens returns the terms a document is found by: the stem of every
+// word and, when it differs, the folded word itself so that prefixes of the
+// full word still match while the user is typing.
+func IndexTokens(text string) []string {
+	var tokens []string
+	for _, word := range splitWords(text) {
+		folded := foldWord(word)
+		if folded == "" {
+			continue
+		}
+		tokens = append(tokens, folded)
+		if stem := stemIndonesian(folded); stem != folded {
+			tokens = append(tokens, stem)
+		}
+	}
+	return tokens
+}
+
+func splitWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func foldWord(word string) string {
+	var folded strings.Builder
+	for _, r := range norm.NFD.String(word) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		folded.WriteRune(unicode.ToLower(r))
+	}
+	return folded.String()
+}
+
+// stemIndonesian is a dictionary-free take on the Nazief-Adriani stemmer. It
+// removes inflectional particles and possessives, the -kan/-an suffixes and
+// the common prefixes, only while at least four letters remain so short roots
+// such as "bulan" survive. Without a dictionary some words are over-stemmed,
+// which is harmless as documents and queries are stemmed the same way.
+func stemIndonesian(word string) string {
+	const minStem = 4
+
+	strip := func(affixes []string, suffix bool) bool {
+		for _, affix := range affixes {
+			if suffix && strings.HasSuffix(word, affix) && len([]rune(word))-len(affix) >= minStem {
+				word = strings.TrimSuffix(word, affix)
+				return true
+			}
+			if !suffix && strings.HasPrefix(word, affix) && len([]rune(word))-len(affix) >= minStem {
+				word = strings.TrimPrefix(word, affix)
+				return true
+			}
+		}
+		return false
+	}
+
+	strip([]string{"lah", "kah", "tah", "pun"}, true)
+	strip([]string{"nya", "ku", "mu"}, true)
+	strip([]string{"kan", "an"}, true)
+
+	// Nasal prefixes replace the first letter of the root: menulis -> tulis,
+	// memukul -> pukul, menyapu -> sapu.
+	for _, rule := range []struct{ prefix, restore string }{
+		{"meny", "s"}, {"peny", "s"},
+		{"meng", ""}, {"peng", ""},
+		{"mem", "p"}, {"pem", "p"},
+		{"men", "t"}, {"pen", "t"},
+	} {
+		rest, ok := strings.CutPrefix(word, rule.prefix)
+		if !ok || len([]rune(rest)) < minStem-1 {
+			continue
+		}
+		if rule.restore != "" && startsWithVowel(rest) {
+			rest = rule.restore + rest
+		}
+		word = rest
+		return word
+	}
+
+	strip([]string{"ber", "ter", "per", "di", "ke", "se", "me", "pe"}, false)
+	return word
+}
+
+func startsWithVowel(word string) bool {
+	return word != "" && strings.ContainsRune("aeiou", rune(word[0]))
+}
+
+// HighlightMatches wraps every word of text whose normalized form starts
+// with one of terms in <mark> tags. The rest of the text is HTML-escaped.
+func HighlightMatches(text string, terms []string) string {
+	var out strings.Builder
+	word := strings.Builder{}
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+		w := word.String()
+		if matchesAnyTerm(IndexTokens(w), terms) {
+			out.WriteString("<mark>" + html.EscapeString(w) + "</mark>")
+		} else {
+			out.WriteString(html.EscapeString(w))
+		}
+		word.Reset()
+	}
+
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			word.WriteRune(r)
+			continue
+		}
+		flush()
+		out.WriteString(html.EscapeString(string(r)))
+	}
+	flush()
+
+	return out.String()
+}
+
+func matchesAnyTerm(tokens, terms []string) bool {
+	for _, token := range tokens {
+		for _, term := range terms {
+			if strings.HasPrefix(token, term) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Slugify turns a name into a lowercase, hyphenated URL segment, dropping
+// diacritics and punctuation: "Sastra Indonésia & Puisi" becomes
+// "sastra-indonesia-puisi".
+func Slugify(name string) string {
+	var slug strings.Builder
+	hyphen := false
+	for _, r := range norm.NFD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if hyphen && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			hyphen = false
+			slug.WriteRune(unicode.ToLower(r))
+		default:
+			hyphen = true
+		}
+	}
+	return slug.String()
+}
@@ -8,9 +8,12 @@ type UserResponse map[string]any
 
 func ToUserResponse(user *models.User) UserResponse {
 	return UserResponse{
-		"id":    user.ID,
-		"name":  user.Name,
-		"email": user.Email,
+		"id":             user.ID,
+		"name":           user.Name,
+		"email":          user.Email,
+		"role":           user.Role,
+		"email_verified": user.HasVerifiedEmail(),
+		"two_factor":     user.HasTwoFactorEnabled(),
 	}
 }
 
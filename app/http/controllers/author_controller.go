@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"errors"
+	"goravel/app/helpers"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"goravel/app/services"
+	"strconv"
+	"strings"
+
+	"github.com/goravel/framework/contracts/http"
+)
+
+type AuthorController struct {
+	service services.AuthorService
+}
+
+func NewAuthorController() *AuthorController {
+	return &AuthorController{service: services.NewAuthorService(repositories.NewAuthorRepository(), newBookService())}
+}
+
+// Index lists authors by name a page at a time. q narrows the list to names
+// containing it.
+func (r *AuthorController) Index(ctx http.Context) http.Response {
+	page, err := strconv.Atoi(ctx.Request().Query("page", "1"))
+	if err != nil || page < 1 {
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{"page": "page must be a positive integer"})
+	}
+	perPage, err := strconv.Atoi(ctx.Request().Query("per_page", strconv.Itoa(defaultPerPage)))
+	if err != nil || perPage < 1 || perPage > maxPerPage {
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{
+			"per_page": "per_page must be an integer between 1 and " + strconv.Itoa(maxPerPage),
+		})
+	}
+
+	authors, total, err := r.service.ListAuthors(strings.TrimSpace(ctx.Request().Query("q")), page, perPage)
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to fetch authors", err.Error())
+	}
+
+	meta := helpers.PageMeta(ctx, total, page, perPage)
+	return helpers.Paginated(ctx, "Authors retrieved successfully", helpers.ToAuthorResponseList(authors), meta)
+}
+
+func (r *AuthorController) Show(ctx http.Context) http.Response {
+	author, err := r.service.GetAuthor(uint(ctx.Request().RouteInt("id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrAuthorNotFound) {
+			return helpers.Error(ctx, 404, "Author not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch author", err.Error())
+	}
+
+	return helpers.Success(ctx, "Author retrieved successfully", helpers.ToAuthorResponse(author))
+}
+
+// Books lists the books an author contributed to, with the same paging,
+// filters and sorting as the book index.
+func (r *AuthorController) Books(ctx http.Context) http.Response {
+	filter, errs := bookFilterFromQuery(ctx)
+	if len(errs) > 0 {
+		return helpers.Error(ctx, 400, "Validation failed", errs)
+	}
+
+	page, err := r.service.ListBooks(uint(ctx.Request().RouteInt("id")), filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrAuthorNotFound):
+			return helpers.Error(ctx, 404, "Author not found", err.Error())
+		case errors.Is(err, repositories.ErrInvalidCursor):
+			return helpers.Error(ctx, 400, "Validation failed", map[string]string{"cursor": err.Error()})
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch books", err.Error())
+	}
+
+	meta := helpers.PageMeta(ctx, page.Total, filter.Page, filter.PerPage)
+	// Any offset page can hand over to the cursor from its last row.
+	meta.NextCursor = page.NextCursor
+	if filter.Cursor != "" {
+		meta = helpers.CursorMeta(ctx, page.Total, filter.PerPage, page.NextCursor)
+	}
+
+	return helpers.Paginated(ctx, "Books retrieved successfully", helpers.ToBookResponseList(page.Books), meta)
+}
+
+func (r *AuthorController) Store(ctx http.Context) http.Response {
+	author := &models.Author{}
+	if resp := fillAuthor(ctx, author); resp != nil {
+		return resp
+	}
+
+	if err := r.service.CreateAuthor(author); err != nil {
+		return helpers.Error(ctx, 500, "Failed to create author", err.Error())
+	}
+
+	return helpers.Created(ctx, "Author created successfully", helpers.ToAuthorResponse(author))
+}
+
+func (r *AuthorController) Update(ctx http.Context) http.Response {
+	author, err := r.service.GetAuthor(uint(ctx.Request().RouteInt("id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrAuthorNotFound) {
+			return helpers.Error(ctx, 404, "Author not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch author", err.Error())
+	}
+
+	if resp := fillAuthor(ctx, author); resp != nil {
+		return resp
+	}
+
+	if err := r.service.UpdateAuthor(author); err != nil {
+		return helpers.Error(ctx, 500, "Failed to update author", err.Error())
+	}
+
+	return helpers.Success(ctx, "Author updated successfully", helpers.ToAuthorResponse(author))
+}
+
+func (r *AuthorController) Destroy(ctx http.Context) http.Response {
+	author, err := r.service.GetAuthor(uint(ctx.Request().RouteInt("id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrAuthorNotFound) {
+			return helpers.Error(ctx, 404, "Author not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch author", err.Error())
+	}
+
+	if err := r.service.DeleteAuthor(author); err != nil {
+		if errors.Is(err, repositories.ErrAuthorHasBooks) {
+			return helpers.Error(ctx, 409, "Failed to delete author", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to delete author", err.Error())
+	}
+
+	return helpers.Success(ctx, "Author deleted successfully", nil)
+}
+
+// fillAuthor validates the request and copies it onto author. It returns the
+// error response to send, if any. Empty years clear them.
+func fillAuthor(ctx http.Context, author *models.Author) http.Response {
+	validation, err := ctx.Request().Validate(map[string]string{
+		"name":        "required|string|max_len:255",
+		"biography":   "string",
+		"birth_year":  "integer",
+		"death_year":  "integer",
+		"nationality": "string|max_len:100",
+		"website":     "string|max_len:255",
+	})
+
+	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	years := map[string]**int{"birth_year": &author.BirthYear, "death_year": &author.DeathYear}
+	for key, field := range years {
+		value := ctx.Request().Input(key)
+		if value == "" {
+			*field = nil
+			continue
+		}
+		year, err := strconv.Atoi(value)
+		if err != nil {
+			return helpers.Error(ctx, 400, "Invalid "+strings.ReplaceAll(key, "_", " ")+" value", err.Error())
+		}
+		*field = &year
+	}
+	if author.BirthYear != nil && author.DeathYear != nil && *author.DeathYear < *author.BirthYear {
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{"death_year": "death_year may not be before birth_year"})
+	}
+
+	author.Name = strings.TrimSpace(ctx.Request().Input("name"))
+	author.Biography = ctx.Request().Input("biography")
+	author.Nationality = ctx.Request().Input("nationality")
+	author.Website = ctx.Request().Input("website")
+	return nil
+}
@@ -1,94 +1,345 @@
 package controllers
 
 import (
+	"bytes"
+	"errors"
 	"goravel/app/helpers"
 	"goravel/app/models"
 	"goravel/app/repositories"
 	"goravel/app/services"
+	"os"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/database/orm"
+	"github.com/goravel/framework/facades"
 )
 
 type BookController struct {
 	service services.BookService
+	imports services.BookImportService
+	covers  services.BookCoverService
 }
 
 func NewBookController() *BookController {
 	repo := repositories.NewBookRepository()
-	service := services.NewBookService(repo)
-	return &BookController{service: service}
+	service := newBookService()
+	copies := services.NewBookCopyService(repositories.NewBookCopyRepository(), repo)
+	imports := services.NewBookImportService(service, repo, copies)
+	covers := services.NewBookCoverService(repo)
+	return &BookController{service: service, imports: imports, covers: covers}
 }
 
+// newBookService wires the book service the way every controller that
+// reads or writes books needs it.
+func newBookService() services.BookService {
+	repo := repositories.NewBookRepository()
+	return services.NewBookService(
+		repo,
+		services.NewBookSearchService(repo),
+		repositories.NewAuthorRepository(),
+		repositories.NewCategoryRepository(),
+		repositories.NewTagRepository(),
+	)
+}
+
+const (
+	defaultPerPage = 15
+	maxPerPage     = 100
+)
+
+// Index lists the catalog a page at a time. It accepts page/per_page or a
+// cursor from a previous response, the author, author_id, category_id, tag,
+// title, year_from, year_to and in_stock filters, and sort=field or sort=-field for descending order.
 func (r *BookController) Index(ctx http.Context) http.Response {
-	books, err := r.service.GetAllBook()
+	filter, errs := bookFilterFromQuery(ctx)
+	if len(errs) > 0 {
+		return helpers.Error(ctx, 400, "Validation failed", errs)
+	}
+
+	page, err := r.service.ListBooks(filter)
 	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidCursor) {
+			return helpers.Error(ctx, 400, "Validation failed", map[string]string{"cursor": err.Error()})
+		}
 		return helpers.Error(ctx, 500, "Failed to fetch books", err.Error())
 	}
 
-	bookResponses := helpers.ToBookResponseList(books)
+	meta := helpers.PageMeta(ctx, page.Total, filter.Page, filter.PerPage)
+	// Any offset page can hand over to the cursor from its last row.
+	meta.NextCursor = page.NextCursor
+	if filter.Cursor != "" {
+		meta = helpers.CursorMeta(ctx, page.Total, filter.PerPage, page.NextCursor)
+	}
 
-	return helpers.Success(ctx, "Books retrieved successfully", bookResponses)
+	bookResponses := helpers.ToBookResponseList(page.Books)
+
+	return helpers.Paginated(ctx, "Books retrieved successfully", bookResponses, meta)
 }
 
+// Search ranks books matching q across title and author. Each result carries
+// its score and the title and author with matched words highlighted.
+func (r *BookController) Search(ctx http.Context) http.Response {
+	query := strings.TrimSpace(ctx.Request().Query("q"))
+	if query == "" {
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{"q": "q is required"})
+	}
+
+	limit, err := strconv.Atoi(ctx.Request().Query("limit", strconv.Itoa(defaultPerPage)))
+	if err != nil || limit < 1 || limit > maxPerPage {
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{
+			"limit": "limit must be an integer between 1 and " + strconv.Itoa(maxPerPage),
+		})
+	}
+
+	hits, err := r.service.SearchBooks(query, limit)
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to search books", err.Error())
+	}
+
+	results := make([]helpers.BookResponse, 0, len(hits))
+	for _, hit := range hits {
+		result := helpers.ToBookResponse(&hit.Book)
+		result["score"] = hit.Score
+		result["highlights"] = hit.Highlights
+		results = append(results, result)
+	}
+
+	return helpers.Success(ctx, "Books retrieved successfully", results)
+}
+
+// Show returns a book as JSON, or as a MARC record when the id carries a
+// .marcxml or .mrc suffix. The router can't tell /books/{id}.marcxml apart
+// from /books/{id}, so the suffix is handled here.
 func (r *BookController) Show(ctx http.Context) http.Response {
-	book, err := r.service.GetByIDBook(ctx.Request().Input("id"))
+	id := ctx.Request().Route("id")
+	format := ""
+	for _, suffix := range []string{".marcxml", ".mrc"} {
+		if strings.HasSuffix(id, suffix) {
+			id, format = strings.TrimSuffix(id, suffix), suffix
+			break
+		}
+	}
+
+	book, err := r.service.GetByIDBook(id)
 	if err != nil {
 		return helpers.Error(ctx, 404, "Book not found", err.Error())
 	}
 
+	switch format {
+	case ".marcxml":
+		var buf bytes.Buffer
+		if err := helpers.WriteMARCXML(&buf, services.BookMARCRecord(book)); err != nil {
+			return helpers.Error(ctx, 500, "Failed to export book", err.Error())
+		}
+		return ctx.Response().Data(200, "application/marcxml+xml", buf.Bytes())
+	case ".mrc":
+		var buf bytes.Buffer
+		if err := helpers.WriteMARC21(&buf, services.BookMARCRecord(book)); err != nil {
+			return helpers.Error(ctx, 500, "Failed to export book", err.Error())
+		}
+		return ctx.Response().Data(200, "application/marc", buf.Bytes())
+	}
+
 	return helpers.Success(ctx, "Book retrieved successfully", helpers.ToBookResponse(book))
 }
 
+// ShowByISBN looks a book up by its ISBN-10 or ISBN-13, with or without
+// hyphens.
+func (r *BookController) ShowByISBN(ctx http.Context) http.Response {
+	book, err := r.service.GetByISBNBook(ctx.Request().Route("isbn"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidISBN) {
+			return helpers.Error(ctx, 400, "Invalid ISBN", err.Error())
+		}
+		return helpers.Error(ctx, 404, "Book not found", err.Error())
+	}
+
+	return helpers.Success(ctx, "Book retrieved successfully", helpers.ToBookResponse(book))
+}
+
+// Store creates a book. An optional stock adds that many available copies
+// with generated barcodes.
 func (r *BookController) Store(ctx http.Context) http.Response {
-	validation, err := ctx.Request().Validate(map[string]string{
-		"author":         "required|string|max_len:255",
-		"title":          "required|string|max_len:255",
-		"published_year": "required|integer",
-		"stock":          "required|integer",
-	})
+	book := &models.Book{}
+	if resp := r.fill(ctx, book); resp != nil {
+		return resp
+	}
+
+	stock := 0
+	if stockStr := ctx.Request().Input("stock"); stockStr != "" {
+		var err error
+		stock, err = strconv.Atoi(stockStr)
+		if err != nil || stock < 0 {
+			return helpers.Error(ctx, 400, "Invalid stock value", "stock must be a non-negative integer")
+		}
+	}
+
+	book.Stock = stock
+	if err := r.service.CreateBook(book); err != nil {
+		if errors.Is(err, services.ErrDuplicateISBN) {
+			return helpers.Error(ctx, 409, "Failed to create book", err.Error())
+		}
+		if errors.Is(err, repositories.ErrAuthorNotFound) {
+			return helpers.Error(ctx, 400, "Validation failed", map[string]string{"authors": err.Error()})
+		}
+		if errors.Is(err, repositories.ErrCategoryNotFound) {
+			return helpers.Error(ctx, 400, "Validation failed", map[string]string{"category_ids": err.Error()})
+		}
+		return helpers.Error(ctx, 500, "Failed to create book", err.Error())
+	}
+
+	return helpers.Success(ctx, "Book created successfully", helpers.ToBookResponse(book))
+}
 
+func (r *BookController) Update(ctx http.Context) http.Response {
+	book, err := r.service.GetByIDBook(ctx.Request().Input("id"))
 	if err != nil {
-		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+		return helpers.Error(ctx, 404, "Book not found", err.Error())
 	}
 
-	if validation.Fails() {
-		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	if resp := r.fill(ctx, book); resp != nil {
+		return resp
+	}
+
+	// Stock counts the available copies, so it can only change through the
+	// copies endpoints.
+	if stockStr := ctx.Request().Input("stock"); stockStr != "" && stockStr != strconv.Itoa(book.Stock) {
+		return helpers.Error(ctx, 400, "Invalid stock value", "stock is derived from the book's copies; manage them under /api/books/{id}/copies")
+	}
+
+	if err := r.service.UpdateBook(book); err != nil {
+		if errors.Is(err, services.ErrDuplicateISBN) {
+			return helpers.Error(ctx, 409, "Failed to update book", err.Error())
+		}
+		if errors.Is(err, repositories.ErrAuthorNotFound) {
+			return helpers.Error(ctx, 400, "Validation failed", map[string]string{"authors": err.Error()})
+		}
+		if errors.Is(err, repositories.ErrCategoryNotFound) {
+			return helpers.Error(ctx, 400, "Validation failed", map[string]string{"category_ids": err.Error()})
+		}
+		return helpers.Error(ctx, 500, "Failed to update book", err.Error())
+	}
+
+	return helpers.Success(ctx, "Book updated successfully", helpers.ToBookResponse(book))
+}
+
+func (r *BookController) Destroy(ctx http.Context) http.Response {
+	book, err := r.service.GetByIDBook(ctx.Request().Input("id"))
+	if err != nil {
+		return helpers.Error(ctx, 404, "Book not found", err.Error())
 	}
+	res, err := r.service.DeleteBook(book)
 
-	stockStr := ctx.Request().Input("stock")
-	stock, err := strconv.Atoi(stockStr)
 	if err != nil {
-		return helpers.Error(ctx, 400, "Invalid stock value", err.Error())
+		return helpers.Error(ctx, 500, "Failed to delete book", err.Error())
 	}
 
-	publishedYearStr := ctx.Request().Input("published_year")
-	publishedYear, err := strconv.Atoi(publishedYearStr)
+	// The row is gone, so a failure here only leaves orphaned files behind.
+	if err := r.covers.DeleteCover(book); err != nil {
+		facades.Log().Errorf("delete cover files of book %d error: %+v", book.ID, err)
+	}
+
+	return helpers.Success(ctx, "Book deleted successfully", res)
+}
+
+// Import creates and updates books from an uploaded CSV file and reports
+// the rows it could not import. dry_run validates the file without saving.
+func (r *BookController) Import(ctx http.Context) http.Response {
+	upload, err := ctx.Request().File("file")
 	if err != nil {
-		return helpers.Error(ctx, 400, "Invalid published year value", err.Error())
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{"file": "file is required"})
 	}
 
-	book := &models.Book{
-		Author:        ctx.Request().Input("author"),
-		Title:         ctx.Request().Input("title"),
-		PublishedYear: publishedYear,
-		Stock:         stock,
+	file, err := os.Open(upload.File())
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to read upload", err.Error())
 	}
+	defer file.Close()
 
-	if err := r.service.CreateBook(book); err != nil {
-		return helpers.Error(ctx, 500, "Failed to create book", err.Error())
+	report, err := r.imports.Import(file, ctx.Request().InputBool("dry_run"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCSVHeader) {
+			return helpers.Error(ctx, 400, "Invalid CSV file", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to import books", err.Error())
 	}
 
-	return helpers.Success(ctx, "Book created successfully", helpers.ToBookResponse(book))
+	return helpers.Success(ctx, report.String(), report)
 }
 
-func (r *BookController) Update(ctx http.Context) http.Response {
-	validation, err := ctx.Request().Validate(map[string]string{
-		"author":         "required|string|max_len:255",
-		"title":          "required|string|max_len:255",
-		"published_year": "required|integer",
-		"stock":          "required|integer",
+// Export streams the whole catalog as CSV in the format Import reads.
+func (r *BookController) Export(ctx http.Context) http.Response {
+	ctx.Response().
+		Header("Content-Type", "text/csv; charset=utf-8").
+		Header("Content-Disposition", `attachment; filename="books.csv"`)
+
+	return ctx.Response().Stream(200, func(w http.StreamWriter) error {
+		return r.imports.Export(w)
 	})
+}
+
+// bookFilterFromQuery reads the Index query string. Query values arrive as
+// strings, so numbers are parsed here rather than by the integer rule.
+func bookFilterFromQuery(ctx http.Context) (repositories.BookFilter, map[string]string) {
+	request := ctx.Request()
+	errs := map[string]string{}
+	number := func(key string, fallback, min, max int) int {
+		value := request.Query(key)
+		if value == "" {
+			return fallback
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < min || n > max {
+			errs[key] = key + " must be an integer between " + strconv.Itoa(min) + " and " + strconv.Itoa(max)
+		}
+		return n
+	}
+
+	filter := repositories.BookFilter{
+		Author:     strings.TrimSpace(request.Query("author")),
+		AuthorID:   uint(number("author_id", 0, 0, 1<<31-1)),
+		CategoryID: uint(number("category_id", 0, 0, 1<<31-1)),
+		Title:      strings.TrimSpace(request.Query("title")),
+		Page:       number("page", 1, 1, 1<<20),
+		PerPage:    number("per_page", defaultPerPage, 1, maxPerPage),
+		YearFrom:   number("year_from", 0, 0, 9999),
+		YearTo:     number("year_to", 0, 0, 9999),
+		Cursor:     request.Query("cursor"),
+	}
+
+	if inStock := request.Query("in_stock"); inStock != "" {
+		value, err := strconv.ParseBool(inStock)
+		if err != nil {
+			errs["in_stock"] = "in_stock must be a boolean"
+		}
+		filter.InStock = value
+	}
+
+	// tag=a,b matches books carrying both tags.
+	for _, tag := range strings.Split(request.Query("tag"), ",") {
+		if slug := helpers.Slugify(tag); slug != "" {
+			filter.Tags = append(filter.Tags, slug)
+		}
+	}
+
+	sort := request.Query("sort")
+	filter.Desc = strings.HasPrefix(sort, "-")
+	filter.Sort = strings.TrimPrefix(sort, "-")
+	if filter.Sort != "" && !slices.Contains(repositories.BookSortFields, filter.Sort) {
+		errs["sort"] = "sort must be one of " + strings.Join(repositories.BookSortFields, ", ") + ", optionally prefixed with -"
+	}
+
+	return filter, errs
+}
+
+// fill validates the request and copies it onto book. It returns the error
+// response to send, if any. Contributors come from authors when given, and
+// from the names in the author string otherwise.
+func (r *BookController) fill(ctx http.Context, book *models.Book) http.Response {
+	validation, err := ctx.Request().Validate(services.BookRules)
 
 	if err != nil {
 		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
@@ -98,42 +349,107 @@ func (r *BookController) Update(ctx http.Context) http.Response {
 		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
 	}
 
-	stockStr := ctx.Request().Input("stock")
-	stock, err := strconv.Atoi(stockStr)
-	if err != nil {
-		return helpers.Error(ctx, 400, "Invalid stock value", err.Error())
-	}
-
 	publishedYearStr := ctx.Request().Input("published_year")
 	publishedYear, err := strconv.Atoi(publishedYearStr)
 	if err != nil {
 		return helpers.Error(ctx, 400, "Invalid published year value", err.Error())
 	}
 
-	book := &models.Book{
-		Author:        ctx.Request().Input("author"),
-		Title:         ctx.Request().Input("title"),
-		PublishedYear: publishedYear,
-		Stock:         stock,
+	// An omitted isbn keeps the current one; an empty isbn removes it.
+	if _, ok := ctx.Request().All()["isbn"]; ok {
+		if err := r.service.SetISBN(book, ctx.Request().Input("isbn")); err != nil {
+			return helpers.Error(ctx, 400, "Invalid ISBN", err.Error())
+		}
 	}
 
-	if err := r.service.UpdateBook(book); err != nil {
-		return helpers.Error(ctx, 500, "Failed to update book", err.Error())
+	if _, ok := ctx.Request().All()["authors"]; ok {
+		credits, errs := bookAuthorsFromRequest(ctx)
+		if len(errs) > 0 {
+			return helpers.Error(ctx, 400, "Validation failed", errs)
+		}
+		book.Authors = credits
+	} else if author := ctx.Request().Input("author"); book.Authors == nil || author != book.Author {
+		// A new credit line replaces the contributors with the names in it.
+		// An unchanged one keeps them, editors and translators included.
+		book.Author = author
+		book.Authors = nil
 	}
 
-	return helpers.Success(ctx, "Book updated successfully", helpers.ToBookResponse(book))
+	// Omitted category_ids or tags keep the book's current ones.
+	if raw, ok := ctx.Request().All()["category_ids"]; ok {
+		ids, ok := raw.([]any)
+		if !ok {
+			return helpers.Error(ctx, 400, "Validation failed", map[string]string{"category_ids": "category_ids must be a list of category IDs"})
+		}
+		book.Categories = []models.Category{}
+		for _, value := range ids {
+			id, ok := value.(float64)
+			if !ok || id < 1 || id != float64(uint(id)) {
+				return helpers.Error(ctx, 400, "Validation failed", map[string]string{"category_ids": "category_ids must be a list of category IDs"})
+			}
+			book.Categories = append(book.Categories, models.Category{Model: orm.Model{ID: uint(id)}})
+		}
+	}
+	if raw, ok := ctx.Request().All()["tags"]; ok {
+		names, ok := raw.([]any)
+		if !ok {
+			return helpers.Error(ctx, 400, "Validation failed", map[string]string{"tags": "tags must be a list of names"})
+		}
+		book.Tags = []models.Tag{}
+		for _, value := range names {
+			name, ok := value.(string)
+			if !ok || len(strings.TrimSpace(name)) > 100 {
+				return helpers.Error(ctx, 400, "Validation failed", map[string]string{"tags": "tags must be names of at most 100 characters"})
+			}
+			book.Tags = append(book.Tags, models.Tag{Name: strings.TrimSpace(name)})
+		}
+	}
+
+	book.Title = ctx.Request().Input("title")
+	book.PublishedYear = publishedYear
+	return nil
 }
 
-func (r *BookController) Destroy(ctx http.Context) http.Response {
-	book, err := r.service.GetByIDBook(ctx.Request().Input("id"))
-	if err != nil {
-		return helpers.Error(ctx, 404, "Book not found", err.Error())
+// bookAuthorsFromRequest reads the authors list of a book request. Each entry
+// is an author name, or an object with the id or name of the author and an
+// optional role, which defaults to author. Entries are kept in order.
+func bookAuthorsFromRequest(ctx http.Context) ([]models.BookAuthor, map[string]string) {
+	errs := map[string]string{}
+	entries, ok := ctx.Request().All()["authors"].([]any)
+	if !ok || len(entries) == 0 {
+		errs["authors"] = "authors must be a non-empty list"
+		return nil, errs
 	}
-	res, err := r.service.DeleteBook(book)
 
-	if err != nil {
-		return helpers.Error(ctx, 500, "Failed to delete book", err.Error())
+	credits := make([]models.BookAuthor, 0, len(entries))
+	for i, entry := range entries {
+		key := "authors." + strconv.Itoa(i)
+		credit := models.BookAuthor{Role: models.ContributorAuthor}
+
+		switch value := entry.(type) {
+		case string:
+			credit.Author = &models.Author{Name: strings.TrimSpace(value)}
+		case map[string]any:
+			if id, ok := value["id"].(float64); ok && id > 0 && id == float64(uint(id)) {
+				credit.AuthorID = uint(id)
+			} else if name, ok := value["name"].(string); ok {
+				credit.Author = &models.Author{Name: strings.TrimSpace(name)}
+			}
+			if role, ok := value["role"]; ok {
+				credit.Role, _ = role.(string)
+				if !slices.Contains(models.ContributorRoles, credit.Role) {
+					errs[key+".role"] = "role must be one of " + strings.Join(models.ContributorRoles, ", ")
+				}
+			}
+		}
+
+		if credit.AuthorID == 0 && (credit.Author == nil || credit.Author.Name == "") {
+			errs[key] = "each author needs an id or a name"
+		} else if credit.Author != nil && len(credit.Author.Name) > 255 {
+			errs[key+".name"] = "name may not be longer than 255 characters"
+		}
+		credits = append(credits, credit)
 	}
 
-	return helpers.Success(ctx, "Book deleted successfully", res)
+	return credits, errs
 }
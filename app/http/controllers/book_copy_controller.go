@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"errors"
+	"goravel/app/helpers"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"goravel/app/services"
+	"strings"
+
+	"github.com/goravel/framework/contracts/http"
+)
+
+type BookCopyController struct {
+	service services.BookCopyService
+}
+
+func NewBookCopyController() *BookCopyController {
+	service := services.NewBookCopyService(repositories.NewBookCopyRepository(), repositories.NewBookRepository())
+	return &BookCopyController{service: service}
+}
+
+func (r *BookCopyController) Index(ctx http.Context) http.Response {
+	copies, err := r.service.ListCopies(uint(ctx.Request().RouteInt("id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrBookNotFound) {
+			return helpers.Error(ctx, 404, "Book not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch copies", err.Error())
+	}
+
+	return helpers.Success(ctx, "Copies retrieved successfully", helpers.ToBookCopyResponseList(copies))
+}
+
+func (r *BookCopyController) Show(ctx http.Context) http.Response {
+	bookCopy, err := r.service.GetCopy(uint(ctx.Request().RouteInt("id")), uint(ctx.Request().RouteInt("copy_id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrCopyNotFound) {
+			return helpers.Error(ctx, 404, "Copy not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch copy", err.Error())
+	}
+
+	return helpers.Success(ctx, "Copy retrieved successfully", helpers.ToBookCopyResponse(bookCopy))
+}
+
+// Store adds a copy to the book. The barcode is generated when omitted.
+func (r *BookCopyController) Store(ctx http.Context) http.Response {
+	if resp := validateCopy(ctx, false); resp != nil {
+		return resp
+	}
+
+	bookCopy := &models.BookCopy{
+		BookID:        uint(ctx.Request().RouteInt("id")),
+		Barcode:       strings.TrimSpace(ctx.Request().Input("barcode")),
+		ShelfLocation: ctx.Request().Input("shelf_location"),
+		Condition:     ctx.Request().Input("condition"),
+	}
+
+	if err := r.service.CreateCopy(bookCopy); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrBookNotFound):
+			return helpers.Error(ctx, 404, "Book not found", err.Error())
+		case errors.Is(err, services.ErrDuplicateBarcode):
+			return helpers.Error(ctx, 409, "Failed to create copy", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to create copy", err.Error())
+	}
+
+	return helpers.Created(ctx, "Copy created successfully", helpers.ToBookCopyResponse(bookCopy))
+}
+
+// Update changes a copy's details. Copies on loan can't change status, and
+// on_loan can only be set by borrowing the copy.
+func (r *BookCopyController) Update(ctx http.Context) http.Response {
+	bookCopy, err := r.service.GetCopy(uint(ctx.Request().RouteInt("id")), uint(ctx.Request().RouteInt("copy_id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrCopyNotFound) {
+			return helpers.Error(ctx, 404, "Copy not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch copy", err.Error())
+	}
+
+	if resp := validateCopy(ctx, true); resp != nil {
+		return resp
+	}
+
+	if barcode := strings.TrimSpace(ctx.Request().Input("barcode")); barcode != "" {
+		bookCopy.Barcode = barcode
+	}
+	if shelfLocation, ok := ctx.Request().All()["shelf_location"]; ok {
+		bookCopy.ShelfLocation, _ = shelfLocation.(string)
+	}
+	if condition := ctx.Request().Input("condition"); condition != "" {
+		bookCopy.Condition = condition
+	}
+	if status := ctx.Request().Input("status"); status != "" {
+		bookCopy.Status = status
+	}
+
+	if err := r.service.UpdateCopy(bookCopy); err != nil {
+		switch {
+		case errors.Is(err, services.ErrDuplicateBarcode), errors.Is(err, repositories.ErrCopyOnLoan), errors.Is(err, repositories.ErrCopyOnHold):
+			return helpers.Error(ctx, 409, "Failed to update copy", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to update copy", err.Error())
+	}
+
+	return helpers.Success(ctx, "Copy updated successfully", helpers.ToBookCopyResponse(bookCopy))
+}
+
+func (r *BookCopyController) Destroy(ctx http.Context) http.Response {
+	bookCopy, err := r.service.GetCopy(uint(ctx.Request().RouteInt("id")), uint(ctx.Request().RouteInt("copy_id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrCopyNotFound) {
+			return helpers.Error(ctx, 404, "Copy not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch copy", err.Error())
+	}
+
+	if err := r.service.DeleteCopy(bookCopy); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrCopyOnLoan), errors.Is(err, repositories.ErrCopyOnHold), errors.Is(err, repositories.ErrCopyHasLoans):
+			return helpers.Error(ctx, 409, "Failed to delete copy", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to delete copy", err.Error())
+	}
+
+	return helpers.Success(ctx, "Copy deleted successfully", nil)
+}
+
+// validateCopy checks a copy request body and returns an error response, or
+// nil when the request is valid.
+func validateCopy(ctx http.Context, withStatus bool) http.Response {
+	rules := map[string]string{
+		"barcode":        "string|max_len:64",
+		"shelf_location": "string|max_len:100",
+		"condition":      "string|in:" + strings.Join(models.CopyConditions, ","),
+	}
+	if withStatus {
+		rules["status"] = "string|in:" + strings.Join([]string{models.CopyAvailable, models.CopyLost, models.CopyWithdrawn}, ",")
+	}
+
+	validation, err := ctx.Request().Validate(rules)
+	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"errors"
+	"goravel/app/helpers"
+	"goravel/app/repositories"
+	"goravel/app/services"
+	"os"
+
+	"github.com/goravel/framework/contracts/http"
+)
+
+type BookCoverController struct {
+	books  services.BookService
+	covers services.BookCoverService
+}
+
+func NewBookCoverController() *BookCoverController {
+	return &BookCoverController{
+		books:  newBookService(),
+		covers: services.NewBookCoverService(repositories.NewBookRepository()),
+	}
+}
+
+// Store replaces the book's cover with the uploaded JPEG, PNG or WebP image
+// and responds with the book, whose cover lists the generated sizes.
+func (r *BookCoverController) Store(ctx http.Context) http.Response {
+	book, err := r.books.GetByIDBook(ctx.Request().Route("id"))
+	if err != nil {
+		return helpers.Error(ctx, 404, "Book not found", err.Error())
+	}
+
+	upload, err := ctx.Request().File("file")
+	if err != nil {
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{"file": "file is required"})
+	}
+	if size, err := upload.Size(); err == nil && size > services.MaxCoverBytes {
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{"file": services.ErrCoverTooLarge.Error()})
+	}
+
+	file, err := os.Open(upload.File())
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to read upload", err.Error())
+	}
+	defer file.Close()
+
+	if err := r.covers.SaveCover(book, file); err != nil {
+		if errors.Is(err, services.ErrUnsupportedCover) || errors.Is(err, services.ErrCoverTooLarge) || errors.Is(err, services.ErrCoverDimensions) {
+			return helpers.Error(ctx, 400, "Validation failed", map[string]string{"file": err.Error()})
+		}
+		return helpers.Error(ctx, 500, "Failed to save cover", err.Error())
+	}
+
+	return helpers.Success(ctx, "Cover uploaded successfully", helpers.ToBookResponse(book))
+}
+
+func (r *BookCoverController) Destroy(ctx http.Context) http.Response {
+	book, err := r.books.GetByIDBook(ctx.Request().Route("id"))
+	if err != nil {
+		return helpers.Error(ctx, 404, "Book not found", err.Error())
+	}
+
+	if err := r.covers.DeleteCover(book); err != nil {
+		return helpers.Error(ctx, 500, "Failed to delete cover", err.Error())
+	}
+
+	return helpers.Success(ctx, "Cover deleted successfully", helpers.ToBookResponse(book))
+}
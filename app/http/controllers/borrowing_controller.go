@@ -1,12 +1,16 @@
 package controllers
 
 import (
+	"errors"
 	"goravel/app/helpers"
 	"goravel/app/models"
 	"goravel/app/repositories"
 	"goravel/app/services"
+	"slices"
+	"strings"
 
 	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/facades"
 )
 
 type BorrowingController struct {
@@ -14,63 +18,238 @@ type BorrowingController struct {
 }
 
 func NewBorrowingController() *BorrowingController {
-	repo := repositories.NewBorrowingRepository()
-	service := services.NewBorrowingService(repo)
+	service := services.NewBorrowingService(
+		repositories.NewBorrowingRepository(),
+		repositories.NewBookCopyRepository(),
+		repositories.NewUserRepository(),
+		services.NewLoanPolicyService(repositories.NewLoanPolicyRepository(), repositories.NewCategoryRepository()),
+	)
 	return &BorrowingController{service: service}
 }
 
+// Index lists loans, only those in the status given by ?status= when set.
 func (r *BorrowingController) Index(ctx http.Context) http.Response {
-	borrowings, err := r.service.GetAllBorrowings()
+	status := ctx.Request().Query("status")
+	if status != "" && !slices.Contains(repositories.BorrowingStatuses, status) {
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{
+			"status": "status must be one of " + strings.Join(repositories.BorrowingStatuses, ", "),
+		})
+	}
+
+	borrowings, err := r.service.GetAllBorrowings(status)
 	if err != nil {
-		return helpers.Error(ctx, 500, "Failed to fetch borrowings", err.Error)
+		return helpers.Error(ctx, 500, "Failed to fetch borrowings", err.Error())
 	}
 	borrowingResponses := helpers.ToBorrowingResponseList(borrowings)
 	return helpers.Success(ctx, "Borrowings retrieved successfully", borrowingResponses)
 }
 
+// Borrow lends a book to the authenticated user.
 func (r *BorrowingController) Borrow(ctx http.Context) http.Response {
-	borrowing := &models.Borrowing{}
-	err := ctx.Request().Bind(borrowing)
+	if resp := validateCirculation(ctx, false); resp != nil {
+		return resp
+	}
+
+	return r.borrow(ctx, helpers.CurrentUser(ctx).ID)
+}
+
+// BorrowFor lends a book to the user named by user_id. Admin only.
+func (r *BorrowingController) BorrowFor(ctx http.Context) http.Response {
+	if resp := validateCirculation(ctx, true); resp != nil {
+		return resp
+	}
+
+	return r.borrow(ctx, uint(ctx.Request().InputInt("user_id")))
+}
+
+// Return takes back a book from the authenticated user.
+func (r *BorrowingController) Return(ctx http.Context) http.Response {
+	if resp := validateCirculation(ctx, false); resp != nil {
+		return resp
+	}
+
+	return r.giveBack(ctx, helpers.CurrentUser(ctx).ID)
+}
+
+// ReturnFor takes back a book from the user named by user_id. Admin only.
+func (r *BorrowingController) ReturnFor(ctx http.Context) http.Response {
+	if resp := validateCirculation(ctx, true); resp != nil {
+		return resp
+	}
+
+	return r.giveBack(ctx, uint(ctx.Request().InputInt("user_id")))
+}
+
+// Renew extends one of the authenticated user's loans.
+func (r *BorrowingController) Renew(ctx http.Context) http.Response {
+	return r.renew(ctx, helpers.CurrentUser(ctx).ID)
+}
+
+// RenewFor extends any patron's loan. Admin only.
+func (r *BorrowingController) RenewFor(ctx http.Context) http.Response {
+	return r.renew(ctx, 0)
+}
+
+// MarkLost closes a loan whose copy is not coming back. Admin only.
+func (r *BorrowingController) MarkLost(ctx http.Context) http.Response {
+	borrowing, err := r.service.MarkLost(uint(ctx.Request().RouteInt("id")))
 	if err != nil {
-		return helpers.Error(ctx, 400, "Failed to bind borrowing data", err.Error())
+		switch {
+		case errors.Is(err, repositories.ErrBorrowingNotFound):
+			return helpers.Error(ctx, 404, "Borrowing not found", err.Error())
+		case errors.Is(err, repositories.ErrAlreadyReturned):
+			return helpers.Error(ctx, 409, "Book already returned", err.Error())
+		case errors.Is(err, repositories.ErrLoanLost):
+			return helpers.Error(ctx, 409, "Loan already marked lost", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to mark loan lost", err.Error())
 	}
 
+	return helpers.Success(ctx, "Loan marked lost", helpers.ToBorrowingResponse(borrowing))
+}
+
+// Mine lists the authenticated user's borrowings.
+func (r *BorrowingController) Mine(ctx http.Context) http.Response {
+	borrowings, err := r.service.FindByUserIDBorrowing(helpers.CurrentUser(ctx).ID)
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to fetch borrowing data", err.Error())
+	}
+
+	return helpers.Success(ctx, "Borrowing data retrieved successfully", helpers.ToBorrowingResponseList(borrowings))
+}
+
+func (r *BorrowingController) FindByUserID(ctx http.Context) http.Response {
 	userID := ctx.Request().Input("user_id")
-	bookID := ctx.Request().Input("book_id")
 
-	err = r.service.BorrowingUser(borrowing, userID, bookID)
+	borrowings, err := r.service.FindByUserIDBorrowing(userID)
 	if err != nil {
-		return helpers.Error(ctx, 500, "Failed to borrow book", err.Error())
+		return helpers.Error(ctx, 500, "Failed to fetch borrowing data", err.Error())
 	}
 
-	return helpers.Success(ctx, "Book borrowed successfully", helpers.ToBorrowingResponse(borrowing))
+	return helpers.Success(ctx, "Borrowing data retrieved successfully", helpers.ToBorrowingResponseList(borrowings))
 }
 
-func (r *BorrowingController) Return(ctx http.Context) http.Response {
+func (r *BorrowingController) borrow(ctx http.Context, userID uint) http.Response {
+	bookID := uint(ctx.Request().InputInt("book_id"))
+	barcode := ctx.Request().Input("barcode")
+
 	borrowing := &models.Borrowing{}
-	err := ctx.Request().Bind(borrowing)
+	err := r.service.BorrowingUser(borrowing, userID, bookID, barcode)
 	if err != nil {
-		return helpers.Error(ctx, 400, "Failed to bind borrowing data", err.Error())
+		var limitErr *repositories.LoanLimitError
+		var fineErr *repositories.FineBalanceError
+		switch {
+		case errors.As(err, &limitErr):
+			return helpers.Error(ctx, 409, "Loan limit reached", loanLimitResponse(limitErr))
+		case errors.As(err, &fineErr):
+			return helpers.Error(ctx, 409, "Outstanding fines", fineBalanceResponse(fineErr))
+		case errors.Is(err, repositories.ErrUserNotFound):
+			return helpers.Error(ctx, 404, "User not found", err.Error())
+		case errors.Is(err, repositories.ErrBookNotFound):
+			return helpers.Error(ctx, 404, "Book not found", err.Error())
+		case errors.Is(err, repositories.ErrCopyNotFound):
+			return helpers.Error(ctx, 404, "Copy not found", err.Error())
+		case errors.Is(err, repositories.ErrBookOutOfStock):
+			return helpers.Error(ctx, 409, "Book is out of stock", err.Error())
+		case errors.Is(err, repositories.ErrCopyUnavailable):
+			return helpers.Error(ctx, 409, "Copy is not available", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to borrow book", err.Error())
 	}
 
-	userID := ctx.Request().Input("user_id")
-	bookID := ctx.Request().Input("book_id")
+	return helpers.Success(ctx, "Book borrowed successfully", helpers.ToBorrowingResponse(borrowing))
+}
 
-	err = r.service.ReturnUserBorrowing(borrowing, userID, bookID)
+func (r *BorrowingController) giveBack(ctx http.Context, userID uint) http.Response {
+	bookID := uint(ctx.Request().InputInt("book_id"))
+	barcode := ctx.Request().Input("barcode")
+	condition := ctx.Request().Input("condition")
+
+	borrowing := &models.Borrowing{}
+	err := r.service.ReturnUserBorrowing(borrowing, userID, bookID, barcode, condition)
 	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrCopyNotFound):
+			return helpers.Error(ctx, 404, "Copy not found", err.Error())
+		case errors.Is(err, repositories.ErrBorrowingNotFound):
+			return helpers.Error(ctx, 404, "Borrowing not found", err.Error())
+		case errors.Is(err, repositories.ErrBookNotFound):
+			return helpers.Error(ctx, 404, "Book not found", err.Error())
+		case errors.Is(err, repositories.ErrAlreadyReturned):
+			return helpers.Error(ctx, 409, "Book already returned", err.Error())
+		}
 		return helpers.Error(ctx, 500, "Failed to return book", err.Error())
 	}
 
 	return helpers.Success(ctx, "Book returned successfully", helpers.ToBorrowingResponse(borrowing))
 }
 
-func (r *BorrowingController) FindByUserID(ctx http.Context) http.Response {
-	userID := ctx.Request().Input("user_id")
+func (r *BorrowingController) renew(ctx http.Context, userID uint) http.Response {
+	borrowing, err := r.service.RenewBorrowing(uint(ctx.Request().RouteInt("id")), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrBorrowingNotFound):
+			return helpers.Error(ctx, 404, "Borrowing not found", err.Error())
+		case errors.Is(err, repositories.ErrAlreadyReturned):
+			return helpers.Error(ctx, 409, "Book already returned", err.Error())
+		case errors.Is(err, repositories.ErrLoanLost), errors.Is(err, repositories.ErrRenewalLimit), errors.Is(err, repositories.ErrHasOverdueLoans), errors.Is(err, repositories.ErrBookOnHold):
+			return helpers.Error(ctx, 409, "Loan cannot be renewed", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to renew loan", err.Error())
+	}
 
-	borrowing, err := r.service.FindByUserIDBorrowing(userID)
+	return helpers.Success(ctx, "Loan renewed successfully", helpers.ToBorrowingResponse(borrowing))
+}
+
+// loanLimitResponse describes the limit a borrow ran into, so clients can
+// tell the patron what to return first.
+func loanLimitResponse(err *repositories.LoanLimitError) map[string]any {
+	response := map[string]any{
+		"code":          "loan_limit_exceeded",
+		"message":       err.Error(),
+		"limit":         err.Max,
+		"current_loans": err.Current,
+		"category_id":   nil,
+	}
+	if err.CategoryID != 0 {
+		response["category_id"] = err.CategoryID
+	}
+	return response
+}
+
+// fineBalanceResponse tells a blocked patron how much they owe and how far
+// under the threshold they have to get to borrow again.
+func fineBalanceResponse(err *repositories.FineBalanceError) map[string]any {
+	return map[string]any{
+		"code":      "fines_outstanding",
+		"message":   err.Error(),
+		"balance":   err.Balance,
+		"threshold": err.Max,
+		"currency":  facades.Config().GetString("fines.currency", "USD"),
+	}
+}
+
+// validateCirculation checks a borrow or return request body and returns an
+// error response, or nil when the request is valid. A copy is named by its
+// barcode, or by book_id to take any copy of the book.
+func validateCirculation(ctx http.Context, withUser bool) http.Response {
+	rules := map[string]string{
+		"book_id":   "required_without:barcode|integer",
+		"barcode":   "required_without:book_id|string|max_len:64",
+		"condition": "string|in:" + strings.Join(models.CopyConditions, ","),
+	}
+	if withUser {
+		rules["user_id"] = "required|integer"
+	}
+
+	validation, err := ctx.Request().Validate(rules)
 	if err != nil {
-		return helpers.Error(ctx, 500, "Failed to fetch borrowing data", err.Error())
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
 	}
 
-	return helpers.Success(ctx, "Borrowing data retrieved successfully", helpers.ToBorrowingResponse(borrowing))
+	return nil
 }
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"errors"
+	"goravel/app/helpers"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"goravel/app/services"
+	"strconv"
+	"strings"
+
+	"github.com/goravel/framework/contracts/http"
+)
+
+type CategoryController struct {
+	service services.CategoryService
+}
+
+func NewCategoryController() *CategoryController {
+	return &CategoryController{service: services.NewCategoryService(repositories.NewCategoryRepository())}
+}
+
+// Index returns the whole category tree with book counts on every node.
+func (r *CategoryController) Index(ctx http.Context) http.Response {
+	roots, err := r.service.Tree()
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to fetch categories", err.Error())
+	}
+
+	return helpers.Success(ctx, "Categories retrieved successfully", categoryTreeResponse(roots))
+}
+
+// Show returns a category with its subtree and the path of ancestors leading
+// to it.
+func (r *CategoryController) Show(ctx http.Context) http.Response {
+	node, ancestors, err := r.service.Subtree(uint(ctx.Request().RouteInt("id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrCategoryNotFound) {
+			return helpers.Error(ctx, 404, "Category not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch category", err.Error())
+	}
+
+	response := categoryNodeResponse(node)
+	response["ancestors"] = helpers.ToCategoryResponseList(ancestors)
+	return helpers.Success(ctx, "Category retrieved successfully", response)
+}
+
+func (r *CategoryController) Store(ctx http.Context) http.Response {
+	category := &models.Category{}
+	if resp := fillCategory(ctx, category); resp != nil {
+		return resp
+	}
+
+	if err := r.service.CreateCategory(category); err != nil {
+		return categoryErrorResponse(ctx, "Failed to create category", err)
+	}
+
+	return helpers.Created(ctx, "Category created successfully", helpers.ToCategoryResponse(category))
+}
+
+// Update renames or moves a category. Moving it takes its subtree along.
+func (r *CategoryController) Update(ctx http.Context) http.Response {
+	category, err := r.service.GetCategory(uint(ctx.Request().RouteInt("id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrCategoryNotFound) {
+			return helpers.Error(ctx, 404, "Category not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch category", err.Error())
+	}
+
+	if resp := fillCategory(ctx, category); resp != nil {
+		return resp
+	}
+
+	if err := r.service.UpdateCategory(category); err != nil {
+		return categoryErrorResponse(ctx, "Failed to update category", err)
+	}
+
+	return helpers.Success(ctx, "Category updated successfully", helpers.ToCategoryResponse(category))
+}
+
+func (r *CategoryController) Destroy(ctx http.Context) http.Response {
+	category, err := r.service.GetCategory(uint(ctx.Request().RouteInt("id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrCategoryNotFound) {
+			return helpers.Error(ctx, 404, "Category not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch category", err.Error())
+	}
+
+	if err := r.service.DeleteCategory(category); err != nil {
+		return categoryErrorResponse(ctx, "Failed to delete category", err)
+	}
+
+	return helpers.Success(ctx, "Category deleted successfully", nil)
+}
+
+// fillCategory validates the request and copies it onto category. It returns
+// the error response to send, if any. An empty parent_id makes the category
+// a root.
+func fillCategory(ctx http.Context, category *models.Category) http.Response {
+	validation, err := ctx.Request().Validate(map[string]string{
+		"name":      "required|string|max_len:255",
+		"slug":      "string|max_len:255",
+		"code":      "string|max_len:32",
+		"parent_id": "integer",
+	})
+
+	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	category.ParentID = nil
+	if parent := ctx.Request().Input("parent_id"); parent != "" {
+		parentID, err := strconv.ParseUint(parent, 10, 64)
+		if err != nil || parentID == 0 {
+			return helpers.Error(ctx, 400, "Validation failed", map[string]string{"parent_id": "parent_id must be a category ID"})
+		}
+		id := uint(parentID)
+		category.ParentID = &id
+	}
+
+	category.Name = strings.TrimSpace(ctx.Request().Input("name"))
+	// An omitted slug keeps the current one, or is derived from the name.
+	if _, ok := ctx.Request().All()["slug"]; ok {
+		category.Slug = ctx.Request().Input("slug")
+	}
+	category.Code = strings.TrimSpace(ctx.Request().Input("code"))
+	return nil
+}
+
+func categoryErrorResponse(ctx http.Context, message string, err error) http.Response {
+	switch {
+	case errors.Is(err, repositories.ErrCategoryNotFound):
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{"parent_id": "parent category not found"})
+	case errors.Is(err, repositories.ErrCategoryCycle):
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{"parent_id": err.Error()})
+	case errors.Is(err, services.ErrDuplicateSlug), errors.Is(err, repositories.ErrCategoryHasChildren):
+		return helpers.Error(ctx, 409, message, err.Error())
+	}
+	return helpers.Error(ctx, 500, message, err.Error())
+}
+
+func categoryTreeResponse(nodes []*services.CategoryNode) []helpers.CategoryResponse {
+	response := []helpers.CategoryResponse{}
+	for _, node := range nodes {
+		response = append(response, categoryNodeResponse(node))
+	}
+	return response
+}
+
+func categoryNodeResponse(node *services.CategoryNode) helpers.CategoryResponse {
+	response := helpers.ToCategoryResponse(&node.Category)
+	response["book_count"] = node.BookCount
+	response["total_book_count"] = node.TotalBookCount
+	response["children"] = categoryTreeResponse(node.Children)
+	return response
+}
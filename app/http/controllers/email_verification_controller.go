@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"errors"
+	"goravel/app/helpers"
+	"goravel/app/repositories"
+	"goravel/app/services"
+
+	"github.com/goravel/framework/contracts/http"
+)
+
+type EmailVerificationController struct {
+	service services.EmailVerificationService
+}
+
+func NewEmailVerificationController() *EmailVerificationController {
+	repo := repositories.NewUserRepository()
+	service := services.NewEmailVerificationService(repo)
+	return &EmailVerificationController{service: service}
+}
+
+func (r *EmailVerificationController) Verify(ctx http.Context) http.Response {
+	user, err := r.service.Verify(
+		uint(ctx.Request().RouteInt("id")),
+		ctx.Request().QueryInt64("expires"),
+		ctx.Request().Query("signature"),
+	)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSignature) {
+			return helpers.Error(ctx, 403, "Email verification failed", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Email verification failed", err.Error())
+	}
+
+	return helpers.Success(ctx, "Email verified successfully", helpers.ToUserResponse(user))
+}
+
+func (r *EmailVerificationController) Resend(ctx http.Context) http.Response {
+	if err := r.service.SendVerificationLink(helpers.CurrentUser(ctx)); err != nil {
+		if errors.Is(err, services.ErrEmailAlreadyVerified) {
+			return helpers.Error(ctx, 400, "Email already verified", nil)
+		}
+		return helpers.Error(ctx, 500, "Failed to send verification link", err.Error())
+	}
+
+	return helpers.Success(ctx, "Verification link sent", nil)
+}
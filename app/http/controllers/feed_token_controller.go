@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"goravel/app/helpers"
+	"goravel/app/repositories"
+	"goravel/app/services"
+
+	"github.com/goravel/framework/contracts/http"
+)
+
+type FeedTokenController struct {
+	service services.FeedTokenService
+}
+
+func NewFeedTokenController() *FeedTokenController {
+	return &FeedTokenController{
+		service: services.NewFeedTokenService(repositories.NewUserRepository()),
+	}
+}
+
+// Store issues the authenticated user a token for the OPDS feeds, replacing
+// any earlier one. E-reader apps sign in with username and token as the
+// Basic auth credentials. The token is not shown again.
+func (r *FeedTokenController) Store(ctx http.Context) http.Response {
+	user := helpers.CurrentUser(ctx)
+	token, err := r.service.Issue(user)
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to issue feed token", err.Error())
+	}
+
+	return helpers.Created(ctx, "Feed token issued", map[string]any{
+		"feed_url": opdsURL(ctx, "/opds"),
+		"username": user.Email,
+		"token":    token,
+	})
+}
+
+// Destroy revokes the authenticated user's feed token, signing every
+// e-reader app out of the feeds.
+func (r *FeedTokenController) Destroy(ctx http.Context) http.Response {
+	if err := r.service.Revoke(helpers.CurrentUser(ctx)); err != nil {
+		return helpers.Error(ctx, 500, "Failed to revoke feed token", err.Error())
+	}
+
+	return helpers.Success(ctx, "Feed token revoked", nil)
+}
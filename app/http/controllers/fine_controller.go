@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"errors"
+	"goravel/app/helpers"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"goravel/app/services"
+	"strings"
+
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/facades"
+)
+
+type FineController struct {
+	service services.FineService
+}
+
+func NewFineController() *FineController {
+	service := services.NewFineService(repositories.NewFineRepository())
+	return &FineController{service: service}
+}
+
+// Balance reports what a patron owes and whether it keeps them from
+// borrowing.
+func (r *FineController) Balance(ctx http.Context) http.Response {
+	userID := uint(ctx.Request().RouteInt("id"))
+	balance, err := r.service.Balance(userID)
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to fetch fine balance", err.Error())
+	}
+
+	return helpers.Success(ctx, "Fine balance retrieved successfully", fineSummary(userID, balance))
+}
+
+// Statement lists every entry in a patron's ledger with the running balance.
+func (r *FineController) Statement(ctx http.Context) http.Response {
+	userID := uint(ctx.Request().RouteInt("id"))
+	entries, err := r.service.Statement(userID)
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to fetch fine statement", err.Error())
+	}
+
+	statement := helpers.ToFineStatementResponse(entries)
+	var balance int64
+	for _, entry := range entries {
+		balance += entry.Amount
+	}
+
+	response := fineSummary(userID, balance)
+	response["entries"] = statement
+	return helpers.Success(ctx, "Fine statement retrieved successfully", response)
+}
+
+// Store records a charge, payment, waiver or refund against a loan.
+func (r *FineController) Store(ctx http.Context) http.Response {
+	validation, err := ctx.Request().Validate(map[string]string{
+		"type":   "required|in:" + strings.Join(models.FineTypes, ","),
+		"amount": "required|integer|min:1",
+		"note":   "string|max_len:255",
+	})
+	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	recordedBy := helpers.CurrentUser(ctx).ID
+	entry := &models.FineEntry{
+		BorrowingID: uint(ctx.Request().RouteInt("id")),
+		Type:        ctx.Request().Input("type"),
+		Amount:      ctx.Request().InputInt64("amount"),
+		Note:        ctx.Request().Input("note"),
+		RecordedBy:  &recordedBy,
+	}
+	if err := r.service.RecordEntry(entry); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrBorrowingNotFound):
+			return helpers.Error(ctx, 404, "Borrowing not found", err.Error())
+		case errors.Is(err, repositories.ErrFineExceedsBalance), errors.Is(err, repositories.ErrRefundExceedsPayments):
+			return helpers.Error(ctx, 409, "Failed to record fine entry", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to record fine entry", err.Error())
+	}
+
+	return helpers.Created(ctx, "Fine entry recorded successfully", helpers.ToFineEntryResponse(entry))
+}
+
+func fineSummary(userID uint, balance int64) map[string]any {
+	config := facades.Config()
+	threshold := int64(config.GetInt("fines.block_threshold", 1000))
+	return map[string]any{
+		"user_id":   userID,
+		"balance":   balance,
+		"currency":  config.GetString("fines.currency", "USD"),
+		"threshold": threshold,
+		"blocked":   threshold > 0 && balance > threshold,
+	}
+}
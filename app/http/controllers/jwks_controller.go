@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"goravel/app/helpers"
+	"goravel/app/repositories"
+	"goravel/app/services"
+
+	"github.com/goravel/framework/contracts/http"
+)
+
+type JwksController struct {
+	service services.KeyRingService
+}
+
+func NewJwksController() *JwksController {
+	repo := repositories.NewKeyRingRepository()
+	service := services.NewKeyRingService(repo)
+	return &JwksController{service: service}
+}
+
+// Index serves the public signing keys as a JSON Web Key Set. The body is the
+// bare key set rather than a JsonResponse so standard JWT libraries can read it.
+func (r *JwksController) Index(ctx http.Context) http.Response {
+	jwks, err := r.service.JWKS()
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to load signing keys", err.Error())
+	}
+
+	return ctx.Response().
+		Header("Cache-Control", "public, max-age=300").
+		Json(200, jwks)
+}
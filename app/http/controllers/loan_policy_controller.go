@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"errors"
+	"goravel/app/helpers"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"goravel/app/services"
+	"strconv"
+	"strings"
+
+	"github.com/goravel/framework/contracts/http"
+)
+
+type LoanPolicyController struct {
+	service services.LoanPolicyService
+}
+
+func NewLoanPolicyController() *LoanPolicyController {
+	service := services.NewLoanPolicyService(repositories.NewLoanPolicyRepository(), repositories.NewCategoryRepository())
+	return &LoanPolicyController{service: service}
+}
+
+func (r *LoanPolicyController) Index(ctx http.Context) http.Response {
+	policies, err := r.service.ListPolicies()
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to fetch loan policies", err.Error())
+	}
+
+	return helpers.Success(ctx, "Loan policies retrieved successfully", helpers.ToLoanPolicyResponseList(policies))
+}
+
+func (r *LoanPolicyController) Store(ctx http.Context) http.Response {
+	policy := &models.LoanPolicy{}
+	if resp := fillLoanPolicy(ctx, policy); resp != nil {
+		return resp
+	}
+
+	if err := r.service.CreatePolicy(policy); err != nil {
+		return loanPolicyErrorResponse(ctx, "Failed to create loan policy", err)
+	}
+
+	return helpers.Created(ctx, "Loan policy created successfully", helpers.ToLoanPolicyResponse(policy))
+}
+
+func (r *LoanPolicyController) Update(ctx http.Context) http.Response {
+	policy, err := r.service.GetPolicy(uint(ctx.Request().RouteInt("id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrLoanPolicyNotFound) {
+			return helpers.Error(ctx, 404, "Loan policy not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch loan policy", err.Error())
+	}
+
+	if resp := fillLoanPolicy(ctx, policy); resp != nil {
+		return resp
+	}
+
+	if err := r.service.UpdatePolicy(policy); err != nil {
+		return loanPolicyErrorResponse(ctx, "Failed to update loan policy", err)
+	}
+
+	return helpers.Success(ctx, "Loan policy updated successfully", helpers.ToLoanPolicyResponse(policy))
+}
+
+func (r *LoanPolicyController) Destroy(ctx http.Context) http.Response {
+	policy, err := r.service.GetPolicy(uint(ctx.Request().RouteInt("id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrLoanPolicyNotFound) {
+			return helpers.Error(ctx, 404, "Loan policy not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch loan policy", err.Error())
+	}
+
+	if err := r.service.DeletePolicy(policy); err != nil {
+		return helpers.Error(ctx, 500, "Failed to delete loan policy", err.Error())
+	}
+
+	return helpers.Success(ctx, "Loan policy deleted successfully", nil)
+}
+
+// fillLoanPolicy validates the request and copies it onto policy. It returns
+// the error response to send, if any. An empty role or category_id makes the
+// policy apply to every role or category.
+func fillLoanPolicy(ctx http.Context, policy *models.LoanPolicy) http.Response {
+	validation, err := ctx.Request().Validate(map[string]string{
+		"role":         "string|in:" + strings.Join([]string{models.RoleAdmin, models.RoleLibrarian, models.RoleMember}, ","),
+		"category_id":  "integer",
+		"loan_days":    "required|integer|min:1|max:365",
+		"max_loans":    "integer|min:0",
+		"max_renewals": "integer|min:0",
+	})
+
+	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	policy.CategoryID = nil
+	if category := ctx.Request().Input("category_id"); category != "" {
+		categoryID, err := strconv.ParseUint(category, 10, 64)
+		if err != nil || categoryID == 0 {
+			return helpers.Error(ctx, 400, "Validation failed", map[string]string{"category_id": "category_id must be a category ID"})
+		}
+		id := uint(categoryID)
+		policy.CategoryID = &id
+	}
+
+	policy.Role = ctx.Request().Input("role")
+	policy.LoanDays = ctx.Request().InputInt("loan_days")
+	policy.MaxLoans = ctx.Request().InputInt("max_loans")
+	policy.MaxRenewals = ctx.Request().InputInt("max_renewals")
+	return nil
+}
+
+func loanPolicyErrorResponse(ctx http.Context, message string, err error) http.Response {
+	switch {
+	case errors.Is(err, repositories.ErrCategoryNotFound):
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{"category_id": err.Error()})
+	case errors.Is(err, services.ErrDuplicateLoanPolicy):
+		return helpers.Error(ctx, 409, message, err.Error())
+	}
+	return helpers.Error(ctx, 500, message, err.Error())
+}
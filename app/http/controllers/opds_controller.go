@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"bytes"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"goravel/app/helpers"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"goravel/app/services"
+
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/facades"
+)
+
+type OpdsController struct {
+	books      services.BookService
+	categories services.CategoryService
+}
+
+func NewOpdsController() *OpdsController {
+	return &OpdsController{
+		books:      newBookService(),
+		categories: services.NewCategoryService(repositories.NewCategoryRepository()),
+	}
+}
+
+// Root is the navigation feed e-reader apps start from. It links to the
+// whole catalog, the newest additions and each top-level category.
+func (r *OpdsController) Root(ctx http.Context) http.Response {
+	feed := r.feed(ctx, "urn:catalog:root", facades.Config().GetString("app.name")+" catalog", helpers.OPDSNavigationType)
+	feed.Entries = []helpers.OPDSEntry{
+		navigationEntry("urn:catalog:books", "All books", "Browse the whole catalog", helpers.OPDSRelSubsection, opdsURL(ctx, "/opds/books")),
+		navigationEntry("urn:catalog:new", "New arrivals", "The latest additions to the catalog", helpers.OPDSRelNew, opdsURL(ctx, "/opds/new")),
+	}
+
+	roots, err := r.categories.Tree()
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to fetch categories", err.Error())
+	}
+	for _, node := range roots {
+		id := strconv.FormatUint(uint64(node.ID), 10)
+		feed.Entries = append(feed.Entries, navigationEntry(
+			"urn:catalog:category:"+id,
+			node.Name,
+			strconv.FormatInt(node.TotalBookCount, 10)+" books",
+			helpers.OPDSRelSubsection,
+			opdsURL(ctx, "/opds/books?category_id="+id),
+		))
+	}
+
+	return r.respond(ctx, helpers.OPDSNavigationType, feed)
+}
+
+// Books is an acquisition feed over the catalog. It takes the same filters
+// and sort as GET /api/books and pages with page/per_page.
+func (r *OpdsController) Books(ctx http.Context) http.Response {
+	filter, errs := bookFilterFromQuery(ctx)
+	if len(errs) > 0 {
+		return helpers.Error(ctx, 400, "Validation failed", errs)
+	}
+	filter.Cursor = ""
+
+	return r.acquisition(ctx, "urn:catalog:books", "All books", filter)
+}
+
+// New lists the most recently added books first.
+func (r *OpdsController) New(ctx http.Context) http.Response {
+	filter, errs := bookFilterFromQuery(ctx)
+	if len(errs) > 0 {
+		return helpers.Error(ctx, 400, "Validation failed", errs)
+	}
+	filter.Cursor = ""
+	filter.Sort, filter.Desc = "id", true
+
+	return r.acquisition(ctx, "urn:catalog:new", "New arrivals", filter)
+}
+
+// Search runs q through the catalog search and returns the best matches as
+// an acquisition feed.
+func (r *OpdsController) Search(ctx http.Context) http.Response {
+	query := strings.TrimSpace(ctx.Request().Query("q"))
+	if query == "" {
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{"q": "q is required"})
+	}
+
+	hits, err := r.books.SearchBooks(query, maxPerPage)
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to search books", err.Error())
+	}
+
+	feed := r.feed(ctx, "urn:catalog:search:"+url.QueryEscape(query), "Search results for "+query, helpers.OPDSAcquisitionType)
+	for _, hit := range hits {
+		feed.Entries = append(feed.Entries, r.entry(ctx, &hit.Book))
+	}
+	total := int64(len(hits))
+	feed.TotalResults = &total
+
+	return r.respond(ctx, helpers.OPDSAcquisitionType, feed)
+}
+
+// OpenSearch describes how clients build search URLs for the catalog.
+func (r *OpdsController) OpenSearch(ctx http.Context) http.Response {
+	name := facades.Config().GetString("app.name")
+
+	var buf bytes.Buffer
+	err := helpers.WriteOpenSearch(&buf, name, "Search the "+name+" catalog", opdsURL(ctx, "/opds/search")+"?q={searchTerms}")
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to render search description", err.Error())
+	}
+	return ctx.Response().Data(200, helpers.OpenSearchType, buf.Bytes())
+}
+
+func (r *OpdsController) acquisition(ctx http.Context, id, title string, filter repositories.BookFilter) http.Response {
+	page, err := r.books.ListBooks(filter)
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to fetch books", err.Error())
+	}
+
+	feed := r.feed(ctx, id, title, helpers.OPDSAcquisitionType)
+	feed.TotalResults = &page.Total
+	feed.ItemsPerPage = filter.PerPage
+
+	// The same page links the JSON API puts in meta.links.
+	meta := helpers.PageMeta(ctx, page.Total, filter.Page, filter.PerPage)
+	feed.Links = append(feed.Links,
+		helpers.OPDSLink{Rel: "first", Href: helpers.PageURL(ctx, map[string]string{"page": "1"}), Type: helpers.OPDSAcquisitionType},
+		helpers.OPDSLink{Rel: "last", Href: helpers.PageURL(ctx, map[string]string{"page": strconv.Itoa(meta.LastPage)}), Type: helpers.OPDSAcquisitionType},
+	)
+	if meta.Links.Next != "" {
+		feed.Links = append(feed.Links, helpers.OPDSLink{Rel: "next", Href: meta.Links.Next, Type: helpers.OPDSAcquisitionType})
+	}
+	if meta.Links.Prev != "" {
+		feed.Links = append(feed.Links, helpers.OPDSLink{Rel: "previous", Href: meta.Links.Prev, Type: helpers.OPDSAcquisitionType})
+	}
+
+	for i := range page.Books {
+		feed.Entries = append(feed.Entries, r.entry(ctx, &page.Books[i]))
+	}
+
+	return r.respond(ctx, helpers.OPDSAcquisitionType, feed)
+}
+
+// feed starts a feed with the links every catalog page carries: itself, the
+// root and the search description.
+func (r *OpdsController) feed(ctx http.Context, id, title, kind string) *helpers.OPDSFeed {
+	return &helpers.OPDSFeed{
+		ID:    id,
+		Title: title,
+		Links: []helpers.OPDSLink{
+			{Rel: "self", Href: ctx.Request().FullUrl(), Type: kind},
+			{Rel: helpers.OPDSRelStart, Href: opdsURL(ctx, "/opds"), Type: helpers.OPDSNavigationType},
+			{Rel: helpers.OPDSRelSearch, Href: opdsURL(ctx, "/opds/opensearch.xml"), Type: helpers.OpenSearchType},
+		},
+	}
+}
+
+// entry describes book with a borrow link to its API resource, where
+// patrons can see its copies and place a loan.
+func (r *OpdsController) entry(ctx http.Context, book *models.Book) helpers.OPDSEntry {
+	return helpers.ToOPDSEntry(book, opdsURL(ctx, "/api/books/"+strconv.FormatUint(uint64(book.ID), 10)))
+}
+
+func (r *OpdsController) respond(ctx http.Context, contentType string, feed *helpers.OPDSFeed) http.Response {
+	var buf bytes.Buffer
+	if err := helpers.WriteOPDS(&buf, feed); err != nil {
+		return helpers.Error(ctx, 500, "Failed to render feed", err.Error())
+	}
+	return ctx.Response().Data(200, contentType+";charset=utf-8", buf.Bytes())
+}
+
+func navigationEntry(id, title, summary, rel, href string) helpers.OPDSEntry {
+	return helpers.OPDSEntry{
+		ID:      id,
+		Title:   title,
+		Updated: time.Now().UTC().Format(time.RFC3339),
+		Content: &helpers.OPDSContent{Type: "text", Text: summary},
+		Links:   []helpers.OPDSLink{{Rel: rel, Href: href, Type: helpers.OPDSAcquisitionType}},
+	}
+}
+
+// opdsURL resolves path against the host the request came in on, so feeds
+// work behind whatever name the client used to reach the catalog.
+func opdsURL(ctx http.Context, path string) string {
+	current, err := url.Parse(ctx.Request().FullUrl())
+	if err != nil {
+		return path
+	}
+	target, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+	return current.ResolveReference(target).String()
+}
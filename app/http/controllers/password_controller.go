@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"errors"
+	"goravel/app/helpers"
+	"goravel/app/repositories"
+	"goravel/app/services"
+
+	"github.com/goravel/framework/contracts/http"
+)
+
+type PasswordController struct {
+	service services.PasswordService
+}
+
+func NewPasswordController() *PasswordController {
+	repo := repositories.NewPasswordResetRepository()
+	service := services.NewPasswordService(repo, repositories.NewUserRepository())
+	return &PasswordController{service: service}
+}
+
+func (r *PasswordController) Forgot(ctx http.Context) http.Response {
+	validation, err := ctx.Request().Validate(map[string]string{
+		"email": "required|string|email|max_len:255",
+	})
+
+	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	if err := r.service.SendResetLink(ctx.Request().Input("email")); err != nil {
+		return helpers.Error(ctx, 500, "Failed to send reset link", err.Error())
+	}
+
+	return helpers.Success(ctx, "If the email is registered, a reset link has been sent", nil)
+}
+
+func (r *PasswordController) Reset(ctx http.Context) http.Response {
+	validation, err := ctx.Request().Validate(map[string]string{
+		"token":    "required|string",
+		"password": "required|string|min_len:8",
+	})
+
+	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	err = r.service.ResetPassword(ctx.Request().Input("token"), ctx.Request().Input("password"))
+	if err != nil {
+		if errors.Is(err, repositories.ErrPasswordResetInvalid) {
+			return helpers.Error(ctx, 400, "Password reset failed", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Password reset failed", err.Error())
+	}
+
+	return helpers.Success(ctx, "Password reset successfully", nil)
+}
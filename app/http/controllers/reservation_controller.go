@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"errors"
+	"goravel/app/helpers"
+	"goravel/app/repositories"
+	"goravel/app/services"
+
+	"github.com/goravel/framework/contracts/http"
+)
+
+type ReservationController struct {
+	service services.ReservationService
+}
+
+func NewReservationController() *ReservationController {
+	service := services.NewReservationService(repositories.NewReservationRepository())
+	return &ReservationController{service: service}
+}
+
+// Store places a hold on a book for the authenticated user.
+func (r *ReservationController) Store(ctx http.Context) http.Response {
+	validation, err := ctx.Request().Validate(map[string]string{
+		"book_id": "required|integer",
+	})
+	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	reservation, err := r.service.PlaceHold(helpers.CurrentUser(ctx).ID, uint(ctx.Request().InputInt("book_id")))
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrBookNotFound):
+			return helpers.Error(ctx, 404, "Book not found", err.Error())
+		case errors.Is(err, repositories.ErrBookAvailable),
+			errors.Is(err, repositories.ErrAlreadyReserved),
+			errors.Is(err, repositories.ErrAlreadyBorrowed):
+			return helpers.Error(ctx, 409, "Failed to place hold", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to place hold", err.Error())
+	}
+
+	return helpers.Created(ctx, "Hold placed successfully", helpers.ToReservationResponse(reservation))
+}
+
+// Mine lists the authenticated user's holds with their place in each queue.
+func (r *ReservationController) Mine(ctx http.Context) http.Response {
+	reservations, err := r.service.ListUserHolds(helpers.CurrentUser(ctx).ID)
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to fetch holds", err.Error())
+	}
+
+	return helpers.Success(ctx, "Holds retrieved successfully", helpers.ToReservationResponseList(reservations))
+}
+
+// BookQueue lists the active holds on a book in the order they are served.
+func (r *ReservationController) BookQueue(ctx http.Context) http.Response {
+	reservations, err := r.service.ListBookHolds(uint(ctx.Request().RouteInt("id")))
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to fetch holds", err.Error())
+	}
+
+	return helpers.Success(ctx, "Holds retrieved successfully", helpers.ToReservationResponseList(reservations))
+}
+
+// Destroy cancels one of the authenticated user's holds.
+func (r *ReservationController) Destroy(ctx http.Context) http.Response {
+	return r.cancel(ctx, helpers.CurrentUser(ctx).ID)
+}
+
+// DestroyFor cancels any patron's hold. Admin only.
+func (r *ReservationController) DestroyFor(ctx http.Context) http.Response {
+	return r.cancel(ctx, 0)
+}
+
+func (r *ReservationController) cancel(ctx http.Context, userID uint) http.Response {
+	reservation, err := r.service.CancelHold(uint(ctx.Request().RouteInt("id")), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrReservationNotFound):
+			return helpers.Error(ctx, 404, "Hold not found", err.Error())
+		case errors.Is(err, repositories.ErrReservationNotActive):
+			return helpers.Error(ctx, 409, "Hold is no longer active", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to cancel hold", err.Error())
+	}
+
+	return helpers.Success(ctx, "Hold cancelled successfully", helpers.ToReservationResponse(reservation))
+}
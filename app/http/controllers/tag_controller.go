@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"errors"
+	"goravel/app/helpers"
+	"goravel/app/repositories"
+	"goravel/app/services"
+	"strings"
+
+	"github.com/goravel/framework/contracts/http"
+)
+
+type TagController struct {
+	service services.TagService
+}
+
+func NewTagController() *TagController {
+	return &TagController{service: services.NewTagService(repositories.NewTagRepository())}
+}
+
+// Index lists tags with the number of books carrying each. q narrows the
+// list to names containing it.
+func (r *TagController) Index(ctx http.Context) http.Response {
+	tags, err := r.service.ListTags(strings.TrimSpace(ctx.Request().Query("q")))
+	if err != nil {
+		return helpers.Error(ctx, 500, "Failed to fetch tags", err.Error())
+	}
+
+	response := []helpers.TagResponse{}
+	for _, tag := range tags {
+		entry := helpers.ToTagResponse(&tag.Tag)
+		entry["book_count"] = tag.BookCount
+		response = append(response, entry)
+	}
+	return helpers.Success(ctx, "Tags retrieved successfully", response)
+}
+
+func (r *TagController) Update(ctx http.Context) http.Response {
+	tag, err := r.service.GetTag(uint(ctx.Request().RouteInt("id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrTagNotFound) {
+			return helpers.Error(ctx, 404, "Tag not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch tag", err.Error())
+	}
+
+	validation, err := ctx.Request().Validate(map[string]string{
+		"name": "required|string|max_len:100",
+	})
+
+	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	name := strings.TrimSpace(ctx.Request().Input("name"))
+	if helpers.Slugify(name) == "" {
+		return helpers.Error(ctx, 400, "Validation failed", map[string]string{"name": "name must contain a letter or digit"})
+	}
+
+	if err := r.service.RenameTag(tag, name); err != nil {
+		if errors.Is(err, services.ErrDuplicateSlug) {
+			return helpers.Error(ctx, 409, "Failed to update tag", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to update tag", err.Error())
+	}
+
+	return helpers.Success(ctx, "Tag updated successfully", helpers.ToTagResponse(tag))
+}
+
+func (r *TagController) Destroy(ctx http.Context) http.Response {
+	tag, err := r.service.GetTag(uint(ctx.Request().RouteInt("id")))
+	if err != nil {
+		if errors.Is(err, repositories.ErrTagNotFound) {
+			return helpers.Error(ctx, 404, "Tag not found", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to fetch tag", err.Error())
+	}
+
+	if err := r.service.DeleteTag(tag); err != nil {
+		return helpers.Error(ctx, 500, "Failed to delete tag", err.Error())
+	}
+
+	return helpers.Success(ctx, "Tag deleted successfully", nil)
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"errors"
+	"goravel/app/helpers"
+	"goravel/app/repositories"
+	"goravel/app/services"
+
+	"github.com/goravel/framework/contracts/http"
+)
+
+type TwoFactorController struct {
+	service services.TwoFactorService
+}
+
+func NewTwoFactorController() *TwoFactorController {
+	service := services.NewTwoFactorService(repositories.NewUserRepository())
+	return &TwoFactorController{service: service}
+}
+
+// Enroll starts TOTP enrollment for the authenticated user. qr_data is the
+// payload to render as a QR code for authenticator apps to scan.
+func (r *TwoFactorController) Enroll(ctx http.Context) http.Response {
+	setup, err := r.service.Enroll(helpers.CurrentUser(ctx))
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+			return helpers.Error(ctx, 400, "Two-factor authentication already enabled", nil)
+		}
+		return helpers.Error(ctx, 500, "Failed to enroll two-factor authentication", err.Error())
+	}
+
+	return helpers.Success(ctx, "Scan the QR code and confirm with a code", map[string]any{
+		"secret":      setup.Secret,
+		"otpauth_uri": setup.URI,
+		"qr_data":     setup.URI,
+	})
+}
+
+// Confirm enables two-factor authentication and returns the recovery codes.
+// They are not shown again.
+func (r *TwoFactorController) Confirm(ctx http.Context) http.Response {
+	validation, err := ctx.Request().Validate(map[string]string{
+		"code": "required|string|len:6",
+	})
+
+	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	codes, err := r.service.Confirm(helpers.CurrentUser(ctx), ctx.Request().Input("code"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTwoFactorAlreadyEnabled), errors.Is(err, services.ErrTwoFactorNotEnrolled):
+			return helpers.Error(ctx, 400, "Failed to confirm two-factor authentication", err.Error())
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			return helpers.Error(ctx, 422, "Failed to confirm two-factor authentication", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to confirm two-factor authentication", err.Error())
+	}
+
+	return helpers.Success(ctx, "Two-factor authentication enabled", map[string]any{
+		"recovery_codes": codes,
+	})
+}
+
+func (r *TwoFactorController) Disable(ctx http.Context) http.Response {
+	validation, err := ctx.Request().Validate(map[string]string{
+		"code":          "required_without:recovery_code|string|len:6",
+		"recovery_code": "required_without:code|string",
+	})
+
+	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	user := helpers.CurrentUser(ctx)
+	err = r.service.Disable(user, ctx.Request().Input("code"), ctx.Request().Input("recovery_code"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTwoFactorNotEnrolled):
+			return helpers.Error(ctx, 400, "Failed to disable two-factor authentication", err.Error())
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			return helpers.Error(ctx, 422, "Failed to disable two-factor authentication", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Failed to disable two-factor authentication", err.Error())
+	}
+
+	return helpers.Success(ctx, "Two-factor authentication disabled", helpers.ToUserResponse(user))
+}
@@ -1,23 +1,34 @@
 package controllers
 
 import (
+	"errors"
 	"goravel/app/helpers"
 	"goravel/app/models"
 	"goravel/app/repositories"
 	"goravel/app/services"
+	"math"
 	"strconv"
 
 	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/facades"
 )
 
 type UserController struct {
-	service services.UserService
+	service      services.UserService
+	verification services.EmailVerificationService
+	throttle     services.LoginThrottleService
+	twoFactor    services.TwoFactorService
 }
 
 func NewUserController() *UserController {
 	repo := repositories.NewUserRepository()
-	service := services.NewUserService(repo)
-	return &UserController{service: service}
+	keyRing := services.NewKeyRingService(repositories.NewKeyRingRepository())
+	tokens := services.NewTokenService(repositories.NewTokenRepository(), repo, keyRing)
+	twoFactor := services.NewTwoFactorService(repo)
+	service := services.NewUserService(repo, tokens, twoFactor)
+	verification := services.NewEmailVerificationService(repo)
+	throttle := services.NewLoginThrottleService()
+	return &UserController{service: service, verification: verification, throttle: throttle, twoFactor: twoFactor}
 }
 
 func (r *UserController) Login(ctx http.Context) http.Response {
@@ -36,21 +47,107 @@ func (r *UserController) Login(ctx http.Context) http.Response {
 
 	email := ctx.Request().Input("email")
 	password := ctx.Request().Input("password")
+	ip := ctx.Request().Ip()
+
+	if wait := r.throttle.RetryAfter(email, ip); wait > 0 {
+		seconds := int(math.Ceil(wait.Seconds()))
+		ctx.Response().Header("Retry-After", strconv.Itoa(seconds))
+		return helpers.Error(ctx, 429, "Too many login attempts", map[string]any{
+			"retry_after": seconds,
+		})
+	}
 
-	user, token, err := r.service.Login(email, password)
+	result, err := r.service.Login(email, password)
 	if err != nil {
-		return helpers.Error(ctx, 401, "Login failed", err.Error())
+		if errors.Is(err, repositories.ErrInvalidCredentials) {
+			r.throttle.RecordFailure(email, ip)
+			return helpers.Error(ctx, 401, "Login failed", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Login failed", nil)
+	}
+	r.throttle.RecordSuccess(email, ip)
+
+	if result.ChallengeToken != "" {
+		return helpers.Success(ctx, "Two-factor authentication required", map[string]any{
+			"two_factor_required": true,
+			"challenge_token":     result.ChallengeToken,
+			"expires_in":          facades.Config().GetInt("auth.two_factor.challenge_expire", 5) * 60,
+		})
 	}
 
-	return helpers.Success(ctx, "Login successful", map[string]any{
-		"user":  helpers.ToUserResponse(user),
-		"token": token,
+	return r.loggedIn(ctx, result)
+}
+
+// VerifyTwoFactor exchanges a login challenge token and a TOTP or recovery
+// code for an access and refresh token.
+func (r *UserController) VerifyTwoFactor(ctx http.Context) http.Response {
+	validation, err := ctx.Request().Validate(map[string]string{
+		"challenge_token": "required|string",
+		"code":            "required_without:recovery_code|string|len:6",
+		"recovery_code":   "required_without:code|string",
 	})
+
+	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	result, err := r.service.VerifyTwoFactor(
+		ctx.Request().Input("challenge_token"),
+		ctx.Request().Input("code"),
+		ctx.Request().Input("recovery_code"),
+	)
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorChallengeInvalid) || errors.Is(err, services.ErrInvalidTwoFactorCode) {
+			return helpers.Error(ctx, 401, "Two-factor verification failed", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Two-factor verification failed", nil)
+	}
+
+	return r.loggedIn(ctx, result)
 }
 
-func (r *UserController) Logout(ctx http.Context) http.Response {
-	err := r.service.Logout(ctx.Request().Input("token"))
+func (r *UserController) Refresh(ctx http.Context) http.Response {
+	validation, err := ctx.Request().Validate(map[string]string{
+		"refresh_token": "required|string",
+	})
+
 	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	tokens, err := r.service.Refresh(ctx.Request().Input("refresh_token"))
+	if err != nil {
+		if errors.Is(err, repositories.ErrRefreshTokenInvalid) || errors.Is(err, repositories.ErrRefreshTokenReused) {
+			return helpers.Error(ctx, 401, "Refresh failed", err.Error())
+		}
+		return helpers.Error(ctx, 500, "Refresh failed", err.Error())
+	}
+
+	return helpers.Success(ctx, "Token refreshed successfully", map[string]any{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+func (r *UserController) Logout(ctx http.Context) http.Response {
+	claims := helpers.Claims(ctx)
+	jti, _ := claims["jti"].(string)
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return helpers.Error(ctx, 400, "Logout failed", "token has no expiry")
+	}
+
+	err = r.service.Logout(jti, helpers.CurrentUser(ctx).ID, exp.Time, ctx.Request().Input("refresh_token"))
+	if err != nil && !errors.Is(err, repositories.ErrRefreshTokenInvalid) {
 		return helpers.Error(ctx, 500, "Logout failed", err.Error())
 	}
 
@@ -96,6 +193,11 @@ func (r *UserController) Register(ctx http.Context) http.Response {
 		return helpers.Error(ctx, 500, "Failed to create user", err.Error())
 	}
 
+	// The account exists either way; the user can ask for a new link later.
+	if err := r.verification.SendVerificationLink(user); err != nil {
+		facades.Log().Errorf("send verification link error: %+v", err)
+	}
+
 	return helpers.Success(ctx, "User created successfully", helpers.ToUserResponse(user))
 }
 
@@ -109,36 +211,34 @@ func (r *UserController) Show(ctx http.Context) http.Response {
 }
 
 func (r *UserController) Update(ctx http.Context) http.Response {
-	validation, err := ctx.Request().Validate(map[string]string{
-		"name":     "string|max_len:255",
-		"email":    "string|email|max_len:255",
-		"password": "string|min_len:8",
-	})
-
+	user, err := r.service.GetByIDUser(ctx.Request().Input("id"))
 	if err != nil {
-		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+		return helpers.Error(ctx, 404, "User not found", err.Error())
 	}
 
-	if validation.Fails() {
-		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
-	}
+	return r.update(ctx, user)
+}
 
+// Me returns the authenticated user's profile.
+func (r *UserController) Me(ctx http.Context) http.Response {
+	return helpers.Success(ctx, "User retrieved successfully", helpers.ToUserResponse(helpers.CurrentUser(ctx)))
+}
+
+// UpdateMe updates the authenticated user's profile.
+func (r *UserController) UpdateMe(ctx http.Context) http.Response {
+	return r.update(ctx, helpers.CurrentUser(ctx))
+}
+
+// Unlock clears failed login attempts recorded for a user's email. Admin only.
+func (r *UserController) Unlock(ctx http.Context) http.Response {
 	user, err := r.service.GetByIDUser(ctx.Request().Input("id"))
 	if err != nil {
 		return helpers.Error(ctx, 404, "User not found", err.Error())
 	}
 
-	idStr := ctx.Request().Input("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		return helpers.Error(ctx, 400, "Invalid user ID", err.Error())
-	}
+	r.throttle.Clear(user.Email)
 
-	if err := r.service.UpdateUser(user, id); err != nil {
-		return helpers.Error(ctx, 500, "Failed to update user", err.Error())
-	}
-
-	return helpers.Success(ctx, "User updated successfully", helpers.ToUserResponse(user))
+	return helpers.Success(ctx, "User unlocked successfully", helpers.ToUserResponse(user))
 }
 
 func (r *UserController) Destroy(ctx http.Context) http.Response {
@@ -154,3 +254,55 @@ func (r *UserController) Destroy(ctx http.Context) http.Response {
 
 	return helpers.Success(ctx, "User deleted successfully", res)
 }
+
+func (r *UserController) update(ctx http.Context, user *models.User) http.Response {
+	validation, err := ctx.Request().Validate(map[string]string{
+		"name":     "string|max_len:255",
+		"email":    "string|email|max_len:255",
+		"password": "string|min_len:8",
+	})
+
+	if err != nil {
+		return helpers.Error(ctx, 500, "Validation setup failed", err.Error())
+	}
+
+	if validation.Fails() {
+		return helpers.Error(ctx, 400, "Validation failed", validation.Errors().All())
+	}
+
+	if name := ctx.Request().Input("name"); name != "" {
+		user.Name = name
+	}
+	emailChanged := false
+	if email := ctx.Request().Input("email"); email != "" && email != user.Email {
+		user.Email = email
+		user.EmailVerifiedAt = nil
+		emailChanged = true
+	}
+
+	if err := r.service.UpdateUser(user, ctx.Request().Input("password")); err != nil {
+		if errors.Is(err, services.ErrEmailExists) {
+			return helpers.Error(ctx, 400, "Email already exists", nil)
+		}
+		return helpers.Error(ctx, 500, "Failed to update user", err.Error())
+	}
+
+	// A new address has to be confirmed before the user can borrow again.
+	if emailChanged {
+		if err := r.verification.SendVerificationLink(user); err != nil {
+			facades.Log().Errorf("send verification link error: %+v", err)
+		}
+	}
+
+	return helpers.Success(ctx, "User updated successfully", helpers.ToUserResponse(user))
+}
+
+func (r *UserController) loggedIn(ctx http.Context, result *services.LoginResult) http.Response {
+	return helpers.Success(ctx, "Login successful", map[string]any{
+		"user":                           helpers.ToUserResponse(result.User),
+		"token":                          result.Tokens.AccessToken,
+		"refresh_token":                  result.Tokens.RefreshToken,
+		"expires_in":                     result.Tokens.ExpiresIn,
+		"two_factor_enrollment_required": r.twoFactor.RequiresEnrollment(result.User),
+	})
+}
@@ -2,13 +2,19 @@ package middleware
 
 import (
 	"goravel/app/helpers"
+	"goravel/app/repositories"
+	"goravel/app/services"
+	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/goravel/framework/contracts/http"
-	"github.com/goravel/framework/facades"
 )
 
 func Auth() http.Middleware {
+	userRepo := repositories.NewUserRepository()
+	keyRing := services.NewKeyRingService(repositories.NewKeyRingRepository())
+	tokenRepo := repositories.NewTokenRepository()
+	tokens := services.NewTokenService(tokenRepo, userRepo, keyRing)
+
 	return func(ctx http.Context) {
 		token := ctx.Request().Header("Authorization")
 		if token == "" {
@@ -24,20 +30,50 @@ func Auth() http.Middleware {
 			token = token[7:]
 		}
 
-		// Parse and validate JWT token manually
-		jwtSecret := facades.Config().GetString("jwt.secret")
-		_, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
-			return []byte(jwtSecret), nil
-		})
+		claims, err := tokens.ParseAccessToken(token)
+		jti, _ := claims["jti"].(string)
+		if err != nil || jti == "" {
+			ctx.Request().AbortWithStatusJson(401, helpers.JsonResponse{
+				StatusCode: 401,
+				Message:    "Unauthorized - Invalid token",
+			})
+			return
+		}
 
+		revoked, err := tokenRepo.IsTokenRevoked(jti)
+		if err != nil || revoked {
+			ctx.Request().AbortWithStatusJson(401, helpers.JsonResponse{
+				StatusCode: 401,
+				Message:    "Unauthorized - Token revoked",
+			})
+			return
+		}
+
+		sub, _ := claims["sub"].(float64)
+		user, err := userRepo.FindByIDUser(uint(sub))
 		if err != nil {
 			ctx.Request().AbortWithStatusJson(401, helpers.JsonResponse{
 				StatusCode: 401,
-				Message:    "Unauthorized - Invalid token",
+				Message:    "Unauthorized - User not found",
 			})
 			return
 		}
 
+		// Tokens issued before e.g. a password reset are no longer valid.
+		if user.TokensRevokedAt != nil {
+			iat, _ := claims.GetIssuedAt()
+			if iat == nil || iat.Before(user.TokensRevokedAt.Truncate(time.Second)) {
+				ctx.Request().AbortWithStatusJson(401, helpers.JsonResponse{
+					StatusCode: 401,
+					Message:    "Unauthorized - Token revoked",
+				})
+				return
+			}
+		}
+
+		ctx.WithValue(helpers.ClaimsKey, claims)
+		ctx.WithValue(helpers.UserKey, user)
+
 		ctx.Request().Next()
 	}
 }
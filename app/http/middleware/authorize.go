@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/facades"
+
+	"goravel/app/helpers"
+)
+
+// RequireRole allows the request through only when the authenticated user's
+// role is one of roles. It must run after Auth.
+func RequireRole(roles ...string) http.Middleware {
+	return func(ctx http.Context) {
+		user := helpers.CurrentUser(ctx)
+		if user == nil || !slices.Contains(roles, user.Role) {
+			forbidden(ctx)
+			return
+		}
+
+		ctx.Request().Next()
+	}
+}
+
+// RequirePermission allows the request through only when the Gate grants the
+// ability to the authenticated user's role. It must run after Auth.
+func RequirePermission(ability string) http.Middleware {
+	return func(ctx http.Context) {
+		if !allows(ctx, ability) {
+			forbidden(ctx)
+			return
+		}
+
+		ctx.Request().Next()
+	}
+}
+
+// RequireSelfOrPermission lets users act on their own record, identified by the
+// route parameter param, and otherwise falls back to RequirePermission.
+func RequireSelfOrPermission(param, ability string) http.Middleware {
+	return func(ctx http.Context) {
+		user := helpers.CurrentUser(ctx)
+		isSelf := user != nil && strconv.FormatUint(uint64(user.ID), 10) == ctx.Request().Route(param)
+		if !isSelf && !allows(ctx, ability) {
+			forbidden(ctx)
+			return
+		}
+
+		ctx.Request().Next()
+	}
+}
+
+func allows(ctx http.Context, ability string) bool {
+	user := helpers.CurrentUser(ctx)
+	if user == nil {
+		return false
+	}
+
+	return facades.Gate().WithContext(ctx).Allows(ability, map[string]any{
+		"role": user.Role,
+	})
+}
+
+func forbidden(ctx http.Context) {
+	ctx.Request().AbortWithStatusJson(403, helpers.JsonResponse{
+		StatusCode: 403,
+		Message:    "Forbidden - Insufficient permissions",
+	})
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/facades"
+
+	"goravel/app/helpers"
+	"goravel/app/repositories"
+	"goravel/app/services"
+)
+
+// FeedAuth authenticates e-reader apps on the OPDS feeds with HTTP Basic
+// auth: the account's email as the username and its feed token as the
+// password. A failed attempt asks the app to prompt for credentials.
+func FeedAuth() http.Middleware {
+	feedTokens := services.NewFeedTokenService(repositories.NewUserRepository())
+
+	return func(ctx http.Context) {
+		email, token, ok := ctx.Request().Origin().BasicAuth()
+		if !ok {
+			feedUnauthorized(ctx, "Unauthorized - Feed token required")
+			return
+		}
+
+		user, err := feedTokens.Authenticate(email, token)
+		if err != nil {
+			feedUnauthorized(ctx, "Unauthorized - Invalid feed token")
+			return
+		}
+
+		ctx.WithValue(helpers.UserKey, user)
+
+		ctx.Request().Next()
+	}
+}
+
+func feedUnauthorized(ctx http.Context, message string) {
+	ctx.Response().Header("WWW-Authenticate", `Basic realm="`+facades.Config().GetString("app.name")+` catalog", charset="UTF-8"`)
+	ctx.Request().AbortWithStatusJson(401, helpers.JsonResponse{
+		StatusCode: 401,
+		Message:    message,
+	})
+}
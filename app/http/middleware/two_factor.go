@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"github.com/goravel/framework/contracts/http"
+
+	"goravel/app/helpers"
+	"goravel/app/repositories"
+	"goravel/app/services"
+)
+
+// RequireTwoFactor blocks privileged users who have not enrolled in
+// two-factor authentication while auth.two_factor.required is on. It must
+// run after Auth.
+func RequireTwoFactor() http.Middleware {
+	twoFactor := services.NewTwoFactorService(repositories.NewUserRepository())
+
+	return func(ctx http.Context) {
+		user := helpers.CurrentUser(ctx)
+		if user == nil || twoFactor.RequiresEnrollment(user) {
+			ctx.Request().AbortWithStatusJson(403, helpers.JsonResponse{
+				StatusCode: 403,
+				Message:    "Forbidden - Two-factor authentication must be enabled",
+			})
+			return
+		}
+
+		ctx.Request().Next()
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/goravel/framework/contracts/http"
+
+	"goravel/app/helpers"
+)
+
+// RequireVerifiedEmail blocks users who have not confirmed their email
+// address yet. It must run after Auth.
+func RequireVerifiedEmail() http.Middleware {
+	return func(ctx http.Context) {
+		user := helpers.CurrentUser(ctx)
+		if user == nil || !user.HasVerifiedEmail() {
+			ctx.Request().AbortWithStatusJson(403, helpers.JsonResponse{
+				StatusCode: 403,
+				Message:    "Forbidden - Email address is not verified",
+			})
+			return
+		}
+
+		ctx.Request().Next()
+	}
+}
@@ -0,0 +1,36 @@
+package listeners
+
+import (
+	"github.com/goravel/framework/contracts/event"
+	"github.com/goravel/framework/facades"
+)
+
+// LogOverdueLoan writes each loan that turns overdue to the application log.
+type LogOverdueLoan struct {
+}
+
+func (receiver *LogOverdueLoan) Signature() string {
+	return "log_overdue_loan"
+}
+
+func (receiver *LogOverdueLoan) Queue(args ...any) event.Queue {
+	return event.Queue{
+		Enable:     false,
+		Connection: "",
+		Queue:      "",
+	}
+}
+
+func (receiver *LogOverdueLoan) Handle(args ...any) error {
+	if len(args) < 4 {
+		return nil
+	}
+
+	facades.Log().With(map[string]any{
+		"borrowing_id": args[0],
+		"user_id":      args[1],
+		"book_id":      args[2],
+		"due_at":       args[3],
+	}).Info("Loan is overdue")
+	return nil
+}
@@ -0,0 +1,49 @@
+package mails
+
+import (
+	"fmt"
+	"html"
+
+	"github.com/goravel/framework/contracts/mail"
+)
+
+// PasswordReset mails a single-use password reset link.
+type PasswordReset struct {
+	To        string
+	Name      string
+	Link      string
+	ExpiresIn int
+}
+
+// Attachments attach files to the mail
+func (m *PasswordReset) Attachments() []string {
+	return []string{}
+}
+
+// Content set the content of the mail
+func (m *PasswordReset) Content() *mail.Content {
+	return &mail.Content{
+		Html: fmt.Sprintf(
+			`<p>Hi %s,</p><p>We received a request to reset your password. <a href="%s">Choose a new password</a>.</p><p>This link expires in %d minutes and can only be used once. If you did not ask for a reset, you can ignore this email.</p>`,
+			html.EscapeString(m.Name), html.EscapeString(m.Link), m.ExpiresIn,
+		),
+	}
+}
+
+// Envelope set the envelope of the mail
+func (m *PasswordReset) Envelope() *mail.Envelope {
+	return &mail.Envelope{
+		Subject: "Reset your password",
+		To:      []string{m.To},
+	}
+}
+
+// Headers set the headers of the mail
+func (m *PasswordReset) Headers() map[string]string {
+	return map[string]string{}
+}
+
+// Queue set the queue of the mail
+func (m *PasswordReset) Queue() *mail.Queue {
+	return &mail.Queue{}
+}
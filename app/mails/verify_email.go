@@ -0,0 +1,48 @@
+package mails
+
+import (
+	"fmt"
+	"html"
+
+	"github.com/goravel/framework/contracts/mail"
+)
+
+// VerifyEmail mails the signed link that confirms a new account's address.
+type VerifyEmail struct {
+	To   string
+	Name string
+	Link string
+}
+
+// Attachments attach files to the mail
+func (m *VerifyEmail) Attachments() []string {
+	return []string{}
+}
+
+// Content set the content of the mail
+func (m *VerifyEmail) Content() *mail.Content {
+	return &mail.Content{
+		Html: fmt.Sprintf(
+			`<p>Hi %s,</p><p>Please <a href="%s">confirm your email address</a> to start borrowing books.</p><p>If you did not create an account, no further action is required.</p>`,
+			html.EscapeString(m.Name), html.EscapeString(m.Link),
+		),
+	}
+}
+
+// Envelope set the envelope of the mail
+func (m *VerifyEmail) Envelope() *mail.Envelope {
+	return &mail.Envelope{
+		Subject: "Verify your email address",
+		To:      []string{m.To},
+	}
+}
+
+// Headers set the headers of the mail
+func (m *VerifyEmail) Headers() map[string]string {
+	return map[string]string{}
+}
+
+// Queue set the queue of the mail
+func (m *VerifyEmail) Queue() *mail.Queue {
+	return &mail.Queue{}
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/goravel/framework/database/orm"
+)
+
+const (
+	ContributorAuthor     = "author"
+	ContributorEditor     = "editor"
+	ContributorTranslator = "translator"
+)
+
+// ContributorRoles lists the roles a person can have on a book.
+var ContributorRoles = []string{ContributorAuthor, ContributorEditor, ContributorTranslator}
+
+type Author struct {
+	orm.Model
+	Name        string `gorm:"size:255;not null"`
+	Biography   string
+	BirthYear   *int
+	DeathYear   *int
+	Nationality string `gorm:"size:100"`
+	Website     string `gorm:"size:255"`
+}
+
+// BookAuthor links a book to one of its contributors. Position orders the
+// contributors of a book, starting at 1.
+type BookAuthor struct {
+	orm.Model
+	BookID   uint
+	AuthorID uint
+	Role     string `gorm:"size:20;not null;default:author"`
+	Position int
+	// Author is loaded by the repositories; it is never saved with the link.
+	Author *Author `gorm:"-"`
+}
@@ -6,8 +6,30 @@ import (
 
 type Book struct {
 	orm.Model
-	Title         string
+	Title string
+	// Author is the credit line built from the book's authors, e.g.
+	// "Jane Doe, John Roe". It is kept for display, filtering and search; the
+	// book service rewrites it whenever Authors is saved.
 	Author        string
 	PublishedYear int
 	Stock         int
+	// ISBN10 and ISBN13 are stored normalized, without hyphens. Both are nil
+	// for books without an ISBN; ISBN10 is also nil for the 979 range.
+	ISBN10 *string `gorm:"column:isbn10"`
+	ISBN13 *string `gorm:"column:isbn13"`
+	// SearchTitle and SearchAuthor hold the normalized terms the database
+	// full-text index is built on. The book service keeps them current.
+	SearchTitle  string
+	SearchAuthor string
+	// Cover is the public disk path of the cover image, e.g.
+	// "covers/12/3f9a0c1b2d4e/original.jpg", or empty for books without one.
+	// Its thumbnails sit next to it, named after their size.
+	Cover string
+	// Authors lists the book's contributors in order. It is only filled when
+	// loaded through the book service.
+	Authors []BookAuthor `gorm:"-"`
+	// Categories and Tags are filled the same way. Saving a book through the
+	// service replaces its links when they are non-nil.
+	Categories []Category `gorm:"-"`
+	Tags       []Tag      `gorm:"-"`
 }
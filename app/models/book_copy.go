@@ -0,0 +1,28 @@
+package models
+
+import (
+	"github.com/goravel/framework/database/orm"
+)
+
+const (
+	CopyAvailable = "available"
+	CopyOnLoan    = "on_loan"
+	// CopyOnHold is a copy set aside for a hold that is ready for pickup.
+	CopyOnHold    = "on_hold"
+	CopyLost      = "lost"
+	CopyWithdrawn = "withdrawn"
+)
+
+// CopyConditions lists the conditions a copy can be recorded in.
+var CopyConditions = []string{"new", "good", "fair", "poor", "damaged"}
+
+// BookCopy is one physical item of a book, identified by the barcode on its
+// label. Book.Stock caches the number of available copies.
+type BookCopy struct {
+	orm.Model
+	BookID        uint
+	Barcode       string `gorm:"size:64;uniqueIndex;not null"`
+	ShelfLocation string `gorm:"size:100"`
+	Condition     string `gorm:"size:20;not null;default:good"`
+	Status        string `gorm:"size:20;not null;default:available"`
+}
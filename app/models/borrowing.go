@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/goravel/framework/database/orm"
 )
 
@@ -8,7 +10,16 @@ type Borrowing struct {
 	orm.Model
 	UserID     uint
 	BookID     uint
+	BookCopyID *uint
 	BorrowDate string
 	ReturnDate string
-	Status     string
+	// DueAt is when the loan has to be returned, set from the loan policy
+	// in effect when it was made.
+	DueAt *time.Time
+	// Renewals counts how many times the due date was pushed back.
+	Renewals int
+	Status   string
+	// History lists the loan's events, oldest first. It is only filled when
+	// loaded through the borrowing service.
+	History []BorrowingEvent `gorm:"-"`
 }
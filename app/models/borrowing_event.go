@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/goravel/framework/database/orm"
+)
+
+// Events recorded in a loan's history.
+const (
+	BorrowingRenewed = "renewed"
+	BorrowingOverdue = "overdue"
+	BorrowingLost    = "lost"
+)
+
+// BorrowingEvent is an entry in a loan's history. Events that move the due
+// date record it before and after.
+type BorrowingEvent struct {
+	orm.Model
+	BorrowingID   uint
+	Event         string
+	PreviousDueAt *time.Time
+	DueAt         *time.Time
+}
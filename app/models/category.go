@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/goravel/framework/database/orm"
+)
+
+// Category is a node in the subject tree, such as a Dewey class or a
+// library's own heading. Path lists the IDs from the root down to the
+// category itself, e.g. "/1/5/12/", so a subtree is every path sharing its
+// prefix. The category repository keeps it current.
+type Category struct {
+	orm.Model
+	ParentID *uint
+	Name     string `gorm:"size:255;not null"`
+	Slug     string `gorm:"size:255;uniqueIndex;not null"`
+	Code     string `gorm:"size:32"`
+	Path     string `gorm:"size:1024;not null"`
+}
+
+type Tag struct {
+	orm.Model
+	Name string `gorm:"size:100;not null"`
+	Slug string `gorm:"size:100;uniqueIndex;not null"`
+}
+
+type BookCategory struct {
+	orm.Model
+	BookID     uint
+	CategoryID uint
+}
+
+type BookTag struct {
+	orm.Model
+	BookID uint
+	TagID  uint
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"github.com/goravel/framework/database/orm"
+)
+
+// Types of fine ledger entries.
+const (
+	FineCharge  = "charge"
+	FinePayment = "payment"
+	FineWaiver  = "waiver"
+	FineRefund  = "refund"
+)
+
+// FineTypes lists the types of fine ledger entries.
+var FineTypes = []string{FineCharge, FinePayment, FineWaiver, FineRefund}
+
+// Reasons recorded on entries made by circulation rather than by staff.
+const (
+	FineReasonOverdue = "overdue"
+	FineReasonLost    = "lost"
+)
+
+// FineEntry is a line in a patron's fines ledger. Entries are never changed
+// once written: a mistaken charge is waived and a payment is refunded. Amount
+// is in the smallest currency unit and signed, so charges and refunds add to
+// what the patron owes, payments and waivers subtract from it, and the
+// balance is the sum of the amounts.
+type FineEntry struct {
+	orm.Model
+	UserID      uint
+	BorrowingID uint
+	Type        string
+	// Reason is set on entries circulation makes, FineReasonOverdue or
+	// FineReasonLost, and empty on entries recorded by staff.
+	Reason string
+	Amount int64
+	Note   string
+	// RecordedBy is the staff member who recorded the entry.
+	RecordedBy *uint
+}
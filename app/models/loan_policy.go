@@ -0,0 +1,20 @@
+package models
+
+import (
+	"github.com/goravel/framework/database/orm"
+)
+
+// LoanPolicy sets the lending terms for patrons with Role borrowing books
+// filed under CategoryID or its subcategories. An empty Role or nil
+// CategoryID matches any; the most specific matching policy applies.
+type LoanPolicy struct {
+	orm.Model
+	Role       string
+	CategoryID *uint
+	// LoanDays is how long a loan runs before it is due.
+	LoanDays int
+	// MaxLoans caps the patron's open loans, counting only books in the
+	// category when CategoryID is set. 0 means no limit.
+	MaxLoans    int
+	MaxRenewals int
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/goravel/framework/database/orm"
+)
+
+type PasswordReset struct {
+	orm.Model
+	UserID    uint
+	TokenHash string `gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
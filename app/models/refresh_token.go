@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/goravel/framework/database/orm"
+)
+
+// RefreshToken is an opaque, long-lived credential stored as a SHA-256 hash.
+// Every rotation creates a new row in the same family; presenting a row that
+// has already been revoked signals reuse and revokes the whole family.
+type RefreshToken struct {
+	orm.Model
+	UserID    uint
+	FamilyID  string `gorm:"size:36;index;not null"`
+	TokenHash string `gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/goravel/framework/database/orm"
+)
+
+const (
+	ReservationWaiting   = "waiting"
+	ReservationReady     = "ready_for_pickup"
+	ReservationFulfilled = "fulfilled"
+	ReservationCancelled = "cancelled"
+	ReservationExpired   = "expired"
+)
+
+// Reservation is a patron's hold on a book. Holds are served first come,
+// first served: when a copy comes back it is set aside for the oldest
+// waiting hold, which is then ready for pickup until ExpiresAt.
+type Reservation struct {
+	orm.Model
+	UserID     uint
+	BookID     uint
+	Status     string
+	BookCopyID *uint
+	ReadyAt    *time.Time
+	ExpiresAt  *time.Time
+	// Position is the hold's place in its book's queue, counting from 1, for
+	// waiting holds loaded through the reservation repository.
+	Position int `gorm:"-"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+
+	"github.com/goravel/framework/database/orm"
+)
+
+type RevokedToken struct {
+	orm.Model
+	JTI       string `gorm:"column:jti;size:64;uniqueIndex;not null"`
+	UserID    uint
+	ExpiresAt time.Time
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	SigningKeyActive  = "active"
+	SigningKeyRetired = "retired"
+)
+
+// SigningKey is one entry of the JWT key ring kept on the storage disk. Only
+// the active key signs new tokens; retired keys still verify tokens issued
+// before the last rotation.
+type SigningKey struct {
+	KID        string    `json:"kid"`
+	Algorithm  string    `json:"alg"`
+	PrivateKey string    `json:"private_key"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
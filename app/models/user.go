@@ -1,17 +1,57 @@
 package models
 
 import (
+	"time"
+
 	"github.com/goravel/framework/database/orm"
 )
 
+const (
+	RoleAdmin     = "admin"
+	RoleLibrarian = "librarian"
+	RoleMember    = "member"
+)
+
 type User struct {
 	orm.Model
 	Name     string
 	Email    string `gorm:"size:150;uniqueIndex;not null"`
 	Password string
-	Role     string `gorm:"size:20;not null;default:user"`
+	Role     string `gorm:"size:20;not null;default:member"`
+	// EmailVerifiedAt is nil until the user follows the mailed verification link.
+	EmailVerifiedAt *time.Time
+	// TokensRevokedAt invalidates every access token issued before it.
+	TokensRevokedAt *time.Time
+	// TwoFactorSecret is the encrypted TOTP secret; it is only in effect once
+	// TwoFactorConfirmedAt is set.
+	TwoFactorSecret        string
+	TwoFactorRecoveryCodes string
+	TwoFactorConfirmedAt   *time.Time
+	// FeedTokenHash is the SHA-256 of the token e-reader apps sign in to the
+	// OPDS feeds with, or nil when the user has none.
+	FeedTokenHash *string
 }
 
 func (u *User) GetKey() any {
 	return u.ID
 }
+
+// HasRole reports whether the user holds any of the given roles.
+func (u *User) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if u.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasVerifiedEmail reports whether the user confirmed their email address.
+func (u *User) HasVerifiedEmail() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// HasTwoFactorEnabled reports whether the user completed TOTP enrollment.
+func (u *User) HasTwoFactorEnabled() bool {
+	return u.TwoFactorConfirmedAt != nil
+}
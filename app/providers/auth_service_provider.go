@@ -1,9 +1,26 @@
 package providers
 
 import (
+	"context"
+	"slices"
+
+	"github.com/goravel/framework/auth/access"
+	contractsaccess "github.com/goravel/framework/contracts/auth/access"
 	"github.com/goravel/framework/contracts/foundation"
+	"github.com/goravel/framework/facades"
+
+	"goravel/app/models"
 )
 
+// permissions maps every ability checked by middleware.RequirePermission to
+// the roles that are granted it.
+var permissions = map[string][]string{
+	"books.manage":      {models.RoleAdmin, models.RoleLibrarian},
+	"borrowings.manage": {models.RoleAdmin, models.RoleLibrarian},
+	"users.view":        {models.RoleAdmin, models.RoleLibrarian},
+	"users.manage":      {models.RoleAdmin},
+}
+
 type AuthServiceProvider struct {
 }
 
@@ -12,5 +29,14 @@ func (receiver *AuthServiceProvider) Register(app foundation.Application) {
 }
 
 func (receiver *AuthServiceProvider) Boot(app foundation.Application) {
+	for ability, roles := range permissions {
+		facades.Gate().Define(ability, func(ctx context.Context, arguments map[string]any) contractsaccess.Response {
+			role, _ := arguments["role"].(string)
+			if slices.Contains(roles, role) {
+				return access.NewAllowResponse()
+			}
 
+			return access.NewDenyResponse("Forbidden - Missing permission " + ability)
+		})
+	}
 }
@@ -4,6 +4,9 @@ import (
 	"github.com/goravel/framework/contracts/event"
 	"github.com/goravel/framework/contracts/foundation"
 	"github.com/goravel/framework/facades"
+
+	"goravel/app/events"
+	"goravel/app/listeners"
 )
 
 type EventServiceProvider struct {
@@ -18,5 +21,9 @@ func (receiver *EventServiceProvider) Boot(app foundation.Application) {
 }
 
 func (receiver *EventServiceProvider) listen() map[event.Event][]event.Listener {
-	return map[event.Event][]event.Listener{}
+	return map[event.Event][]event.Listener{
+		&events.LoanOverdue{}: {
+			&listeners.LogOverdueLoan{},
+		},
+	}
 }
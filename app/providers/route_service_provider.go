@@ -1,9 +1,15 @@
 package providers
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/goravel/framework/contracts/foundation"
+	contractshttp "github.com/goravel/framework/contracts/http"
 	"github.com/goravel/framework/facades"
+	httplimit "github.com/goravel/framework/http/limit"
 
+	"goravel/app/helpers"
 	"goravel/app/http"
 	"goravel/routes"
 )
@@ -26,5 +32,22 @@ func (receiver *RouteServiceProvider) Boot(app foundation.Application) {
 }
 
 func (receiver *RouteServiceProvider) configureRateLimiting() {
-
+	// One verification mail per user per minute.
+	facades.RateLimiter().For("verification", func(ctx contractshttp.Context) contractshttp.Limit {
+		limit := httplimit.PerMinute(1)
+		if user := helpers.CurrentUser(ctx); user != nil {
+			return limit.By(strconv.FormatUint(uint64(user.ID), 10))
+		}
+		return limit.By(ctx.Request().Ip())
+	})
+
+	// Reset links are limited per client and per address, so nobody can flood
+	// an inbox or keep replacing someone's pending reset token.
+	facades.RateLimiter().ForWithLimits("password-reset", func(ctx contractshttp.Context) []contractshttp.Limit {
+		email := strings.ToLower(strings.TrimSpace(ctx.Request().Input("email")))
+		return []contractshttp.Limit{
+			httplimit.PerMinute(5).By(ctx.Request().Ip()),
+			httplimit.PerMinute(1).By(email),
+		}
+	})
 }
@@ -4,6 +4,8 @@ import (
 	"github.com/goravel/framework/contracts/foundation"
 	"github.com/goravel/framework/contracts/validation"
 	"github.com/goravel/framework/facades"
+
+	"goravel/app/rules"
 )
 
 type ValidationServiceProvider struct {
@@ -23,7 +25,9 @@ func (receiver *ValidationServiceProvider) Boot(app foundation.Application) {
 }
 
 func (receiver *ValidationServiceProvider) rules() []validation.Rule {
-	return []validation.Rule{}
+	return []validation.Rule{
+		&rules.Isbn{},
+	}
 }
 
 func (receiver *ValidationServiceProvider) filters() []validation.Filter {
@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"errors"
+	"goravel/app/models"
+	"strings"
+
+	"github.com/goravel/framework/contracts/database/orm"
+	"github.com/goravel/framework/facades"
+)
+
+var (
+	ErrAuthorNotFound = errors.New("author not found")
+	ErrAuthorHasBooks = errors.New("author is credited on books")
+)
+
+type AuthorRepository interface {
+	PaginateAuthors(name string, page, perPage int) ([]models.Author, int64, error)
+	FindAuthor(id uint) (*models.Author, error)
+	FindAuthorByName(name string) (*models.Author, error)
+	CreateAuthor(author *models.Author) error
+	UpdateAuthor(author *models.Author) error
+	DeleteAuthor(author *models.Author) error
+	FindBookIDsByAuthor(authorID uint) ([]uint, error)
+	FindBookAuthors(bookIDs []uint) ([]models.BookAuthor, error)
+	SyncBookAuthors(bookID uint, credits []models.BookAuthor) error
+}
+
+type authorRepository struct{}
+
+func NewAuthorRepository() AuthorRepository {
+	return &authorRepository{}
+}
+
+// PaginateAuthors lists authors by name, optionally only those whose name
+// contains name.
+func (r *authorRepository) PaginateAuthors(name string, page, perPage int) ([]models.Author, int64, error) {
+	query := facades.Orm().Query().Model(&models.Author{})
+	if name != "" {
+		query = query.Where(`LOWER(name) LIKE ? ESCAPE '!'`, containsPattern(name))
+	}
+
+	total, err := query.Count()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var authors []models.Author
+	err = query.OrderBy("name").OrderBy("id").Offset((page - 1) * perPage).Limit(perPage).Find(&authors)
+	return authors, total, err
+}
+
+func (r *authorRepository) FindAuthor(id uint) (*models.Author, error) {
+	var author models.Author
+	if err := facades.Orm().Query().Where("id", id).First(&author); err != nil {
+		return nil, err
+	}
+	if author.ID == 0 {
+		return nil, ErrAuthorNotFound
+	}
+	return &author, nil
+}
+
+// FindAuthorByName finds the author with exactly this name, ignoring case.
+func (r *authorRepository) FindAuthorByName(name string) (*models.Author, error) {
+	var author models.Author
+	if err := facades.Orm().Query().Where("LOWER(name) = LOWER(?)", name).OrderBy("id").First(&author); err != nil {
+		return nil, err
+	}
+	if author.ID == 0 {
+		return nil, ErrAuthorNotFound
+	}
+	return &author, nil
+}
+
+func (r *authorRepository) CreateAuthor(author *models.Author) error {
+	return facades.Orm().Query().Create(author)
+}
+
+func (r *authorRepository) UpdateAuthor(author *models.Author) error {
+	return facades.Orm().Query().Save(author)
+}
+
+// DeleteAuthor removes an author who is not credited on any book.
+func (r *authorRepository) DeleteAuthor(author *models.Author) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		credited, err := tx.Model(&models.BookAuthor{}).Where("author_id", author.ID).Exists()
+		if err != nil {
+			return err
+		}
+		if credited {
+			return ErrAuthorHasBooks
+		}
+
+		_, err = tx.Delete(author)
+		return err
+	})
+}
+
+func (r *authorRepository) FindBookIDsByAuthor(authorID uint) ([]uint, error) {
+	var ids []uint
+	err := facades.Orm().Query().Model(&models.BookAuthor{}).
+		Where("author_id", authorID).
+		Distinct("book_id").
+		Pluck("book_id", &ids)
+	return ids, err
+}
+
+// FindBookAuthors returns the contributors of the given books, ordered by
+// book and position, with each Author loaded.
+func (r *authorRepository) FindBookAuthors(bookIDs []uint) ([]models.BookAuthor, error) {
+	credits := []models.BookAuthor{}
+	if len(bookIDs) == 0 {
+		return credits, nil
+	}
+
+	if err := facades.Orm().Query().
+		WhereIn("book_id", toAny(bookIDs)).
+		OrderBy("book_id").
+		OrderBy("position").
+		Find(&credits); err != nil {
+		return nil, err
+	}
+
+	authorIDs := make([]uint, 0, len(credits))
+	for _, credit := range credits {
+		authorIDs = append(authorIDs, credit.AuthorID)
+	}
+
+	var authors []models.Author
+	if len(authorIDs) > 0 {
+		if err := facades.Orm().Query().WhereIn("id", toAny(authorIDs)).Find(&authors); err != nil {
+			return nil, err
+		}
+	}
+
+	byID := make(map[uint]*models.Author, len(authors))
+	for i := range authors {
+		byID[authors[i].ID] = &authors[i]
+	}
+	for i := range credits {
+		credits[i].Author = byID[credits[i].AuthorID]
+	}
+	return credits, nil
+}
+
+// SyncBookAuthors replaces the contributors of a book with credits, numbering
+// them in the order given.
+func (r *authorRepository) SyncBookAuthors(bookID uint, credits []models.BookAuthor) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		return syncBookAuthors(tx, bookID, credits)
+	})
+}
+
+// createCreditedAuthors creates the authors of credits that have no row yet,
+// once per name, and points the credits at them.
+func createCreditedAuthors(tx orm.Query, credits []models.BookAuthor) error {
+	created := map[string]*models.Author{}
+	for i := range credits {
+		if credits[i].AuthorID != 0 || credits[i].Author == nil {
+			continue
+		}
+		key := strings.ToLower(credits[i].Author.Name)
+		author, ok := created[key]
+		if !ok {
+			author = &models.Author{Name: credits[i].Author.Name}
+			if err := tx.Create(author); err != nil {
+				return err
+			}
+			created[key] = author
+		}
+		credits[i].AuthorID = author.ID
+		credits[i].Author = author
+	}
+	return nil
+}
+
+func syncBookAuthors(tx orm.Query, bookID uint, credits []models.BookAuthor) error {
+	if _, err := tx.Where("book_id", bookID).Delete(&models.BookAuthor{}); err != nil {
+		return err
+	}
+
+	for i := range credits {
+		credits[i].ID = 0
+		credits[i].BookID = bookID
+		credits[i].Position = i + 1
+		if err := tx.Create(&credits[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toAny(ids []uint) []any {
+	values := make([]any, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+	return values
+}
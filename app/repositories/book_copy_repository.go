@@ -0,0 +1,215 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"goravel/app/models"
+	"time"
+
+	"github.com/goravel/framework/contracts/database/orm"
+	"github.com/goravel/framework/facades"
+)
+
+var (
+	ErrCopyNotFound    = errors.New("copy not found")
+	ErrCopyOnLoan      = errors.New("copy is on loan")
+	ErrCopyOnHold      = errors.New("copy is set aside for a hold")
+	ErrCopyHasLoans    = errors.New("copy has loan history; withdraw it instead")
+	ErrCopyUnavailable = errors.New("copy is not available")
+)
+
+type BookCopyRepository interface {
+	FindCopiesByBook(bookID uint) ([]models.BookCopy, error)
+	FindCopy(bookID, copyID uint) (*models.BookCopy, error)
+	FindCopyByBarcode(barcode string) (*models.BookCopy, error)
+	CountCopies(bookID uint) (int64, error)
+	CountHeldCopies(bookIDs []uint) (map[uint]int, error)
+	BarcodeTaken(barcode string, excludeID uint) (bool, error)
+	CreateCopy(bookCopy *models.BookCopy) error
+	UpdateCopy(bookCopy *models.BookCopy) error
+	DeleteCopy(bookCopy *models.BookCopy) error
+}
+
+type bookCopyRepository struct{}
+
+func NewBookCopyRepository() BookCopyRepository {
+	return &bookCopyRepository{}
+}
+
+func (r *bookCopyRepository) FindCopiesByBook(bookID uint) ([]models.BookCopy, error) {
+	var copies []models.BookCopy
+	err := facades.Orm().Query().Where("book_id", bookID).OrderBy("id").Find(&copies)
+	return copies, err
+}
+
+func (r *bookCopyRepository) FindCopy(bookID, copyID uint) (*models.BookCopy, error) {
+	var bookCopy models.BookCopy
+	if err := facades.Orm().Query().Where("book_id", bookID).Where("id", copyID).First(&bookCopy); err != nil {
+		return nil, err
+	}
+	if bookCopy.ID == 0 {
+		return nil, ErrCopyNotFound
+	}
+	return &bookCopy, nil
+}
+
+func (r *bookCopyRepository) FindCopyByBarcode(barcode string) (*models.BookCopy, error) {
+	var bookCopy models.BookCopy
+	if err := facades.Orm().Query().Where("barcode", barcode).First(&bookCopy); err != nil {
+		return nil, err
+	}
+	if bookCopy.ID == 0 {
+		return nil, ErrCopyNotFound
+	}
+	return &bookCopy, nil
+}
+
+func (r *bookCopyRepository) CountCopies(bookID uint) (int64, error) {
+	return facades.Orm().Query().Model(&models.BookCopy{}).Where("book_id", bookID).Count()
+}
+
+// CountHeldCopies counts the copies each book has in the collection, whether
+// on the shelf, on loan, on hold or lost. Withdrawn copies are left out.
+func (r *bookCopyRepository) CountHeldCopies(bookIDs []uint) (map[uint]int, error) {
+	counts := map[uint]int{}
+	if len(bookIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		BookID uint
+		Total  int
+	}
+	if err := facades.Orm().Query().Raw(`SELECT book_id, COUNT(*) AS total
+		FROM book_copies
+		WHERE book_id IN ? AND status <> ?
+		GROUP BY book_id`, bookIDs, models.CopyWithdrawn).Scan(&rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.BookID] = row.Total
+	}
+	return counts, nil
+}
+
+func (r *bookCopyRepository) BarcodeTaken(barcode string, excludeID uint) (bool, error) {
+	query := facades.Orm().Query().Model(&models.BookCopy{}).Where("barcode", barcode)
+	if excludeID > 0 {
+		query = query.Where("id != ?", excludeID)
+	}
+	return query.Exists()
+}
+
+// CreateCopy adds a copy to its book. While patrons are waiting for the book,
+// a new available copy is set aside for the first hold in the queue rather
+// than going on the shelf.
+func (r *bookCopyRepository) CreateCopy(bookCopy *models.BookCopy) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		var book models.Book
+		if err := tx.LockForUpdate().Where("id", bookCopy.BookID).First(&book); err != nil {
+			return err
+		}
+		if book.ID == 0 {
+			return ErrBookNotFound
+		}
+
+		if err := tx.Create(bookCopy); err != nil {
+			return err
+		}
+		if err := assignCopyToNextHold(tx, bookCopy, time.Now()); err != nil {
+			return err
+		}
+		if bookCopy.Status == models.CopyOnHold {
+			if err := tx.Save(bookCopy); err != nil {
+				return err
+			}
+		}
+		return syncBookStock(tx, bookCopy.BookID)
+	})
+}
+
+// UpdateCopy saves bookCopy. The status of a copy on loan or on hold belongs to
+// circulation and can't be changed here. A copy made available again, e.g.
+// one that was lost and turned up, goes to the next hold on the book first.
+func (r *bookCopyRepository) UpdateCopy(bookCopy *models.BookCopy) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		var current models.BookCopy
+		if err := tx.LockForUpdate().Where("id", bookCopy.ID).First(&current); err != nil {
+			return err
+		}
+		if current.ID == 0 {
+			return ErrCopyNotFound
+		}
+		if current.Status != bookCopy.Status && (current.Status == models.CopyOnLoan || bookCopy.Status == models.CopyOnLoan) {
+			return ErrCopyOnLoan
+		}
+		if current.Status != bookCopy.Status && (current.Status == models.CopyOnHold || bookCopy.Status == models.CopyOnHold) {
+			return ErrCopyOnHold
+		}
+		if current.Status != models.CopyAvailable {
+			if err := assignCopyToNextHold(tx, bookCopy, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Save(bookCopy); err != nil {
+			return err
+		}
+		return syncBookStock(tx, bookCopy.BookID)
+	})
+}
+
+// DeleteCopy removes a copy that was added by mistake. Copies that have been
+// lent out stay for the loan history and should be withdrawn instead.
+func (r *bookCopyRepository) DeleteCopy(bookCopy *models.BookCopy) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		loaned, err := tx.Model(&models.Borrowing{}).Where("book_copy_id", bookCopy.ID).Exists()
+		if err != nil {
+			return err
+		}
+		if loaned {
+			return ErrCopyHasLoans
+		}
+
+		if _, err := tx.Delete(bookCopy); err != nil {
+			return err
+		}
+		return syncBookStock(tx, bookCopy.BookID)
+	})
+}
+
+// createFirstCopies gives a book created in tx count available copies, with
+// barcodes of the form <book id>-<sequence> as nextBarcode generates them.
+func createFirstCopies(tx orm.Query, bookID uint, count int) error {
+	for n := 1; count > 0; n++ {
+		barcode := fmt.Sprintf("%06d-%03d", bookID, n)
+		taken, err := tx.Model(&models.BookCopy{}).Where("barcode", barcode).Exists()
+		if err != nil {
+			return err
+		}
+		if taken {
+			continue
+		}
+		if err := tx.Create(&models.BookCopy{BookID: bookID, Barcode: barcode, Condition: "good", Status: models.CopyAvailable}); err != nil {
+			return err
+		}
+		count--
+	}
+	return syncBookStock(tx, bookID)
+}
+
+// syncBookStock recounts the available copies of a book into Book.Stock. It
+// must run inside the transaction that changed a copy's status.
+func syncBookStock(tx orm.Query, bookID uint) error {
+	available, err := tx.Model(&models.BookCopy{}).
+		Where("book_id", bookID).
+		Where("status", models.CopyAvailable).
+		Count()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Model(&models.Book{}).Where("id", bookID).Update("stock", available)
+	return err
+}
@@ -1,14 +1,70 @@
 package repositories
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"goravel/app/models"
+	"strings"
 
+	"github.com/goravel/framework/contracts/database/orm"
 	"github.com/goravel/framework/facades"
 )
 
+var (
+	ErrInvalidCursor       = errors.New("invalid pagination cursor")
+	ErrFullTextUnsupported = errors.New("database driver has no full-text search")
+)
+
+// BookSortFields whitelists the columns GET /api/books may be sorted by.
+var BookSortFields = []string{"id", "title", "author", "published_year", "stock"}
+
+// BookFilter narrows and orders a page of the catalog. Sort is a column from
+// BookSortFields; the id breaks ties so pages never overlap. A non-empty
+// Cursor switches from offset pages to keyset pagination.
+type BookFilter struct {
+	Author   string
+	AuthorID uint
+	// CategoryID matches books filed under the category or any of its
+	// descendants. Tags are slugs, all of which a book must carry.
+	CategoryID uint
+	Tags       []string
+	Title      string
+	YearFrom   int
+	YearTo     int
+	InStock    bool
+	Sort       string
+	Desc       bool
+	Page       int
+	PerPage    int
+	Cursor     string
+}
+
+type BookPage struct {
+	Books      []models.Book
+	Total      int64
+	NextCursor string
+}
+
+// BookMatch is a full-text search result with its relevance score.
+type BookMatch struct {
+	models.Book
+	Score float64
+}
+
 type BookRepository interface {
 	FindAllBook() ([]models.Book, error)
+	PaginateBooks(filter BookFilter) (*BookPage, error)
+	SupportsFullText() bool
+	FullTextSearchBooks(terms []string, limit int) ([]BookMatch, error)
+	UpdateSearchColumns(book *models.Book) error
+	UpdateBookCover(book *models.Book) error
 	FindByIDBook(id any) (*models.Book, error)
+	FindByISBNBook(isbn13 string) (*models.Book, error)
+	FindMatchingBook(isbn13, title, author string) (*models.Book, error)
+	FindBooksAfter(afterID uint, limit int) ([]models.Book, error)
+	FindBooksByIDs(ids []uint) ([]models.Book, error)
+	ISBNTaken(isbn13 string, excludeID uint) (bool, error)
 	CreateBook(book *models.Book) error
 	UpdateBook(book *models.Book) error
 	DeleteBook(book *models.Book) (int64, error)
@@ -16,6 +72,15 @@ type BookRepository interface {
 
 type bookRepository struct{}
 
+// bookCursor marks the last row of a page: its sort column value and id,
+// along with the ordering it was taken from.
+type bookCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value any    `json:"v"`
+	ID    uint   `json:"id"`
+}
+
 func NewBookRepository() BookRepository {
 	return &bookRepository{}
 }
@@ -26,21 +91,304 @@ func (r *bookRepository) FindAllBook() ([]models.Book, error) {
 	return books, err
 }
 
+func (r *bookRepository) PaginateBooks(filter BookFilter) (*BookPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = "id"
+	}
+
+	total, err := filterBooks(facades.Orm().Query().Model(&models.Book{}), filter).Count()
+	if err != nil {
+		return nil, err
+	}
+
+	direction, compare := "asc", ">"
+	if filter.Desc {
+		direction, compare = "desc", "<"
+	}
+
+	query := filterBooks(facades.Orm().Query(), filter).
+		OrderBy(filter.Sort, direction).
+		OrderBy("id", direction)
+
+	if filter.Cursor != "" {
+		cursor, err := decodeBookCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != filter.Sort || cursor.Desc != filter.Desc {
+			return nil, ErrInvalidCursor
+		}
+		if filter.Sort == "id" {
+			query = query.Where("id "+compare+" ?", cursor.ID)
+		} else {
+			query = query.Where(
+				"("+filter.Sort+" "+compare+" ?) OR ("+filter.Sort+" = ? AND id "+compare+" ?)",
+				cursor.Value, cursor.Value, cursor.ID,
+			)
+		}
+	} else {
+		query = query.Offset((filter.Page - 1) * filter.PerPage)
+	}
+
+	// One extra row tells whether another page follows.
+	var books []models.Book
+	if err := query.Limit(filter.PerPage + 1).Find(&books); err != nil {
+		return nil, err
+	}
+
+	page := &BookPage{Books: books, Total: total}
+	if len(books) > filter.PerPage {
+		page.Books = books[:filter.PerPage]
+		last := page.Books[len(page.Books)-1]
+		page.NextCursor = encodeBookCursor(bookCursor{
+			Sort:  filter.Sort,
+			Desc:  filter.Desc,
+			Value: bookSortValue(last, filter.Sort),
+			ID:    last.ID,
+		})
+	}
+
+	return page, nil
+}
+
+// SupportsFullText reports whether the connection has the FULLTEXT index
+// the search columns migration creates, which only MySQL does.
+func (r *bookRepository) SupportsFullText() bool {
+	return facades.Orm().Query().Driver() == "mysql"
+}
+
+// FullTextSearchBooks ranks books whose search columns contain every term,
+// each matched as a prefix. terms must already be normalized search tokens,
+// which only contain letters and digits. Title and author matches rank
+// alike.
+func (r *bookRepository) FullTextSearchBooks(terms []string, limit int) ([]BookMatch, error) {
+	if !r.SupportsFullText() {
+		return nil, ErrFullTextUnsupported
+	}
+
+	var matches []BookMatch
+	against := "+" + strings.Join(terms, "* +") + "*"
+	err := facades.Orm().Query().Raw(`SELECT books.*, MATCH(search_title, search_author) AGAINST (? IN BOOLEAN MODE) AS score
+		FROM books
+		WHERE MATCH(search_title, search_author) AGAINST (? IN BOOLEAN MODE)
+		ORDER BY score DESC, books.id
+		LIMIT ?`, against, against, limit).Scan(&matches)
+	return matches, err
+}
+
+func (r *bookRepository) UpdateSearchColumns(book *models.Book) error {
+	_, err := facades.Orm().Query().Model(&models.Book{}).Where("id", book.ID).Update(map[string]any{
+		"search_title":  book.SearchTitle,
+		"search_author": book.SearchAuthor,
+	})
+	return err
+}
+
+func (r *bookRepository) UpdateBookCover(book *models.Book) error {
+	_, err := facades.Orm().Query().Model(&models.Book{}).Where("id", book.ID).Update("cover", book.Cover)
+	return err
+}
+
 func (r *bookRepository) FindByIDBook(id any) (*models.Book, error) {
 	var book models.Book
 	err := facades.Orm().Query().Where("id", id).FirstOrFail(&book)
 	return &book, err
 }
 
+func (r *bookRepository) FindByISBNBook(isbn13 string) (*models.Book, error) {
+	var book models.Book
+	err := facades.Orm().Query().Where("isbn13", isbn13).FirstOrFail(&book)
+	return &book, err
+}
+
+// FindMatchingBook finds the book a catalog record describes: the one with
+// its ISBN, or else the one with the same title and credit line, ignoring
+// case. It returns nil when there is none.
+func (r *bookRepository) FindMatchingBook(isbn13, title, author string) (*models.Book, error) {
+	var book models.Book
+	if isbn13 != "" {
+		if err := facades.Orm().Query().Where("isbn13", isbn13).First(&book); err != nil {
+			return nil, err
+		}
+		if book.ID != 0 {
+			return &book, nil
+		}
+	}
+
+	err := facades.Orm().Query().
+		Where("LOWER(title) = LOWER(?)", title).
+		Where("LOWER(author) = LOWER(?)", author).
+		OrderBy("id").
+		First(&book)
+	if err != nil || book.ID == 0 {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// FindBooksAfter returns up to limit books with an ID above afterID, in ID
+// order, so the whole catalog can be walked a batch at a time.
+func (r *bookRepository) FindBooksAfter(afterID uint, limit int) ([]models.Book, error) {
+	var books []models.Book
+	err := facades.Orm().Query().Where("id > ?", afterID).OrderBy("id").Limit(limit).Find(&books)
+	return books, err
+}
+
+// FindBooksByIDs returns the books with the given IDs, in no particular
+// order. IDs without a book are skipped.
+func (r *bookRepository) FindBooksByIDs(ids []uint) ([]models.Book, error) {
+	books := []models.Book{}
+	if len(ids) == 0 {
+		return books, nil
+	}
+	err := facades.Orm().Query().WhereIn("id", toAny(ids)).Find(&books)
+	return books, err
+}
+
+func (r *bookRepository) ISBNTaken(isbn13 string, excludeID uint) (bool, error) {
+	query := facades.Orm().Query().Model(&models.Book{}).Where("isbn13", isbn13)
+	if excludeID > 0 {
+		query = query.Where("id != ?", excludeID)
+	}
+	return query.Exists()
+}
+
+// CreateBook saves a new book with Stock available copies and links it to
+// its contributors, categories and tags in one transaction.
 func (r *bookRepository) CreateBook(book *models.Book) error {
-	return facades.Orm().Query().Create(book)
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		if err := tx.Create(book); err != nil {
+			return err
+		}
+		if err := createFirstCopies(tx, book.ID, book.Stock); err != nil {
+			return err
+		}
+		return saveBookLinks(tx, book)
+	})
 }
 
+// UpdateBook saves book and its links in one transaction, without touching
+// stock, which only changes alongside its copies.
 func (r *bookRepository) UpdateBook(book *models.Book) error {
-	return facades.Orm().Query().Save(book)
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		if err := tx.Omit("stock").Save(book); err != nil {
+			return err
+		}
+		return saveBookLinks(tx, book)
+	})
 }
 
 func (r *bookRepository) DeleteBook(book *models.Book) (int64, error) {
 	res, err := facades.Orm().Query().Delete(book)
 	return res.RowsAffected, err
 }
+
+// saveBookLinks replaces the contributors, categories and tags of a saved
+// book with those it carries. Nil lists leave the current links alone.
+func saveBookLinks(tx orm.Query, book *models.Book) error {
+	if book.Authors != nil {
+		if err := createCreditedAuthors(tx, book.Authors); err != nil {
+			return err
+		}
+		if err := syncBookAuthors(tx, book.ID, book.Authors); err != nil {
+			return err
+		}
+	}
+
+	if book.Categories != nil {
+		ids := make([]uint, len(book.Categories))
+		for i, category := range book.Categories {
+			ids[i] = category.ID
+		}
+		if err := syncBookCategories(tx, book.ID, ids); err != nil {
+			return err
+		}
+	}
+
+	if book.Tags != nil {
+		if err := firstOrCreateTags(tx, book.Tags); err != nil {
+			return err
+		}
+		ids := make([]uint, len(book.Tags))
+		for i, tag := range book.Tags {
+			ids[i] = tag.ID
+		}
+		if err := syncBookTags(tx, book.ID, ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func filterBooks(query orm.Query, filter BookFilter) orm.Query {
+	if filter.Author != "" {
+		query = query.Where(`LOWER(author) LIKE ? ESCAPE '!'`, containsPattern(filter.Author))
+	}
+	if filter.AuthorID > 0 {
+		query = query.Where("id IN (SELECT book_id FROM book_authors WHERE author_id = ?)", filter.AuthorID)
+	}
+	if filter.CategoryID > 0 {
+		query = query.Where(`id IN (SELECT bc.book_id FROM book_categories bc
+			JOIN categories c ON c.id = bc.category_id
+			JOIN categories root ON c.path LIKE CONCAT(root.path, '%')
+			WHERE root.id = ?)`, filter.CategoryID)
+	}
+	for _, tag := range filter.Tags {
+		query = query.Where(`id IN (SELECT bt.book_id FROM book_tags bt
+			JOIN tags t ON t.id = bt.tag_id
+			WHERE t.slug = ?)`, tag)
+	}
+	if filter.Title != "" {
+		query = query.Where(`LOWER(title) LIKE ? ESCAPE '!'`, containsPattern(filter.Title))
+	}
+	if filter.YearFrom > 0 {
+		query = query.Where("published_year >= ?", filter.YearFrom)
+	}
+	if filter.YearTo > 0 {
+		query = query.Where("published_year <= ?", filter.YearTo)
+	}
+	if filter.InStock {
+		query = query.Where("stock > ?", 0)
+	}
+	return query
+}
+
+// containsPattern builds a case-insensitive LIKE pattern matching term
+// anywhere, treating % and _ in the term literally. It escapes with !, to be
+// used with ESCAPE '!': a backslash would itself need escaping inside the
+// MySQL string literal.
+func containsPattern(term string) string {
+	escaped := strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(strings.ToLower(term))
+	return "%" + escaped + "%"
+}
+
+func bookSortValue(book models.Book, sort string) any {
+	switch sort {
+	case "title":
+		return book.Title
+	case "author":
+		return book.Author
+	case "published_year":
+		return book.PublishedYear
+	case "stock":
+		return book.Stock
+	}
+	return book.ID
+}
+
+func encodeBookCursor(cursor bookCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeBookCursor(encoded string) (bookCursor, error) {
+	var cursor bookCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
@@ -1,17 +1,89 @@
 package repositories
 
 import (
+	"errors"
+	"fmt"
 	"goravel/app/models"
 	"time"
 
+	"github.com/goravel/framework/contracts/database/orm"
 	"github.com/goravel/framework/facades"
 )
 
+var (
+	ErrBookNotFound      = errors.New("book not found")
+	ErrBookOutOfStock    = errors.New("book is out of stock")
+	ErrBorrowingNotFound = errors.New("borrowing not found")
+	ErrAlreadyReturned   = errors.New("borrowing already returned")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrRenewalLimit      = errors.New("loan has reached its renewal limit")
+	ErrHasOverdueLoans   = errors.New("patron has overdue loans")
+	ErrLoanLost          = errors.New("loan was marked lost")
+)
+
+// BorrowingStatuses lists the statuses a loan moves through. Loans are
+// borrowed, turn overdue once past their due date, and end up returned, or
+// lost when the copy is not coming back.
+var BorrowingStatuses = []string{"borrowed", "overdue", "returned", "lost"}
+
+// openLoanStatuses are the statuses of borrowings whose book is still out.
+var openLoanStatuses = []any{"borrowed", "overdue"}
+
+// returnableLoanStatuses are the statuses of borrowings that can still be
+// returned.
+var returnableLoanStatuses = []any{"borrowed", "overdue", "lost"}
+
+// LoanLimit caps a patron's open loans: of any book, or with CategoryID set,
+// of books filed under that category. A Max of 0 means no limit.
+type LoanLimit struct {
+	CategoryID uint
+	Max        int
+}
+
+// LoanTerms are the policy terms a new loan is made under. MaxFineBalance
+// blocks patrons who owe more than it in fines; 0 means they are never
+// blocked.
+type LoanTerms struct {
+	DueAt          time.Time
+	Limits         []LoanLimit
+	MaxFineBalance int64
+}
+
+// LoanLimitError rejects a borrow that would take the patron past one of
+// their loan limits. Current is how many loans count towards it.
+type LoanLimitError struct {
+	LoanLimit
+	Current int64
+}
+
+func (e *LoanLimitError) Error() string {
+	if e.CategoryID != 0 {
+		return fmt.Sprintf("loan limit reached: %d of %d books from category %d already on loan", e.Current, e.Max, e.CategoryID)
+	}
+	return fmt.Sprintf("loan limit reached: %d of %d books already on loan", e.Current, e.Max)
+}
+
+// FineBalanceError rejects a borrow by a patron who owes more in fines than
+// their loan terms allow.
+type FineBalanceError struct {
+	Balance int64
+	Max     int64
+}
+
+func (e *FineBalanceError) Error() string {
+	return fmt.Sprintf("outstanding fines of %d are over the limit of %d", e.Balance, e.Max)
+}
+
 type BorrowingRepository interface {
-	FindAllBorrowings() ([]models.Borrowing, error)
-	BorrowingUser(borrowing *models.Borrowing, userID any, bookID any) error
-	ReturnUserBorrowing(borrowing *models.Borrowing, userID any, bookID any) error
-	FindByUserIDBorrowing(id any) (*models.Borrowing, error)
+	FindAllBorrowings(status string) ([]models.Borrowing, error)
+	BorrowingUser(borrowing *models.Borrowing, userID uint, bookID uint, barcode string, terms LoanTerms) error
+	ReturnUserBorrowing(borrowing *models.Borrowing, userID uint, bookID uint, barcode string, condition string, fines FineRules) error
+	FindByUserIDBorrowing(id any) ([]models.Borrowing, error)
+	FindBorrowing(id uint) (*models.Borrowing, error)
+	FindBorrowingEvents(borrowingID uint) ([]models.BorrowingEvent, error)
+	RenewBorrowing(borrowing *models.Borrowing, loanDays int, maxRenewals int) error
+	MarkOverdueBorrowings(now time.Time) ([]models.Borrowing, error)
+	MarkBorrowingLost(borrowing *models.Borrowing, fines FineRules) error
 }
 
 type borrowingRepository struct{}
@@ -20,52 +92,432 @@ func NewBorrowingRepository() BorrowingRepository {
 	return &borrowingRepository{}
 }
 
-func (r *borrowingRepository) FindAllBorrowings() ([]models.Borrowing, error) {
+// FindAllBorrowings lists every loan, or only those in status when it is set.
+func (r *borrowingRepository) FindAllBorrowings(status string) ([]models.Borrowing, error) {
 	var borrowings []models.Borrowing
-	err := facades.Orm().Query().Find(&borrowings)
+	query := facades.Orm().Query()
+	if status != "" {
+		query = query.Where("status", status)
+	}
+	err := query.Find(&borrowings)
 	return borrowings, err
 }
 
-func (r *borrowingRepository) BorrowingUser(borrowing *models.Borrowing, userID any, bookID any) error {
-	borrowing.UserID = userID.(uint)
-	borrowing.BookID = bookID.(uint)
-	borrowing.BorrowDate = time.Now().Format("2006-01-02 15:04:05")
-	borrowing.Status = "borrowed"
-	return facades.Orm().Query().Create(borrowing)
+// BorrowingUser lends the copy with barcode to the user, or any available
+// copy of bookID when barcode is empty, due at terms.DueAt. The user's row
+// is locked while their open loans are checked against terms.Limits, so
+// concurrent borrows cannot both slip under a limit, and while their fines are
+// checked against terms.MaxFineBalance.
+//
+// A copy set aside for the user's hold is lent ahead of the shelf, and the
+// borrow fulfils their hold on the book.
+func (r *borrowingRepository) BorrowingUser(borrowing *models.Borrowing, userID uint, bookID uint, barcode string, terms LoanTerms) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		var user models.User
+		if err := tx.LockForUpdate().Where("id", userID).First(&user); err != nil {
+			return err
+		}
+		if user.ID == 0 {
+			return ErrUserNotFound
+		}
+		if terms.MaxFineBalance > 0 {
+			balance, err := sumFineEntries(tx.Model(&models.FineEntry{}).Where("user_id", userID))
+			if err != nil {
+				return err
+			}
+			if balance > terms.MaxFineBalance {
+				return &FineBalanceError{Balance: balance, Max: terms.MaxFineBalance}
+			}
+		}
+		for _, limit := range terms.Limits {
+			if limit.Max <= 0 {
+				continue
+			}
+			current, err := countOpenLoans(tx, userID, limit.CategoryID)
+			if err != nil {
+				return err
+			}
+			if current >= int64(limit.Max) {
+				return &LoanLimitError{LoanLimit: limit, Current: current}
+			}
+		}
+
+		var bookCopy models.BookCopy
+		if barcode != "" {
+			if err := tx.LockForUpdate().Where("barcode", barcode).First(&bookCopy); err != nil {
+				return err
+			}
+			if bookCopy.ID == 0 || (bookID != 0 && bookCopy.BookID != bookID) {
+				return ErrCopyNotFound
+			}
+			bookID = bookCopy.BookID
+		}
+
+		var book models.Book
+		if err := tx.LockForUpdate().Where("id", bookID).First(&book); err != nil {
+			return err
+		}
+		if book.ID == 0 {
+			return ErrBookNotFound
+		}
+
+		var hold models.Reservation
+		err := tx.LockForUpdate().
+			Where("user_id", userID).
+			Where("book_id", bookID).
+			WhereIn("status", activeReservationStatuses).
+			First(&hold)
+		if err != nil {
+			return err
+		}
+		heldCopyID := uint(0)
+		if hold.Status == models.ReservationReady && hold.BookCopyID != nil {
+			heldCopyID = *hold.BookCopyID
+		}
+
+		switch {
+		case barcode != "":
+			if bookCopy.Status != models.CopyAvailable && (bookCopy.Status != models.CopyOnHold || bookCopy.ID != heldCopyID) {
+				return ErrCopyUnavailable
+			}
+		case heldCopyID != 0:
+			if err := tx.LockForUpdate().Where("id", heldCopyID).First(&bookCopy); err != nil {
+				return err
+			}
+		}
+		if bookCopy.ID == 0 {
+			err := tx.LockForUpdate().
+				Where("book_id", bookID).
+				Where("status", models.CopyAvailable).
+				OrderBy("id").
+				First(&bookCopy)
+			if err != nil {
+				return err
+			}
+			if bookCopy.ID == 0 {
+				return ErrBookOutOfStock
+			}
+		}
+
+		bookCopy.Status = models.CopyOnLoan
+		if err := tx.Save(&bookCopy); err != nil {
+			return err
+		}
+
+		if hold.ID != 0 {
+			hold.Status = models.ReservationFulfilled
+			if err := tx.Save(&hold); err != nil {
+				return err
+			}
+			// The patron took a different copy from the shelf, so the one
+			// set aside for them moves on down the queue.
+			if heldCopyID != 0 && heldCopyID != bookCopy.ID {
+				if err := releaseHeldCopy(tx, heldCopyID, time.Now()); err != nil {
+					return err
+				}
+			}
+		}
+		if err := syncBookStock(tx, bookID); err != nil {
+			return err
+		}
+
+		borrowing.UserID = userID
+		borrowing.BookID = bookID
+		borrowing.BookCopyID = &bookCopy.ID
+		borrowing.BorrowDate = time.Now().Format("2006-01-02 15:04:05")
+		if !terms.DueAt.IsZero() {
+			borrowing.DueAt = &terms.DueAt
+		}
+		borrowing.Status = "borrowed"
+		return tx.Create(borrowing)
+	})
 }
 
-func (r *borrowingRepository) ReturnUserBorrowing(borrowing *models.Borrowing, userID any, bookID any) error {
-	borrowing.UserID = userID.(uint)
-	borrowing.BookID = bookID.(uint)
+// ReturnUserBorrowing takes back the user's loan of the copy with barcode, or
+// their latest loan of bookID when barcode is empty. A non-empty condition
+// records the state the copy came back in. The copy is set aside for the next
+// hold on the book when there is one.
+//
+// A late return is charged its remaining late fee under fines. A loan that
+// was marked lost has the unpaid part of its replacement charge waived
+// instead.
+func (r *borrowingRepository) ReturnUserBorrowing(borrowing *models.Borrowing, userID uint, bookID uint, barcode string, condition string, fines FineRules) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		query := tx.LockForUpdate().Where("user_id", userID)
+		if barcode != "" {
+			var bookCopy models.BookCopy
+			if err := tx.Where("barcode", barcode).First(&bookCopy); err != nil {
+				return err
+			}
+			if bookCopy.ID == 0 {
+				return ErrCopyNotFound
+			}
+			query = query.Where("book_copy_id", bookCopy.ID)
+		} else {
+			// A patron may have several copies of the book out, so only
+			// loans that are still out are candidates.
+			query = query.Where("book_id", bookID).WhereIn("status", returnableLoanStatuses)
+		}
+
+		// The most recent loan decides whether there is anything left to
+		// return.
+		if err := query.OrderByDesc("id").First(borrowing); err != nil {
+			return err
+		}
+		if borrowing.ID == 0 {
+			if barcode != "" {
+				return ErrBorrowingNotFound
+			}
+			returned, err := tx.Model(&models.Borrowing{}).Where("user_id", userID).Where("book_id", bookID).Exists()
+			if err != nil {
+				return err
+			}
+			if returned {
+				return ErrAlreadyReturned
+			}
+			return ErrBorrowingNotFound
+		}
+		if borrowing.Status == "returned" {
+			return ErrAlreadyReturned
+		}
+
+		if borrowing.BookCopyID != nil {
+			var bookCopy models.BookCopy
+			if err := tx.LockForUpdate().Where("id", *borrowing.BookCopyID).First(&bookCopy); err != nil {
+				return err
+			}
+			if bookCopy.ID != 0 {
+				bookCopy.Status = models.CopyAvailable
+				if condition != "" {
+					bookCopy.Condition = condition
+				}
+				if err := assignCopyToNextHold(tx, &bookCopy, time.Now()); err != nil {
+					return err
+				}
+				if err := tx.Save(&bookCopy); err != nil {
+					return err
+				}
+			}
+		}
+		if err := syncBookStock(tx, borrowing.BookID); err != nil {
+			return err
+		}
 
+		now := time.Now()
+		if borrowing.Status == "lost" {
+			if err := waiveReplacement(tx, borrowing); err != nil {
+				return err
+			}
+		} else if _, err := accrueLateFee(tx, borrowing, fines, now); err != nil {
+			return err
+		}
+
+		borrowing.ReturnDate = now.Format("2006-01-02 15:04:05")
+		borrowing.Status = "returned"
+		return tx.Save(borrowing)
+	})
+}
+
+func (r *borrowingRepository) FindByUserIDBorrowing(id any) ([]models.Borrowing, error) {
+	var borrowings []models.Borrowing
 	err := facades.Orm().Query().
-		Where("user_id", borrowing.UserID).
-		Where("book_id", borrowing.BookID).
-		First(borrowing)
+		Where("user_id", id).
+		OrderByDesc("id").
+		Find(&borrowings)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return borrowings, nil
+}
 
-	borrowing.ReturnDate = time.Now().Format("2006-01-02 15:04:05")
-	borrowing.Status = "returned"
-
-	err = facades.Orm().Query().Save(borrowing)
-	if err != nil {
-		return err
+func (r *borrowingRepository) FindBorrowing(id uint) (*models.Borrowing, error) {
+	var borrowing models.Borrowing
+	if err := facades.Orm().Query().Where("id", id).First(&borrowing); err != nil {
+		return nil, err
+	}
+	if borrowing.ID == 0 {
+		return nil, ErrBorrowingNotFound
 	}
+	return &borrowing, nil
+}
 
-	return nil
+func (r *borrowingRepository) FindBorrowingEvents(borrowingID uint) ([]models.BorrowingEvent, error) {
+	var events []models.BorrowingEvent
+	err := facades.Orm().Query().Where("borrowing_id", borrowingID).OrderBy("id").Find(&events)
+	return events, err
 }
 
-func (r *borrowingRepository) FindByUserIDBorrowing(id any) (*models.Borrowing, error) {
-	var borrowing models.Borrowing
-	err := facades.Orm().Query().
-		Where("user_id", id).
-		First(&borrowing)
+// RenewBorrowing pushes the loan's due date back by loanDays and records the
+// renewal in its history. It is refused once the loan was renewed
+// maxRenewals times, while any of the patron's loans, this one included, is
+// past due, or while another patron holds the book. Loans without a due date
+// are renewed from now.
+func (r *borrowingRepository) RenewBorrowing(borrowing *models.Borrowing, loanDays int, maxRenewals int) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		if err := tx.LockForUpdate().Where("id", borrowing.ID).First(borrowing); err != nil {
+			return err
+		}
+		if borrowing.ID == 0 {
+			return ErrBorrowingNotFound
+		}
+		switch borrowing.Status {
+		case "returned":
+			return ErrAlreadyReturned
+		case "lost":
+			return ErrLoanLost
+		}
+		if borrowing.Renewals >= maxRenewals {
+			return ErrRenewalLimit
+		}
+
+		now := time.Now()
+		overdue, err := tx.Model(&models.Borrowing{}).
+			Where("user_id", borrowing.UserID).
+			WhereIn("status", openLoanStatuses).
+			Where("due_at < ?", now).
+			Exists()
+		if err != nil {
+			return err
+		}
+		if overdue {
+			return ErrHasOverdueLoans
+		}
+
+		held, err := tx.Model(&models.Reservation{}).
+			Where("book_id", borrowing.BookID).
+			Where("user_id <> ?", borrowing.UserID).
+			WhereIn("status", activeReservationStatuses).
+			Exists()
+		if err != nil {
+			return err
+		}
+		if held {
+			return ErrBookOnHold
+		}
+
+		previous := borrowing.DueAt
+		from := now
+		if previous != nil {
+			from = *previous
+		}
+		dueAt := from.AddDate(0, 0, loanDays)
+		borrowing.DueAt = &dueAt
+		borrowing.Renewals++
+		if err := tx.Save(borrowing); err != nil {
+			return err
+		}
 
+		return tx.Create(&models.BorrowingEvent{
+			BorrowingID:   borrowing.ID,
+			Event:         models.BorrowingRenewed,
+			PreviousDueAt: previous,
+			DueAt:         &dueAt,
+		})
+	})
+}
+
+// MarkOverdueBorrowings moves the loans that were due before now from
+// borrowed to overdue, recording the change in each loan's history, and
+// returns the loans it moved. Loans already overdue are left as they are.
+func (r *borrowingRepository) MarkOverdueBorrowings(now time.Time) ([]models.Borrowing, error) {
+	var due []models.Borrowing
+	err := facades.Orm().Query().
+		Where("status", "borrowed").
+		Where("due_at < ?", now).
+		OrderBy("id").
+		Find(&due)
 	if err != nil {
 		return nil, err
 	}
-	return &borrowing, nil
+
+	var overdue []models.Borrowing
+	for _, borrowing := range due {
+		flipped := false
+		err := facades.Orm().Transaction(func(tx orm.Query) error {
+			// The loan may have been returned or renewed since it was listed.
+			if err := tx.LockForUpdate().Where("id", borrowing.ID).First(&borrowing); err != nil {
+				return err
+			}
+			if borrowing.Status != "borrowed" || borrowing.DueAt == nil || !borrowing.DueAt.Before(now) {
+				return nil
+			}
+
+			borrowing.Status = "overdue"
+			if err := tx.Save(&borrowing); err != nil {
+				return err
+			}
+			flipped = true
+			return tx.Create(&models.BorrowingEvent{
+				BorrowingID: borrowing.ID,
+				Event:       models.BorrowingOverdue,
+				DueAt:       borrowing.DueAt,
+			})
+		})
+		if err != nil {
+			return overdue, err
+		}
+		if flipped {
+			overdue = append(overdue, borrowing)
+		}
+	}
+	return overdue, nil
+}
+
+// MarkBorrowingLost closes an open loan whose copy is not coming back. The
+// copy is marked lost too; should it turn up, returning the loan puts it back
+// on the shelf. The patron is charged the late fee accrued so far and the
+// replacement charge under fines.
+func (r *borrowingRepository) MarkBorrowingLost(borrowing *models.Borrowing, fines FineRules) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		if err := tx.LockForUpdate().Where("id", borrowing.ID).First(borrowing); err != nil {
+			return err
+		}
+		if borrowing.ID == 0 {
+			return ErrBorrowingNotFound
+		}
+		switch borrowing.Status {
+		case "returned":
+			return ErrAlreadyReturned
+		case "lost":
+			return ErrLoanLost
+		}
+
+		if borrowing.BookCopyID != nil {
+			_, err := tx.Model(&models.BookCopy{}).Where("id", *borrowing.BookCopyID).Update("status", models.CopyLost)
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := accrueLateFee(tx, borrowing, fines, time.Now()); err != nil {
+			return err
+		}
+		if err := chargeReplacement(tx, borrowing, fines); err != nil {
+			return err
+		}
+
+		borrowing.Status = "lost"
+		if err := tx.Save(borrowing); err != nil {
+			return err
+		}
+		return tx.Create(&models.BorrowingEvent{
+			BorrowingID: borrowing.ID,
+			Event:       models.BorrowingLost,
+			DueAt:       borrowing.DueAt,
+		})
+	})
+}
+
+// countOpenLoans counts the user's loans still out, only of books filed under
+// categoryID or its subcategories when it is set.
+func countOpenLoans(tx orm.Query, userID uint, categoryID uint) (int64, error) {
+	query := tx.Model(&models.Borrowing{}).Where("user_id", userID).WhereIn("status", openLoanStatuses)
+	if categoryID != 0 {
+		query = query.Where(`book_id IN (SELECT bc.book_id FROM book_categories bc
+			JOIN categories c ON c.id = bc.category_id
+			JOIN categories root ON c.path LIKE CONCAT(root.path, '%')
+			WHERE root.id = ?)`, categoryID)
+	}
+	return query.Count()
 }
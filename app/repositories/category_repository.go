@@ -0,0 +1,231 @@
+package repositories
+
+import (
+	"errors"
+	"goravel/app/models"
+	"strconv"
+	"strings"
+
+	"github.com/goravel/framework/contracts/database/orm"
+	"github.com/goravel/framework/facades"
+)
+
+var (
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategoryCycle       = errors.New("a category cannot be moved under itself or its descendants")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+)
+
+// CategoryCount holds the number of books filed directly under a category
+// and the number of distinct books anywhere in its subtree.
+type CategoryCount struct {
+	CategoryID uint
+	Direct     int64
+	Total      int64
+}
+
+type CategoryRepository interface {
+	FindAllCategories() ([]models.Category, error)
+	FindCategory(id uint) (*models.Category, error)
+	CountCategoryBooks() (map[uint]CategoryCount, error)
+	SlugTaken(slug string, excludeID uint) (bool, error)
+	CreateCategory(category *models.Category) error
+	UpdateCategory(category *models.Category) error
+	DeleteCategory(category *models.Category) error
+	FindBookCategories(bookIDs []uint) (map[uint][]models.Category, error)
+	SyncBookCategories(bookID uint, categoryIDs []uint) error
+}
+
+type categoryRepository struct{}
+
+func NewCategoryRepository() CategoryRepository {
+	return &categoryRepository{}
+}
+
+// FindAllCategories returns every category, ordered by code and name.
+func (r *categoryRepository) FindAllCategories() ([]models.Category, error) {
+	var categories []models.Category
+	err := facades.Orm().Query().OrderBy("code").OrderBy("name").OrderBy("id").Find(&categories)
+	return categories, err
+}
+
+func (r *categoryRepository) FindCategory(id uint) (*models.Category, error) {
+	var category models.Category
+	if err := facades.Orm().Query().Where("id", id).First(&category); err != nil {
+		return nil, err
+	}
+	if category.ID == 0 {
+		return nil, ErrCategoryNotFound
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) CountCategoryBooks() (map[uint]CategoryCount, error) {
+	var direct []CategoryCount
+	if err := facades.Orm().Query().Raw(`SELECT category_id, COUNT(*) AS direct
+		FROM book_categories
+		GROUP BY category_id`).Scan(&direct); err != nil {
+		return nil, err
+	}
+
+	// A book filed under two categories of the same subtree counts once.
+	var total []CategoryCount
+	if err := facades.Orm().Query().Raw(`SELECT c.id AS category_id, COUNT(DISTINCT bc.book_id) AS total
+		FROM categories c
+		JOIN categories d ON d.path LIKE CONCAT(c.path, '%')
+		JOIN book_categories bc ON bc.category_id = d.id
+		GROUP BY c.id`).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]CategoryCount, len(total))
+	for _, count := range total {
+		counts[count.CategoryID] = count
+	}
+	for _, count := range direct {
+		entry := counts[count.CategoryID]
+		entry.CategoryID = count.CategoryID
+		entry.Direct = count.Direct
+		counts[count.CategoryID] = entry
+	}
+	return counts, nil
+}
+
+func (r *categoryRepository) SlugTaken(slug string, excludeID uint) (bool, error) {
+	query := facades.Orm().Query().Model(&models.Category{}).Where("slug", slug)
+	if excludeID > 0 {
+		query = query.Where("id != ?", excludeID)
+	}
+	return query.Exists()
+}
+
+// CreateCategory saves a category under its parent, or as a root when
+// ParentID is nil.
+func (r *categoryRepository) CreateCategory(category *models.Category) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		parentPath, err := categoryParentPath(tx, category.ParentID)
+		if err != nil {
+			return err
+		}
+
+		// The path needs the new ID, so it is filled in after the insert.
+		category.Path = parentPath
+		if err := tx.Create(category); err != nil {
+			return err
+		}
+		category.Path = parentPath + strconv.FormatUint(uint64(category.ID), 10) + "/"
+		return tx.Save(category)
+	})
+}
+
+// UpdateCategory saves a category. Moving it to another parent moves its
+// whole subtree along.
+func (r *categoryRepository) UpdateCategory(category *models.Category) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		var current models.Category
+		if err := tx.LockForUpdate().Where("id", category.ID).First(&current); err != nil {
+			return err
+		}
+		if current.ID == 0 {
+			return ErrCategoryNotFound
+		}
+
+		parentPath, err := categoryParentPath(tx, category.ParentID)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(parentPath, current.Path) {
+			return ErrCategoryCycle
+		}
+
+		category.Path = parentPath + strconv.FormatUint(uint64(category.ID), 10) + "/"
+		if category.Path != current.Path {
+			if _, err := tx.Exec(
+				"UPDATE categories SET path = CONCAT(?, SUBSTRING(path, ?)) WHERE path LIKE ? AND id != ?",
+				category.Path, len(current.Path)+1, current.Path+"%", category.ID,
+			); err != nil {
+				return err
+			}
+		}
+		return tx.Save(category)
+	})
+}
+
+// DeleteCategory removes a category without subcategories. Its books are
+// unfiled from it but otherwise kept.
+func (r *categoryRepository) DeleteCategory(category *models.Category) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		hasChildren, err := tx.Model(&models.Category{}).Where("parent_id", category.ID).Exists()
+		if err != nil {
+			return err
+		}
+		if hasChildren {
+			return ErrCategoryHasChildren
+		}
+
+		_, err = tx.Delete(category)
+		return err
+	})
+}
+
+// FindBookCategories returns the categories of each of the given books,
+// keyed by book ID.
+func (r *categoryRepository) FindBookCategories(bookIDs []uint) (map[uint][]models.Category, error) {
+	categories := map[uint][]models.Category{}
+	if len(bookIDs) == 0 {
+		return categories, nil
+	}
+
+	var rows []struct {
+		models.Category
+		BookID uint
+	}
+	if err := facades.Orm().Query().Raw(`SELECT categories.*, book_categories.book_id
+		FROM book_categories
+		JOIN categories ON categories.id = book_categories.category_id
+		WHERE book_categories.book_id IN ?
+		ORDER BY categories.code, categories.name`, bookIDs).Scan(&rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		categories[row.BookID] = append(categories[row.BookID], row.Category)
+	}
+	return categories, nil
+}
+
+// SyncBookCategories files a book under exactly the given categories.
+func (r *categoryRepository) SyncBookCategories(bookID uint, categoryIDs []uint) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		return syncBookCategories(tx, bookID, categoryIDs)
+	})
+}
+
+func syncBookCategories(tx orm.Query, bookID uint, categoryIDs []uint) error {
+	if _, err := tx.Where("book_id", bookID).Delete(&models.BookCategory{}); err != nil {
+		return err
+	}
+	for _, categoryID := range categoryIDs {
+		if err := tx.Create(&models.BookCategory{BookID: bookID, CategoryID: categoryID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// categoryParentPath returns the path of the parent a category is placed
+// under, or "/" for a root category.
+func categoryParentPath(tx orm.Query, parentID *uint) (string, error) {
+	if parentID == nil {
+		return "/", nil
+	}
+
+	var parent models.Category
+	if err := tx.Where("id", *parentID).First(&parent); err != nil {
+		return "", err
+	}
+	if parent.ID == 0 {
+		return "", ErrCategoryNotFound
+	}
+	return parent.Path, nil
+}
@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"errors"
+	"goravel/app/models"
+	"time"
+
+	"github.com/goravel/framework/contracts/database/orm"
+	"github.com/goravel/framework/facades"
+)
+
+var (
+	ErrFineExceedsBalance    = errors.New("amount is more than the loan's outstanding fines")
+	ErrRefundExceedsPayments = errors.New("amount is more than was paid towards the loan")
+)
+
+// FineRules are the accrual rules late and lost loans are charged under.
+// Amounts are in the smallest currency unit.
+type FineRules struct {
+	// DailyRate is charged for each whole day a loan is kept past its due
+	// date, not counting the first GraceDays.
+	DailyRate int64
+	GraceDays int
+	// MaxPerItem caps the late fees of a single loan. 0 means no cap.
+	MaxPerItem int64
+	// ReplacementCharge is charged when a loan is marked lost.
+	ReplacementCharge int64
+}
+
+type FineRepository interface {
+	FindUserFineEntries(userID uint) ([]models.FineEntry, error)
+	UserFineBalance(userID uint) (int64, error)
+	RecordFineEntry(entry *models.FineEntry) error
+	AccrueLateFees(rules FineRules, now time.Time) (int, error)
+}
+
+type fineRepository struct{}
+
+func NewFineRepository() FineRepository {
+	return &fineRepository{}
+}
+
+// FindUserFineEntries lists the user's ledger oldest first, the order it is
+// read as a statement.
+func (r *fineRepository) FindUserFineEntries(userID uint) ([]models.FineEntry, error) {
+	var entries []models.FineEntry
+	err := facades.Orm().Query().Where("user_id", userID).OrderBy("id").Find(&entries)
+	return entries, err
+}
+
+func (r *fineRepository) UserFineBalance(userID uint) (int64, error) {
+	return sumFineEntries(facades.Orm().Query().Model(&models.FineEntry{}).Where("user_id", userID))
+}
+
+// RecordFineEntry appends a staff entry to the ledger of the loan's patron.
+// entry.Amount is given as a positive amount and signed by its type. A
+// payment or waiver can't take the loan's balance below zero, and a refund
+// can't return more than was paid towards the loan.
+func (r *fineRepository) RecordFineEntry(entry *models.FineEntry) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		var borrowing models.Borrowing
+		if err := tx.LockForUpdate().Where("id", entry.BorrowingID).First(&borrowing); err != nil {
+			return err
+		}
+		if borrowing.ID == 0 {
+			return ErrBorrowingNotFound
+		}
+
+		switch entry.Type {
+		case models.FinePayment, models.FineWaiver:
+			balance, err := sumFineEntries(tx.Model(&models.FineEntry{}).Where("borrowing_id", borrowing.ID))
+			if err != nil {
+				return err
+			}
+			if entry.Amount > balance {
+				return ErrFineExceedsBalance
+			}
+			entry.Amount = -entry.Amount
+		case models.FineRefund:
+			paid, err := sumFineEntries(tx.Model(&models.FineEntry{}).
+				Where("borrowing_id", borrowing.ID).
+				WhereIn("type", []any{models.FinePayment, models.FineRefund}))
+			if err != nil {
+				return err
+			}
+			// Payments are negative and refunds positive, so what is left
+			// to refund is the negated sum.
+			if entry.Amount > -paid {
+				return ErrRefundExceedsPayments
+			}
+		}
+
+		entry.UserID = borrowing.UserID
+		return tx.Create(entry)
+	})
+}
+
+// AccrueLateFees brings the late fees of every loan still out past its due
+// date up to what the rules charge as of now. It returns how many loans were
+// charged.
+func (r *fineRepository) AccrueLateFees(rules FineRules, now time.Time) (int, error) {
+	var late []models.Borrowing
+	err := facades.Orm().Query().
+		WhereIn("status", openLoanStatuses).
+		Where("due_at < ?", now).
+		OrderBy("id").
+		Find(&late)
+	if err != nil {
+		return 0, err
+	}
+
+	charged := 0
+	for _, borrowing := range late {
+		var entry *models.FineEntry
+		err := facades.Orm().Transaction(func(tx orm.Query) error {
+			if err := tx.LockForUpdate().Where("id", borrowing.ID).First(&borrowing); err != nil {
+				return err
+			}
+			// A loan returned since it was listed was charged on return.
+			if borrowing.Status != "borrowed" && borrowing.Status != "overdue" {
+				return nil
+			}
+
+			var err error
+			entry, err = accrueLateFee(tx, &borrowing, rules, now)
+			return err
+		})
+		if err != nil {
+			return charged, err
+		}
+		if entry != nil {
+			charged++
+		}
+	}
+	return charged, nil
+}
+
+// accrueLateFee charges the loan whatever its late fee as of until comes to
+// beyond what it was already charged, so it can run any number of times
+// while the loan is out and once more when it ends. It returns the charge,
+// or nil when there was nothing more to charge. The caller holds the lock on
+// the loan.
+func accrueLateFee(tx orm.Query, borrowing *models.Borrowing, rules FineRules, until time.Time) (*models.FineEntry, error) {
+	if borrowing.DueAt == nil || rules.DailyRate <= 0 {
+		return nil, nil
+	}
+
+	days := int64(until.Sub(*borrowing.DueAt)/(24*time.Hour)) - int64(rules.GraceDays)
+	if days <= 0 {
+		return nil, nil
+	}
+	fee := days * rules.DailyRate
+	if rules.MaxPerItem > 0 && fee > rules.MaxPerItem {
+		fee = rules.MaxPerItem
+	}
+
+	charged, err := sumFineEntries(tx.Model(&models.FineEntry{}).
+		Where("borrowing_id", borrowing.ID).
+		Where("type", models.FineCharge).
+		Where("reason", models.FineReasonOverdue))
+	if err != nil {
+		return nil, err
+	}
+	if fee <= charged {
+		return nil, nil
+	}
+
+	entry := &models.FineEntry{
+		UserID:      borrowing.UserID,
+		BorrowingID: borrowing.ID,
+		Type:        models.FineCharge,
+		Reason:      models.FineReasonOverdue,
+		Amount:      fee - charged,
+	}
+	return entry, tx.Create(entry)
+}
+
+// chargeReplacement charges the patron for a copy they lost.
+func chargeReplacement(tx orm.Query, borrowing *models.Borrowing, rules FineRules) error {
+	if rules.ReplacementCharge <= 0 {
+		return nil
+	}
+	return tx.Create(&models.FineEntry{
+		UserID:      borrowing.UserID,
+		BorrowingID: borrowing.ID,
+		Type:        models.FineCharge,
+		Reason:      models.FineReasonLost,
+		Amount:      rules.ReplacementCharge,
+	})
+}
+
+// waiveReplacement waives the part of a lost loan's replacement charge that
+// is still unpaid once the copy turns up after all. Anything already paid is
+// left for staff to refund.
+func waiveReplacement(tx orm.Query, borrowing *models.Borrowing) error {
+	replacement, err := sumFineEntries(tx.Model(&models.FineEntry{}).
+		Where("borrowing_id", borrowing.ID).
+		Where("reason", models.FineReasonLost))
+	if err != nil {
+		return err
+	}
+	balance, err := sumFineEntries(tx.Model(&models.FineEntry{}).Where("borrowing_id", borrowing.ID))
+	if err != nil {
+		return err
+	}
+
+	waiver := min(replacement, balance)
+	if waiver <= 0 {
+		return nil
+	}
+	return tx.Create(&models.FineEntry{
+		UserID:      borrowing.UserID,
+		BorrowingID: borrowing.ID,
+		Type:        models.FineWaiver,
+		Reason:      models.FineReasonLost,
+		Amount:      -waiver,
+		Note:        "Lost copy returned",
+	})
+}
+
+// sumFineEntries adds up the amounts of the ledger entries query matches.
+func sumFineEntries(query orm.Query) (int64, error) {
+	var result struct {
+		Total int64
+	}
+	err := query.Select("COALESCE(SUM(amount), 0) AS total").Scan(&result)
+	return result.Total, err
+}
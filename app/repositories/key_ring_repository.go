@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"encoding/json"
+	"goravel/app/models"
+	"time"
+
+	"github.com/goravel/framework/facades"
+)
+
+type KeyRingRepository interface {
+	LoadKeys() ([]models.SigningKey, error)
+	SaveKeys(keys []models.SigningKey) error
+	LastModified() (time.Time, error)
+}
+
+type keyRingRepository struct{}
+
+func NewKeyRingRepository() KeyRingRepository {
+	return &keyRingRepository{}
+}
+
+// LoadKeys reads the key ring, returning no keys when none have been generated yet.
+func (r *keyRingRepository) LoadKeys() ([]models.SigningKey, error) {
+	path := r.path()
+	if facades.Storage().Missing(path) {
+		return nil, nil
+	}
+
+	content, err := facades.Storage().GetBytes(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []models.SigningKey
+	if err := json.Unmarshal(content, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *keyRingRepository) SaveKeys(keys []models.SigningKey) error {
+	content, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	return facades.Storage().Put(r.path(), string(content))
+}
+
+func (r *keyRingRepository) LastModified() (time.Time, error) {
+	path := r.path()
+	if facades.Storage().Missing(path) {
+		return time.Time{}, nil
+	}
+	return facades.Storage().LastModified(path)
+}
+
+func (r *keyRingRepository) path() string {
+	return facades.Config().GetString("jwt.keys_path", "jwt/keys.json")
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"errors"
+	"goravel/app/models"
+
+	"github.com/goravel/framework/facades"
+)
+
+var ErrLoanPolicyNotFound = errors.New("loan policy not found")
+
+type LoanPolicyRepository interface {
+	FindLoanPolicies() ([]models.LoanPolicy, error)
+	FindLoanPolicy(id uint) (*models.LoanPolicy, error)
+	LoanPolicyExists(role string, categoryID *uint, excludeID uint) (bool, error)
+	CreateLoanPolicy(policy *models.LoanPolicy) error
+	UpdateLoanPolicy(policy *models.LoanPolicy) error
+	DeleteLoanPolicy(policy *models.LoanPolicy) error
+}
+
+type loanPolicyRepository struct{}
+
+func NewLoanPolicyRepository() LoanPolicyRepository {
+	return &loanPolicyRepository{}
+}
+
+func (r *loanPolicyRepository) FindLoanPolicies() ([]models.LoanPolicy, error) {
+	var policies []models.LoanPolicy
+	err := facades.Orm().Query().OrderBy("role").OrderBy("category_id").OrderBy("id").Find(&policies)
+	return policies, err
+}
+
+func (r *loanPolicyRepository) FindLoanPolicy(id uint) (*models.LoanPolicy, error) {
+	var policy models.LoanPolicy
+	if err := facades.Orm().Query().Where("id", id).First(&policy); err != nil {
+		return nil, err
+	}
+	if policy.ID == 0 {
+		return nil, ErrLoanPolicyNotFound
+	}
+	return &policy, nil
+}
+
+// LoanPolicyExists reports whether another policy already covers the same
+// role and category.
+func (r *loanPolicyRepository) LoanPolicyExists(role string, categoryID *uint, excludeID uint) (bool, error) {
+	query := facades.Orm().Query().Model(&models.LoanPolicy{}).Where("role", role).Where("id <> ?", excludeID)
+	if categoryID == nil {
+		query = query.WhereNull("category_id")
+	} else {
+		query = query.Where("category_id", *categoryID)
+	}
+	return query.Exists()
+}
+
+func (r *loanPolicyRepository) CreateLoanPolicy(policy *models.LoanPolicy) error {
+	return facades.Orm().Query().Create(policy)
+}
+
+func (r *loanPolicyRepository) UpdateLoanPolicy(policy *models.LoanPolicy) error {
+	return facades.Orm().Query().Save(policy)
+}
+
+func (r *loanPolicyRepository) DeleteLoanPolicy(policy *models.LoanPolicy) error {
+	_, err := facades.Orm().Query().Delete(policy)
+	return err
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"errors"
+	"goravel/app/models"
+	"time"
+
+	"github.com/goravel/framework/contracts/database/orm"
+	"github.com/goravel/framework/facades"
+)
+
+var ErrPasswordResetInvalid = errors.New("password reset token is invalid or expired")
+
+type PasswordResetRepository interface {
+	CreatePasswordReset(reset *models.PasswordReset) error
+	ResetPassword(tokenHash string, hashedPassword string) (*models.User, error)
+}
+
+type passwordResetRepository struct{}
+
+func NewPasswordResetRepository() PasswordResetRepository {
+	return &passwordResetRepository{}
+}
+
+// CreatePasswordReset stores reset and discards any earlier unused token for
+// the same user, so only the most recent link works.
+func (r *passwordResetRepository) CreatePasswordReset(reset *models.PasswordReset) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		if _, err := tx.Where("user_id", reset.UserID).WhereNull("used_at").Delete(&models.PasswordReset{}); err != nil {
+			return err
+		}
+		return tx.Create(reset)
+	})
+}
+
+// ResetPassword consumes the token and sets the new password. Every access
+// and refresh token issued to the user before the reset stops working.
+func (r *passwordResetRepository) ResetPassword(tokenHash string, hashedPassword string) (*models.User, error) {
+	var user models.User
+	err := facades.Orm().Transaction(func(tx orm.Query) error {
+		var reset models.PasswordReset
+		if err := tx.LockForUpdate().Where("token_hash", tokenHash).First(&reset); err != nil {
+			return err
+		}
+		now := time.Now()
+		if reset.ID == 0 || reset.UsedAt != nil || reset.ExpiresAt.Before(now) {
+			return ErrPasswordResetInvalid
+		}
+
+		if err := tx.Where("id", reset.UserID).FirstOrFail(&user); err != nil {
+			return err
+		}
+
+		reset.UsedAt = &now
+		if err := tx.Save(&reset); err != nil {
+			return err
+		}
+
+		user.Password = hashedPassword
+		user.TokensRevokedAt = &now
+		if err := tx.Save(&user); err != nil {
+			return err
+		}
+
+		_, err := tx.Model(&models.RefreshToken{}).
+			Where("user_id", user.ID).
+			WhereNull("revoked_at").
+			Update("revoked_at", now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
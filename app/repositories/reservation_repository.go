@@ -0,0 +1,286 @@
+package repositories
+
+import (
+	"errors"
+	"goravel/app/models"
+	"time"
+
+	"github.com/goravel/framework/contracts/database/orm"
+	"github.com/goravel/framework/facades"
+)
+
+var (
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrReservationNotActive = errors.New("reservation is no longer active")
+	ErrAlreadyReserved      = errors.New("book is already on hold for this patron")
+	ErrAlreadyBorrowed      = errors.New("patron already has this book on loan")
+	ErrBookAvailable        = errors.New("book has copies available to borrow")
+	ErrBookOnHold           = errors.New("another patron is waiting for this book")
+)
+
+// activeReservationStatuses are the statuses of holds still in their book's
+// queue.
+var activeReservationStatuses = []any{models.ReservationWaiting, models.ReservationReady}
+
+type ReservationRepository interface {
+	CreateReservation(reservation *models.Reservation) error
+	FindReservation(id uint) (*models.Reservation, error)
+	FindUserReservations(userID uint) ([]models.Reservation, error)
+	FindBookReservations(bookID uint) ([]models.Reservation, error)
+	CancelReservation(reservation *models.Reservation) error
+	ExpireReservations(now time.Time) (int, error)
+}
+
+type reservationRepository struct{}
+
+func NewReservationRepository() ReservationRepository {
+	return &reservationRepository{}
+}
+
+// CreateReservation places a hold at the back of the book's queue. Holds are
+// only taken on books with no copy on the shelf, and a patron holds a book at
+// most once and not while they have it on loan.
+func (r *reservationRepository) CreateReservation(reservation *models.Reservation) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		var book models.Book
+		if err := tx.LockForUpdate().Where("id", reservation.BookID).First(&book); err != nil {
+			return err
+		}
+		if book.ID == 0 {
+			return ErrBookNotFound
+		}
+		if book.Stock > 0 {
+			return ErrBookAvailable
+		}
+
+		held, err := tx.Model(&models.Reservation{}).
+			Where("user_id", reservation.UserID).
+			Where("book_id", reservation.BookID).
+			WhereIn("status", activeReservationStatuses).
+			Exists()
+		if err != nil {
+			return err
+		}
+		if held {
+			return ErrAlreadyReserved
+		}
+
+		borrowed, err := tx.Model(&models.Borrowing{}).
+			Where("user_id", reservation.UserID).
+			Where("book_id", reservation.BookID).
+			WhereIn("status", openLoanStatuses).
+			Exists()
+		if err != nil {
+			return err
+		}
+		if borrowed {
+			return ErrAlreadyBorrowed
+		}
+
+		reservation.Status = models.ReservationWaiting
+		if err := tx.Create(reservation); err != nil {
+			return err
+		}
+		reservation.Position, err = queuePosition(tx, reservation)
+		return err
+	})
+}
+
+func (r *reservationRepository) FindReservation(id uint) (*models.Reservation, error) {
+	var reservation models.Reservation
+	if err := facades.Orm().Query().Where("id", id).First(&reservation); err != nil {
+		return nil, err
+	}
+	if reservation.ID == 0 {
+		return nil, ErrReservationNotFound
+	}
+	return &reservation, nil
+}
+
+// FindUserReservations lists the user's holds, newest first, with the queue
+// position of those still waiting.
+func (r *reservationRepository) FindUserReservations(userID uint) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	query := facades.Orm().Query()
+	if err := query.Where("user_id", userID).OrderByDesc("id").Find(&reservations); err != nil {
+		return nil, err
+	}
+
+	for i := range reservations {
+		if reservations[i].Status != models.ReservationWaiting {
+			continue
+		}
+		position, err := queuePosition(query, &reservations[i])
+		if err != nil {
+			return nil, err
+		}
+		reservations[i].Position = position
+	}
+	return reservations, nil
+}
+
+// FindBookReservations lists the book's queue in the order it is served:
+// holds ready for pickup, then waiting holds from the oldest.
+func (r *reservationRepository) FindBookReservations(bookID uint) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := facades.Orm().Query().
+		Where("book_id", bookID).
+		WhereIn("status", activeReservationStatuses).
+		OrderBy("id").
+		Find(&reservations)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]models.Reservation, 0, len(reservations))
+	for _, reservation := range reservations {
+		if reservation.Status == models.ReservationReady {
+			ordered = append(ordered, reservation)
+		}
+	}
+	position := 0
+	for _, reservation := range reservations {
+		if reservation.Status == models.ReservationWaiting {
+			position++
+			reservation.Position = position
+			ordered = append(ordered, reservation)
+		}
+	}
+	return ordered, nil
+}
+
+// CancelReservation withdraws an active hold. A copy that was set aside for
+// it goes to the next hold in the queue, or back on the shelf.
+func (r *reservationRepository) CancelReservation(reservation *models.Reservation) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		return closeReservation(tx, reservation, models.ReservationCancelled, time.Now())
+	})
+}
+
+// ExpireReservations expires the holds whose pickup window closed before now
+// and passes their copies down the queue. It returns how many expired.
+func (r *reservationRepository) ExpireReservations(now time.Time) (int, error) {
+	var lapsed []models.Reservation
+	err := facades.Orm().Query().
+		Where("status", models.ReservationReady).
+		Where("expires_at < ?", now).
+		OrderBy("id").
+		Find(&lapsed)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range lapsed {
+		err := facades.Orm().Transaction(func(tx orm.Query) error {
+			return closeReservation(tx, &lapsed[i], models.ReservationExpired, now)
+		})
+		// A hold picked up or cancelled since it was listed is left alone.
+		if errors.Is(err, ErrReservationNotActive) {
+			continue
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// closeReservation moves an active hold to status and releases any copy set
+// aside for it.
+func closeReservation(tx orm.Query, reservation *models.Reservation, status string, now time.Time) error {
+	if err := tx.LockForUpdate().Where("id", reservation.ID).First(reservation); err != nil {
+		return err
+	}
+	if reservation.ID == 0 {
+		return ErrReservationNotFound
+	}
+	if reservation.Status != models.ReservationWaiting && reservation.Status != models.ReservationReady {
+		return ErrReservationNotActive
+	}
+	if status == models.ReservationExpired && (reservation.ExpiresAt == nil || !reservation.ExpiresAt.Before(now)) {
+		return ErrReservationNotActive
+	}
+
+	heldCopyID := reservation.BookCopyID
+	reservation.Status = status
+	reservation.Position = 0
+	if err := tx.Save(reservation); err != nil {
+		return err
+	}
+
+	if heldCopyID == nil {
+		return nil
+	}
+	return releaseHeldCopy(tx, *heldCopyID, now)
+}
+
+// releaseHeldCopy hands a copy that was set aside for a hold to the next
+// hold in its book's queue, or puts it back on the shelf when nobody is
+// waiting.
+func releaseHeldCopy(tx orm.Query, copyID uint, now time.Time) error {
+	var bookCopy models.BookCopy
+	if err := tx.LockForUpdate().Where("id", copyID).First(&bookCopy); err != nil {
+		return err
+	}
+	if bookCopy.ID == 0 || bookCopy.Status != models.CopyOnHold {
+		return nil
+	}
+
+	bookCopy.Status = models.CopyAvailable
+	if err := assignCopyToNextHold(tx, &bookCopy, now); err != nil {
+		return err
+	}
+	if err := tx.Save(&bookCopy); err != nil {
+		return err
+	}
+	return syncBookStock(tx, bookCopy.BookID)
+}
+
+// assignCopyToNextHold sets an available copy aside for the oldest hold
+// waiting on its book, which is then ready for pickup for the configured
+// number of days. The copy's status is changed but not saved; the caller saves
+// it and resyncs the book's stock. When nobody is waiting the copy is left
+// as it is.
+func assignCopyToNextHold(tx orm.Query, bookCopy *models.BookCopy, now time.Time) error {
+	if bookCopy.Status != models.CopyAvailable {
+		return nil
+	}
+
+	var hold models.Reservation
+	err := tx.LockForUpdate().
+		Where("book_id", bookCopy.BookID).
+		Where("status", models.ReservationWaiting).
+		OrderBy("id").
+		First(&hold)
+	if err != nil {
+		return err
+	}
+	if hold.ID == 0 {
+		return nil
+	}
+
+	expiresAt := now.AddDate(0, 0, facades.Config().GetInt("loans.pickup_days", 3))
+	hold.Status = models.ReservationReady
+	hold.BookCopyID = &bookCopy.ID
+	hold.ReadyAt = &now
+	hold.ExpiresAt = &expiresAt
+	if err := tx.Save(&hold); err != nil {
+		return err
+	}
+
+	bookCopy.Status = models.CopyOnHold
+	return nil
+}
+
+// queuePosition counts the waiting holds on the reservation's book placed no
+// later than it.
+func queuePosition(query orm.Query, reservation *models.Reservation) (int, error) {
+	ahead, err := query.Model(&models.Reservation{}).
+		Where("book_id", reservation.BookID).
+		Where("status", models.ReservationWaiting).
+		Where("id <= ?", reservation.ID).
+		Count()
+	return int(ahead), err
+}
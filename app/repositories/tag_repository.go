@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"errors"
+	"goravel/app/models"
+
+	"github.com/goravel/framework/contracts/database/orm"
+	"github.com/goravel/framework/facades"
+)
+
+var ErrTagNotFound = errors.New("tag not found")
+
+// TagCount is a tag with the number of books carrying it.
+type TagCount struct {
+	models.Tag
+	BookCount int64
+}
+
+type TagRepository interface {
+	FindTags(name string) ([]TagCount, error)
+	FindTag(id uint) (*models.Tag, error)
+	FirstOrCreateTag(tag *models.Tag) error
+	SlugTaken(slug string, excludeID uint) (bool, error)
+	UpdateTag(tag *models.Tag) error
+	DeleteTag(tag *models.Tag) error
+	FindBookTags(bookIDs []uint) (map[uint][]models.Tag, error)
+	SyncBookTags(bookID uint, tagIDs []uint) error
+}
+
+type tagRepository struct{}
+
+func NewTagRepository() TagRepository {
+	return &tagRepository{}
+}
+
+// FindTags lists tags by name with their book counts, optionally only those
+// whose name contains name.
+func (r *tagRepository) FindTags(name string) ([]TagCount, error) {
+	where, args := "", []any{}
+	if name != "" {
+		where, args = `WHERE LOWER(tags.name) LIKE ? ESCAPE '!'`, append(args, containsPattern(name))
+	}
+
+	var tags []TagCount
+	err := facades.Orm().Query().Raw(`SELECT tags.*, COUNT(book_tags.id) AS book_count
+		FROM tags
+		LEFT JOIN book_tags ON book_tags.tag_id = tags.id
+		`+where+`
+		GROUP BY tags.id
+		ORDER BY tags.name, tags.id`, args...).Scan(&tags)
+	return tags, err
+}
+
+func (r *tagRepository) FindTag(id uint) (*models.Tag, error) {
+	var tag models.Tag
+	if err := facades.Orm().Query().Where("id", id).First(&tag); err != nil {
+		return nil, err
+	}
+	if tag.ID == 0 {
+		return nil, ErrTagNotFound
+	}
+	return &tag, nil
+}
+
+// FirstOrCreateTag loads the tag with tag.Slug, creating it with tag.Name
+// when there is none.
+func (r *tagRepository) FirstOrCreateTag(tag *models.Tag) error {
+	return facades.Orm().Query().Where("slug", tag.Slug).FirstOrCreate(tag)
+}
+
+func (r *tagRepository) SlugTaken(slug string, excludeID uint) (bool, error) {
+	query := facades.Orm().Query().Model(&models.Tag{}).Where("slug", slug)
+	if excludeID > 0 {
+		query = query.Where("id != ?", excludeID)
+	}
+	return query.Exists()
+}
+
+func (r *tagRepository) UpdateTag(tag *models.Tag) error {
+	return facades.Orm().Query().Save(tag)
+}
+
+// DeleteTag removes a tag. The database takes it off every book.
+func (r *tagRepository) DeleteTag(tag *models.Tag) error {
+	_, err := facades.Orm().Query().Delete(tag)
+	return err
+}
+
+// FindBookTags returns the tags of each of the given books, keyed by book
+// ID.
+func (r *tagRepository) FindBookTags(bookIDs []uint) (map[uint][]models.Tag, error) {
+	tags := map[uint][]models.Tag{}
+	if len(bookIDs) == 0 {
+		return tags, nil
+	}
+
+	var rows []struct {
+		models.Tag
+		BookID uint
+	}
+	if err := facades.Orm().Query().Raw(`SELECT tags.*, book_tags.book_id
+		FROM book_tags
+		JOIN tags ON tags.id = book_tags.tag_id
+		WHERE book_tags.book_id IN ?
+		ORDER BY tags.name`, bookIDs).Scan(&rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		tags[row.BookID] = append(tags[row.BookID], row.Tag)
+	}
+	return tags, nil
+}
+
+// firstOrCreateTags loads each of tags by slug, creating the ones not seen
+// before.
+func firstOrCreateTags(tx orm.Query, tags []models.Tag) error {
+	for i := range tags {
+		if tags[i].ID != 0 {
+			continue
+		}
+		if err := tx.Where("slug", tags[i].Slug).FirstOrCreate(&tags[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *tagRepository) SyncBookTags(bookID uint, tagIDs []uint) error {
+	return facades.Orm().Transaction(func(tx orm.Query) error {
+		return syncBookTags(tx, bookID, tagIDs)
+	})
+}
+
+func syncBookTags(tx orm.Query, bookID uint, tagIDs []uint) error {
+	if _, err := tx.Where("book_id", bookID).Delete(&models.BookTag{}); err != nil {
+		return err
+	}
+	for _, tagID := range tagIDs {
+		if err := tx.Create(&models.BookTag{BookID: bookID, TagID: tagID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
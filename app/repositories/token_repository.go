@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"errors"
+	"goravel/app/models"
+	"time"
+
+	"github.com/goravel/framework/contracts/database/orm"
+	"github.com/goravel/framework/facades"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+)
+
+type TokenRepository interface {
+	RevokeToken(jti string, userID uint, expiresAt time.Time) error
+	IsTokenRevoked(jti string) (bool, error)
+	PruneExpiredTokens() (int64, error)
+	CreateRefreshToken(token *models.RefreshToken) error
+	RotateRefreshToken(tokenHash string, next *models.RefreshToken) error
+	RevokeRefreshTokenFamily(tokenHash string) error
+	RevokeUserRefreshTokens(userID uint) error
+}
+
+type tokenRepository struct{}
+
+func NewTokenRepository() TokenRepository {
+	return &tokenRepository{}
+}
+
+func (r *tokenRepository) RevokeToken(jti string, userID uint, expiresAt time.Time) error {
+	token := models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+	return facades.Orm().Query().Where("jti", jti).FirstOrCreate(&token)
+}
+
+func (r *tokenRepository) IsTokenRevoked(jti string) (bool, error) {
+	return facades.Orm().Query().Model(&models.RevokedToken{}).Where("jti", jti).Exists()
+}
+
+func (r *tokenRepository) PruneExpiredTokens() (int64, error) {
+	res, err := facades.Orm().Query().Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{})
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected, nil
+}
+
+func (r *tokenRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	return facades.Orm().Query().Create(token)
+}
+
+// RotateRefreshToken revokes the token identified by tokenHash and stores next
+// in the same family, inheriting its user and expiry. Presenting a token that
+// was already revoked revokes every token in its family.
+func (r *tokenRepository) RotateRefreshToken(tokenHash string, next *models.RefreshToken) error {
+	reused := false
+	err := facades.Orm().Transaction(func(tx orm.Query) error {
+		var current models.RefreshToken
+		if err := tx.LockForUpdate().Where("token_hash", tokenHash).First(&current); err != nil {
+			return err
+		}
+		if current.ID == 0 {
+			return ErrRefreshTokenInvalid
+		}
+
+		now := time.Now()
+		if current.RevokedAt != nil {
+			reused = true
+			_, err := tx.Model(&models.RefreshToken{}).
+				Where("family_id", current.FamilyID).
+				WhereNull("revoked_at").
+				Update("revoked_at", now)
+			return err
+		}
+		if current.ExpiresAt != nil && current.ExpiresAt.Before(now) {
+			return ErrRefreshTokenInvalid
+		}
+
+		current.RevokedAt = &now
+		if err := tx.Save(&current); err != nil {
+			return err
+		}
+
+		next.UserID = current.UserID
+		next.FamilyID = current.FamilyID
+		next.ExpiresAt = current.ExpiresAt
+		return tx.Create(next)
+	})
+	if err != nil {
+		return err
+	}
+	if reused {
+		return ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+func (r *tokenRepository) RevokeRefreshTokenFamily(tokenHash string) error {
+	var token models.RefreshToken
+	if err := facades.Orm().Query().Where("token_hash", tokenHash).First(&token); err != nil {
+		return err
+	}
+	if token.ID == 0 {
+		return ErrRefreshTokenInvalid
+	}
+
+	_, err := facades.Orm().Query().Model(&models.RefreshToken{}).
+		Where("family_id", token.FamilyID).
+		WhereNull("revoked_at").
+		Update("revoked_at", time.Now())
+	return err
+}
+
+func (r *tokenRepository) RevokeUserRefreshTokens(userID uint) error {
+	_, err := facades.Orm().Query().Model(&models.RefreshToken{}).
+		Where("user_id", userID).
+		WhereNull("revoked_at").
+		Update("revoked_at", time.Now())
+	return err
+}
@@ -1,21 +1,36 @@
 package repositories
 
 import (
+	"encoding/json"
 	"errors"
 	"goravel/app/models"
+	"slices"
+	"sync"
 
+	"github.com/goravel/framework/contracts/database/orm"
 	"github.com/goravel/framework/facades"
 )
 
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// dummyHash is checked against when the email is unknown, so the response
+// takes as long as a wrong password does.
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := facades.Hash().Make("dummy-password")
+	return hash
+})
+
 type UserRepository interface {
 	LoginUser(email, password string) (*models.User, error)
 	FindAllUser() ([]models.User, error)
 	FindByIDUser(id any) (*models.User, error)
+	FindByEmailUser(email string) (*models.User, error)
+	FindByFeedTokenHash(hash string) (*models.User, error)
 	ExcludeEmailByID(email string, id int) (bool, error)
 	RegisterUser(user *models.User) error
 	UpdateUser(user *models.User) error
 	DeleteUser(user *models.User) (int64, error)
-	Logout(token string) error
+	ConsumeRecoveryCode(userID uint, code string) (bool, error)
 }
 
 type userRepository struct{}
@@ -24,6 +39,8 @@ func NewUserRepository() UserRepository {
 	return &userRepository{}
 }
 
+// LoginUser returns ErrInvalidCredentials for both unknown emails and wrong
+// passwords so callers can't tell which accounts exist.
 func (r *userRepository) LoginUser(email, password string) (*models.User, error) {
 	var user models.User
 	err := facades.Orm().Query().Where("email", email).First(&user)
@@ -31,8 +48,13 @@ func (r *userRepository) LoginUser(email, password string) (*models.User, error)
 		return nil, err
 	}
 
+	if user.ID == 0 {
+		facades.Hash().Check(password, dummyHash())
+		return nil, ErrInvalidCredentials
+	}
+
 	if !facades.Hash().Check(password, user.Password) {
-		return nil, errors.New("invalid credentials")
+		return nil, ErrInvalidCredentials
 	}
 
 	return &user, nil
@@ -50,6 +72,18 @@ func (r *userRepository) FindByIDUser(id any) (*models.User, error) {
 	return &user, err
 }
 
+func (r *userRepository) FindByEmailUser(email string) (*models.User, error) {
+	var user models.User
+	err := facades.Orm().Query().Where("email", email).FirstOrFail(&user)
+	return &user, err
+}
+
+func (r *userRepository) FindByFeedTokenHash(hash string) (*models.User, error) {
+	var user models.User
+	err := facades.Orm().Query().Where("feed_token_hash", hash).FirstOrFail(&user)
+	return &user, err
+}
+
 func (r *userRepository) ExcludeEmailByID(email string, id int) (bool, error) {
 	query := facades.Orm().Query().Model(&models.User{}).Where("email = ?", email)
 	if id > 0 {
@@ -72,7 +106,39 @@ func (r *userRepository) DeleteUser(user *models.User) (int64, error) {
 	return res.RowsAffected, err
 }
 
-func (r *userRepository) Logout(token string) error {
-	_, err := facades.Orm().Query().Where("token", token).Update("token", "")
-	return err
+// ConsumeRecoveryCode removes the stored hash matching code from the user's
+// recovery codes and reports whether there was one. The row is locked so a code can't be spent
+// twice by concurrent requests.
+func (r *userRepository) ConsumeRecoveryCode(userID uint, code string) (bool, error) {
+	consumed := false
+	err := facades.Orm().Transaction(func(tx orm.Query) error {
+		var user models.User
+		if err := tx.LockForUpdate().Where("id", userID).First(&user); err != nil {
+			return err
+		}
+		if user.ID == 0 || user.TwoFactorRecoveryCodes == "" {
+			return nil
+		}
+
+		var hashes []string
+		if err := json.Unmarshal([]byte(user.TwoFactorRecoveryCodes), &hashes); err != nil {
+			return err
+		}
+		index := slices.IndexFunc(hashes, func(hash string) bool {
+			return facades.Hash().Check(code, hash)
+		})
+		if index < 0 {
+			return nil
+		}
+
+		remaining, err := json.Marshal(slices.Delete(hashes, index, index+1))
+		if err != nil {
+			return err
+		}
+		consumed = true
+		_, err = tx.Model(&models.User{}).Where("id", userID).Update("two_factor_recovery_codes", string(remaining))
+		return err
+	})
+
+	return consumed, err
 }
@@ -0,0 +1,41 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/goravel/framework/contracts/validation"
+
+	"goravel/app/helpers"
+)
+
+// Isbn accepts an ISBN-10 or ISBN-13 with a valid check digit. Hyphens and
+// spaces are ignored. Use isbn:10 or isbn:13 to allow only one form.
+type Isbn struct {
+}
+
+// Signature The name of the rule.
+func (receiver *Isbn) Signature() string {
+	return "isbn"
+}
+
+// Passes Determine if the validation rule passes.
+func (receiver *Isbn) Passes(data validation.Data, val any, options ...any) bool {
+	value, ok := val.(string)
+	if !ok {
+		return false
+	}
+
+	isbn, valid := helpers.NormalizeISBN(value)
+	if !valid {
+		return false
+	}
+	if len(options) > 0 {
+		return fmt.Sprint(options[0]) == fmt.Sprint(len(isbn))
+	}
+	return true
+}
+
+// Message Get the validation error message.
+func (receiver *Isbn) Message() string {
+	return "The :attribute must be a valid ISBN."
+}
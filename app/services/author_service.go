@@ -0,0 +1,73 @@
+package services
+
+import (
+	"goravel/app/models"
+	"goravel/app/repositories"
+)
+
+type AuthorService interface {
+	ListAuthors(name string, page, perPage int) ([]models.Author, int64, error)
+	GetAuthor(id uint) (*models.Author, error)
+	ListBooks(authorID uint, filter repositories.BookFilter) (*repositories.BookPage, error)
+	CreateAuthor(author *models.Author) error
+	UpdateAuthor(author *models.Author) error
+	DeleteAuthor(author *models.Author) error
+}
+
+type authorService struct {
+	repo  repositories.AuthorRepository
+	books BookService
+}
+
+func NewAuthorService(repo repositories.AuthorRepository, books BookService) AuthorService {
+	return &authorService{repo: repo, books: books}
+}
+
+func (s *authorService) ListAuthors(name string, page, perPage int) ([]models.Author, int64, error) {
+	return s.repo.PaginateAuthors(name, page, perPage)
+}
+
+func (s *authorService) GetAuthor(id uint) (*models.Author, error) {
+	return s.repo.FindAuthor(id)
+}
+
+// ListBooks pages through the books the author contributed to, in any role.
+func (s *authorService) ListBooks(authorID uint, filter repositories.BookFilter) (*repositories.BookPage, error) {
+	if _, err := s.repo.FindAuthor(authorID); err != nil {
+		return nil, err
+	}
+
+	filter.AuthorID = authorID
+	return s.books.ListBooks(filter)
+}
+
+func (s *authorService) CreateAuthor(author *models.Author) error {
+	return s.repo.CreateAuthor(author)
+}
+
+// UpdateAuthor saves author and rewrites the credit line of every book they
+// are credited on, so renames show up in listings and search.
+func (s *authorService) UpdateAuthor(author *models.Author) error {
+	if err := s.repo.UpdateAuthor(author); err != nil {
+		return err
+	}
+
+	bookIDs, err := s.repo.FindBookIDsByAuthor(author.ID)
+	if err != nil {
+		return err
+	}
+	for _, id := range bookIDs {
+		book, err := s.books.GetByIDBook(id)
+		if err != nil {
+			return err
+		}
+		if err := s.books.UpdateBook(book); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *authorService) DeleteAuthor(author *models.Author) error {
+	return s.repo.DeleteAuthor(author)
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"goravel/app/models"
+	"goravel/app/repositories"
+)
+
+var ErrDuplicateBarcode = errors.New("a copy with this barcode already exists")
+
+type BookCopyService interface {
+	ListCopies(bookID uint) ([]models.BookCopy, error)
+	GetCopy(bookID, copyID uint) (*models.BookCopy, error)
+	CreateCopy(bookCopy *models.BookCopy) error
+	AddCopies(bookID uint, count int) error
+	CountHeldCopies(bookIDs []uint) (map[uint]int, error)
+	UpdateCopy(bookCopy *models.BookCopy) error
+	DeleteCopy(bookCopy *models.BookCopy) error
+}
+
+type bookCopyService struct {
+	repo     repositories.BookCopyRepository
+	bookRepo repositories.BookRepository
+}
+
+func NewBookCopyService(repo repositories.BookCopyRepository, bookRepo repositories.BookRepository) BookCopyService {
+	return &bookCopyService{repo: repo, bookRepo: bookRepo}
+}
+
+func (s *bookCopyService) ListCopies(bookID uint) ([]models.BookCopy, error) {
+	if _, err := s.bookRepo.FindByIDBook(bookID); err != nil {
+		return nil, repositories.ErrBookNotFound
+	}
+	return s.repo.FindCopiesByBook(bookID)
+}
+
+func (s *bookCopyService) GetCopy(bookID, copyID uint) (*models.BookCopy, error) {
+	return s.repo.FindCopy(bookID, copyID)
+}
+
+// CreateCopy adds a copy to its book, generating a placeholder barcode when
+// none is given.
+func (s *bookCopyService) CreateCopy(bookCopy *models.BookCopy) error {
+	if bookCopy.Barcode == "" {
+		barcode, err := s.nextBarcode(bookCopy.BookID)
+		if err != nil {
+			return err
+		}
+		bookCopy.Barcode = barcode
+	} else if err := s.ensureBarcodeUnique(bookCopy); err != nil {
+		return err
+	}
+
+	if bookCopy.Condition == "" {
+		bookCopy.Condition = "good"
+	}
+	bookCopy.Status = models.CopyAvailable
+	return s.repo.CreateCopy(bookCopy)
+}
+
+// AddCopies creates count available copies with generated barcodes.
+func (s *bookCopyService) AddCopies(bookID uint, count int) error {
+	for i := 0; i < count; i++ {
+		if err := s.CreateCopy(&models.BookCopy{BookID: bookID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *bookCopyService) CountHeldCopies(bookIDs []uint) (map[uint]int, error) {
+	return s.repo.CountHeldCopies(bookIDs)
+}
+
+func (s *bookCopyService) UpdateCopy(bookCopy *models.BookCopy) error {
+	if err := s.ensureBarcodeUnique(bookCopy); err != nil {
+		return err
+	}
+	return s.repo.UpdateCopy(bookCopy)
+}
+
+func (s *bookCopyService) DeleteCopy(bookCopy *models.BookCopy) error {
+	if bookCopy.Status == models.CopyOnLoan {
+		return repositories.ErrCopyOnLoan
+	}
+	if bookCopy.Status == models.CopyOnHold {
+		return repositories.ErrCopyOnHold
+	}
+	return s.repo.DeleteCopy(bookCopy)
+}
+
+func (s *bookCopyService) ensureBarcodeUnique(bookCopy *models.BookCopy) error {
+	taken, err := s.repo.BarcodeTaken(bookCopy.Barcode, bookCopy.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrDuplicateBarcode
+	}
+	return nil
+}
+
+// nextBarcode returns the first free barcode of the form
+// <book id>-<sequence>, the same scheme used for copies created from the old
+// stock counts.
+func (s *bookCopyService) nextBarcode(bookID uint) (string, error) {
+	count, err := s.repo.CountCopies(bookID)
+	if err != nil {
+		return "", err
+	}
+
+	for n := count + 1; ; n++ {
+		barcode := fmt.Sprintf("%06d-%03d", bookID, n)
+		taken, err := s.repo.BarcodeTaken(barcode, 0)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return barcode, nil
+		}
+	}
+}
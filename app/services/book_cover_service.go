@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/goravel/framework/facades"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+
+	"goravel/app/helpers"
+	"goravel/app/models"
+	"goravel/app/repositories"
+)
+
+const (
+	MaxCoverBytes = 10 << 20
+	minCoverSide  = 100
+	maxCoverSide  = 6000
+)
+
+var (
+	ErrUnsupportedCover = errors.New("cover must be a JPEG, PNG or WebP image")
+	ErrCoverTooLarge    = fmt.Errorf("cover must be at most %d MB", MaxCoverBytes>>20)
+	ErrCoverDimensions  = fmt.Errorf("cover must be between %d and %d pixels on each side", minCoverSide, maxCoverSide)
+)
+
+// coverFormats maps the content types sniffed from an upload to the name
+// image.Decode reports for them; both have to agree.
+var coverFormats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+type BookCoverService interface {
+	SaveCover(book *models.Book, r io.Reader) error
+	DeleteCover(book *models.Book) error
+}
+
+type bookCoverService struct {
+	repo repositories.BookRepository
+}
+
+func NewBookCoverService(repo repositories.BookRepository) BookCoverService {
+	return &bookCoverService{repo: repo}
+}
+
+// SaveCover checks that r holds a JPEG, PNG or WebP image of sensible size,
+// then stores it with its thumbnails and makes it the book's cover. Images
+// are decoded and re-encoded, which drops EXIF and any other metadata;
+// opaque images are written as JPEG and the rest as PNG. The previous
+// cover is removed once the new one is in place.
+func (s *bookCoverService) SaveCover(book *models.Book, r io.Reader) error {
+	data, err := io.ReadAll(io.LimitReader(r, MaxCoverBytes+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > MaxCoverBytes {
+		return ErrCoverTooLarge
+	}
+
+	// The client's content type is not trusted; the bytes decide.
+	format, ok := coverFormats[http.DetectContentType(data)]
+	if !ok {
+		return ErrUnsupportedCover
+	}
+	config, decoded, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decoded != format {
+		return ErrUnsupportedCover
+	}
+	if config.Width < minCoverSide || config.Height < minCoverSide ||
+		config.Width > maxCoverSide || config.Height > maxCoverSide {
+		return ErrCoverDimensions
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return ErrUnsupportedCover
+	}
+
+	ext := ".png"
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		ext = ".jpg"
+	}
+
+	// A directory per upload gives every cover new URLs, so caches never
+	// serve a replaced image.
+	sum := sha256.Sum256(data)
+	dir := path.Join(helpers.CoverDirectory(book.ID), hex.EncodeToString(sum[:6]))
+	cover := path.Join(dir, "original"+ext)
+
+	disk := facades.Storage().Disk(helpers.CoverDisk)
+	variants := map[string]image.Image{cover: img}
+	for _, size := range helpers.CoverSizes {
+		variants[helpers.CoverPath(cover, size.Name)] = scaleToWidth(img, size.Width)
+	}
+	for file, variant := range variants {
+		content, err := encodeCover(variant, ext)
+		if err != nil {
+			_ = disk.DeleteDirectory(dir)
+			return err
+		}
+		if err := disk.Put(file, string(content)); err != nil {
+			_ = disk.DeleteDirectory(dir)
+			return err
+		}
+	}
+
+	previous := book.Cover
+	book.Cover = cover
+	if err := s.repo.UpdateBookCover(book); err != nil {
+		book.Cover = previous
+		_ = disk.DeleteDirectory(dir)
+		return err
+	}
+	if previous != "" && path.Dir(previous) != dir {
+		return disk.DeleteDirectory(path.Dir(previous))
+	}
+	return nil
+}
+
+// DeleteCover removes every cover stored for book and clears its cover. It
+// is also how a deleted book's files are cleaned up.
+func (s *bookCoverService) DeleteCover(book *models.Book) error {
+	if err := facades.Storage().Disk(helpers.CoverDisk).DeleteDirectory(helpers.CoverDirectory(book.ID)); err != nil {
+		return err
+	}
+	if book.Cover == "" {
+		return nil
+	}
+
+	book.Cover = ""
+	return s.repo.UpdateBookCover(book)
+}
+
+// scaleToWidth shrinks img to width pixels wide, keeping its aspect ratio.
+// Images already narrower than width are kept as they are.
+func scaleToWidth(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= width {
+		return img
+	}
+
+	height := max(1, (bounds.Dy()*width+bounds.Dx()/2)/bounds.Dx())
+	scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+	return scaled
+}
+
+func encodeCover(img image.Image, ext string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if ext == ".jpg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	return buf.Bytes(), err
+}
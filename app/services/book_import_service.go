@@ -0,0 +1,322 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"goravel/app/helpers"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goravel/framework/facades"
+)
+
+// BookCSVColumns are the columns of an exported catalog, which an import
+// accepts in any order. Only title is required in the header; tags are
+// separated by semicolons. contributors lists each person with their role,
+// e.g. "Jane Doe (author); Max Lane (translator)", and takes precedence over
+// author when given. stock is the number of copies in the collection, not
+// only those on the shelf, so a re-imported book gets all of them back.
+var BookCSVColumns = []string{"title", "author", "published_year", "isbn", "stock", "tags", "contributors"}
+
+// exportBatchSize is how many books are read from the database at a time
+// while exporting.
+const exportBatchSize = 500
+
+var ErrInvalidCSVHeader = errors.New("the CSV header must name a title column")
+
+// BookImportReport sums up an import. CSV rows are numbered as lines of the
+// file, so the first data row is row 2; MARC records are numbered from 1.
+type BookImportReport struct {
+	DryRun  bool                 `json:"dry_run"`
+	Rows    int                  `json:"rows"`
+	Created int                  `json:"created"`
+	Updated int                  `json:"updated"`
+	Failed  int                  `json:"failed"`
+	Errors  []BookImportRowError `json:"errors"`
+}
+
+// String summarizes the report on one line, e.g. for the import command.
+func (r *BookImportReport) String() string {
+	prefix := ""
+	if r.DryRun {
+		prefix = "Dry run: "
+	}
+	return fmt.Sprintf("%s%d rows, %d created, %d updated, %d failed", prefix, r.Rows, r.Created, r.Updated, r.Failed)
+}
+
+func (r *BookImportReport) fail(line int, errs any) {
+	r.Failed++
+	r.Errors = append(r.Errors, BookImportRowError{Row: line, Errors: errs})
+}
+
+type BookImportRowError struct {
+	Row    int `json:"row"`
+	Errors any `json:"errors"`
+}
+
+type BookImportService interface {
+	Import(r io.Reader, dryRun bool) (*BookImportReport, error)
+	ImportMARC(r io.Reader, format string, dryRun bool) (*BookImportReport, error)
+	Export(w io.Writer) error
+}
+
+type bookImportService struct {
+	books  BookService
+	repo   repositories.BookRepository
+	copies BookCopyService
+}
+
+func NewBookImportService(books BookService, repo repositories.BookRepository, copies BookCopyService) BookImportService {
+	return &bookImportService{books: books, repo: repo, copies: copies}
+}
+
+// Import reads a catalog CSV one row at a time and creates or updates a
+// book for each valid row. A row updates the book with its ISBN, or else
+// the book with the same title and author. Stock only applies to new books,
+// which get that many copies; existing books keep their copies. Invalid rows
+// are reported and skipped. With dryRun nothing is saved.
+func (s *bookImportService) Import(r io.Reader, dryRun bool) (*BookImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrInvalidCSVHeader
+		}
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, ErrInvalidCSVHeader
+	}
+
+	report := &BookImportReport{DryRun: dryRun, Errors: []BookImportRowError{}}
+	// seen tracks rows already imported in this file, so a dry run reports a
+	// repeated row as the update it would be.
+	seen := map[string]bool{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			report.Rows++
+			report.fail(parseErr.StartLine, map[string]string{"row": parseErr.Err.Error()})
+			continue
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		report.Rows++
+		row := map[string]any{}
+		for _, column := range BookCSVColumns {
+			if i, ok := columns[column]; ok && i < len(record) {
+				if value := strings.TrimSpace(record[i]); value != "" {
+					row[column] = value
+				}
+			}
+		}
+
+		created, errs := s.importRow(row, nil, dryRun, seen)
+		if errs != nil {
+			report.fail(line, errs)
+		} else if created {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+	}
+
+	return report, nil
+}
+
+// importRow validates one row and saves it, reporting whether it created a
+// book. It returns the row's errors instead when it is invalid. Contributors
+// are taken from credits or the contributors column when given. Otherwise an
+// existing book with the same credit line keeps its contributors, and any
+// other book gets the names in the author column.
+func (s *bookImportService) importRow(row map[string]any, credits []models.BookAuthor, dryRun bool, seen map[string]bool) (bool, any) {
+	if raw, ok := row["contributors"].(string); ok && credits == nil {
+		var err error
+		if credits, err = helpers.ParseContributors(raw); err != nil {
+			return false, map[string]string{"contributors": err.Error()}
+		}
+		if _, ok := row["author"]; !ok && credits != nil {
+			row["author"] = helpers.CreditLine(credits)
+		}
+	}
+
+	// Numbers arrive as text, so they are converted before the integer rules
+	// see them; values that don't parse are left for the rules to reject.
+	for _, column := range []string{"published_year", "stock"} {
+		if value, ok := row[column].(string); ok {
+			if n, err := strconv.Atoi(value); err == nil {
+				row[column] = n
+			}
+		}
+	}
+
+	validator, err := facades.Validation().Make(row, BookRules)
+	if err != nil {
+		return false, map[string]string{"row": err.Error()}
+	}
+	if validator.Fails() {
+		return false, validator.Errors().All()
+	}
+	stock, _ := row["stock"].(int)
+	if stock < 0 {
+		return false, map[string]string{"stock": "stock must not be negative"}
+	}
+
+	title, _ := row["title"].(string)
+	author, _ := row["author"].(string)
+	author = strings.Join(helpers.SplitAuthorNames(author), ", ")
+	if credits != nil {
+		author = helpers.CreditLine(credits)
+	}
+	isbn, _ := row["isbn"].(string)
+	_, isbn13, _ := helpers.ISBNForms(isbn)
+
+	book, err := s.repo.FindMatchingBook(isbn13, title, author)
+	if err != nil {
+		return false, map[string]string{"row": err.Error()}
+	}
+	if book != nil && isbn13 != "" && book.ISBN13 != nil && *book.ISBN13 != isbn13 {
+		return false, map[string]string{"isbn": "a book with this title and author already has ISBN " + *book.ISBN13}
+	}
+
+	key := "isbn:" + isbn13
+	if isbn13 == "" {
+		key = "title:" + strings.ToLower(title) + "\x00" + strings.ToLower(author)
+	}
+	created := book == nil && !seen[key]
+	seen[key] = true
+	if book == nil {
+		book = &models.Book{}
+	} else if book, err = s.books.GetByIDBook(book.ID); err != nil {
+		return false, map[string]string{"row": err.Error()}
+	}
+
+	book.Title = title
+	if credits != nil || !strings.EqualFold(author, book.Author) {
+		book.Author = author
+		book.Authors = credits
+	}
+	book.PublishedYear, _ = row["published_year"].(int)
+	if isbn != "" {
+		if err := s.books.SetISBN(book, isbn); err != nil {
+			return false, map[string]string{"isbn": err.Error()}
+		}
+	}
+	if tags, ok := row["tags"].(string); ok {
+		book.Tags = []models.Tag{}
+		for _, name := range strings.Split(tags, ";") {
+			if name = strings.TrimSpace(name); name != "" {
+				book.Tags = append(book.Tags, models.Tag{Name: name})
+			}
+		}
+	}
+
+	if dryRun {
+		return created, nil
+	}
+
+	if book.ID == 0 {
+		book.Stock = stock
+		if err := s.books.CreateBook(book); err != nil {
+			return false, map[string]string{"row": err.Error()}
+		}
+		return true, nil
+	}
+
+	if err := s.books.UpdateBook(book); err != nil {
+		return false, map[string]string{"row": err.Error()}
+	}
+	return false, nil
+}
+
+// Export writes the catalog as CSV in the columns Import reads, a batch of
+// books at a time.
+func (s *bookImportService) Export(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(BookCSVColumns); err != nil {
+		return err
+	}
+
+	var afterID uint
+	for {
+		books, err := s.repo.FindBooksAfter(afterID, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(books) == 0 {
+			break
+		}
+		if err := s.books.LoadRelations(books); err != nil {
+			return err
+		}
+		ids := make([]uint, len(books))
+		for i, book := range books {
+			ids[i] = book.ID
+		}
+		held, err := s.copies.CountHeldCopies(ids)
+		if err != nil {
+			return err
+		}
+
+		for _, book := range books {
+			isbn := ""
+			if book.ISBN13 != nil {
+				isbn = *book.ISBN13
+			}
+			tags := make([]string, 0, len(book.Tags))
+			for _, tag := range book.Tags {
+				tags = append(tags, tag.Name)
+			}
+			if err := writer.Write([]string{
+				book.Title,
+				book.Author,
+				strconv.Itoa(book.PublishedYear),
+				isbn,
+				strconv.Itoa(held[book.ID]),
+				strings.Join(tags, "; "),
+				helpers.FormatContributors(book.Authors),
+			}); err != nil {
+				return err
+			}
+		}
+
+		// Flush each batch so a streamed response starts early.
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		afterID = books[len(books)-1].ID
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func isBlankRecord(record []string) bool {
+	return !slices.ContainsFunc(record, func(field string) bool {
+		return strings.TrimSpace(field) != ""
+	})
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"goravel/app/helpers"
+	"goravel/app/models"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MARC formats accepted by ImportMARC.
+const (
+	MARCFormatISO2709 = "marc"
+	MARCFormatXML     = "marcxml"
+)
+
+var ErrUnsupportedMARCFormat = errors.New("unsupported MARC format")
+
+var marcYear = regexp.MustCompile(`\d{4}`)
+
+// marcRoles maps relator terms ($e) and codes ($4) onto contributor roles.
+var marcRoles = map[string]string{
+	"author":     models.ContributorAuthor,
+	"aut":        models.ContributorAuthor,
+	"editor":     models.ContributorEditor,
+	"ed":         models.ContributorEditor,
+	"edt":        models.ContributorEditor,
+	"translator": models.ContributorTranslator,
+	"tr":         models.ContributorTranslator,
+	"trl":        models.ContributorTranslator,
+}
+
+// ImportMARC creates or updates a book for each record of an ISO 2709 or
+// MARCXML file, matching existing books like Import does. Records that
+// cannot be parsed or fail validation are reported and skipped.
+func (s *bookImportService) ImportMARC(r io.Reader, format string, dryRun bool) (*BookImportReport, error) {
+	var read func() (*helpers.MARCRecord, error)
+	switch format {
+	case MARCFormatISO2709:
+		read = helpers.NewMARCReader(r).Read
+	case MARCFormatXML:
+		read = helpers.NewMARCXMLReader(r).Read
+	default:
+		return nil, ErrUnsupportedMARCFormat
+	}
+
+	report := &BookImportReport{DryRun: dryRun, Errors: []BookImportRowError{}}
+	seen := map[string]bool{}
+	for n := 1; ; n++ {
+		record, err := read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// A broken ISO 2709 record ends at its terminator, so the next
+			// one can still be read; broken XML cannot be resumed.
+			if format == MARCFormatISO2709 && errors.Is(err, helpers.ErrInvalidMARC) {
+				report.Rows++
+				report.fail(n, map[string]string{"record": err.Error()})
+				continue
+			}
+			return nil, err
+		}
+
+		report.Rows++
+		row, credits := bookRowFromMARC(record)
+		created, errs := s.importRow(row, credits, dryRun, seen)
+		if errs != nil {
+			report.fail(n, errs)
+		} else if created {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+	}
+
+	return report, nil
+}
+
+// bookRowFromMARC reads the title (245), contributors (100 and 700), first
+// valid ISBN (020) and publication year (264, 260 or 008) of a record into
+// an import row.
+func bookRowFromMARC(record *helpers.MARCRecord) (map[string]any, []models.BookAuthor) {
+	row := map[string]any{}
+
+	for _, field := range record.FieldsByTag("245") {
+		title := trimISBD(field.Subfield('a'))
+		if remainder := trimISBD(field.Subfield('b')); remainder != "" {
+			title += ": " + remainder
+		}
+		if title != "" {
+			row["title"] = title
+		}
+		break
+	}
+
+	for _, field := range record.FieldsByTag("020") {
+		// $a may carry a qualifier, e.g. "9780306406157 (pbk.)".
+		candidate, _, _ := strings.Cut(strings.TrimSpace(field.Subfield('a')), " ")
+		if _, _, ok := helpers.ISBNForms(candidate); ok {
+			row["isbn"] = candidate
+			break
+		}
+	}
+
+	if year := marcPublicationYear(record); year != "" {
+		row["published_year"] = year
+	}
+
+	var credits []models.BookAuthor
+	for _, field := range append(record.FieldsByTag("100"), record.FieldsByTag("700")...) {
+		name := trimISBD(field.Subfield('a'))
+		if field.Ind1 == '1' {
+			if surname, forename, ok := strings.Cut(name, ","); ok {
+				name = strings.TrimSpace(forename) + " " + strings.TrimSpace(surname)
+			}
+		}
+		if name == "" {
+			continue
+		}
+
+		role := models.ContributorAuthor
+		for _, term := range []string{field.Subfield('4'), field.Subfield('e')} {
+			if mapped, ok := marcRoles[strings.ToLower(trimISBD(term))]; ok {
+				role = mapped
+				break
+			}
+		}
+		credits = append(credits, models.BookAuthor{Role: role, Author: &models.Author{Name: name}})
+	}
+	if credits != nil {
+		row["author"] = helpers.CreditLine(credits)
+	}
+
+	return row, credits
+}
+
+func marcPublicationYear(record *helpers.MARCRecord) string {
+	for _, field := range record.FieldsByTag("264") {
+		if field.Ind2 == '1' {
+			if year := marcYear.FindString(field.Subfield('c')); year != "" {
+				return year
+			}
+		}
+	}
+	for _, field := range record.FieldsByTag("260") {
+		if year := marcYear.FindString(field.Subfield('c')); year != "" {
+			return year
+		}
+	}
+	for _, field := range record.FieldsByTag("008") {
+		if len(field.Value) >= 11 && marcYear.MatchString(field.Value[7:11]) {
+			return field.Value[7:11]
+		}
+	}
+	return ""
+}
+
+// BookMARCRecord describes book as a MARC21 bibliographic record: control
+// number, fixed-length data, ISBNs, contributors, title and publication year.
+func BookMARCRecord(book *models.Book) *helpers.MARCRecord {
+	record := &helpers.MARCRecord{Leader: "     nam a22     7i 4500"}
+
+	year := fmt.Sprintf("%04d", book.PublishedYear)
+	if book.PublishedYear <= 0 {
+		year = "uuuu"
+	}
+	record.Fields = append(record.Fields,
+		helpers.MARCField{Tag: "001", Value: strconv.FormatUint(uint64(book.ID), 10)},
+		helpers.MARCField{Tag: "008", Value: time.Now().Format("060102") + "s" + year + "    xx " + strings.Repeat(" ", 17) + "und d"},
+	)
+
+	for _, isbn := range []*string{book.ISBN13, book.ISBN10} {
+		if isbn != nil {
+			record.Fields = append(record.Fields, helpers.MARCField{
+				Tag: "020", Ind1: ' ', Ind2: ' ',
+				Subfields: []helpers.MARCSubfield{{Code: 'a', Value: *isbn}},
+			})
+		}
+	}
+
+	// The first author is the main entry; everyone else is an added entry.
+	mainEntry := false
+	for _, credit := range book.Authors {
+		if credit.Author == nil {
+			continue
+		}
+		tag := "700"
+		if !mainEntry && credit.Role == models.ContributorAuthor {
+			tag, mainEntry = "100", true
+		}
+		record.Fields = append(record.Fields, helpers.MARCField{
+			Tag: tag, Ind1: '1', Ind2: ' ',
+			Subfields: []helpers.MARCSubfield{
+				{Code: 'a', Value: invertName(credit.Author.Name)},
+				{Code: 'e', Value: credit.Role},
+			},
+		})
+	}
+
+	titleIndicator := byte('0')
+	if mainEntry {
+		titleIndicator = '1'
+	}
+	record.Fields = append(record.Fields, helpers.MARCField{
+		Tag: "245", Ind1: titleIndicator, Ind2: '0',
+		Subfields: []helpers.MARCSubfield{{Code: 'a', Value: book.Title}},
+	})
+	if book.PublishedYear > 0 {
+		record.Fields = append(record.Fields, helpers.MARCField{
+			Tag: "264", Ind1: ' ', Ind2: '1',
+			Subfields: []helpers.MARCSubfield{{Code: 'c', Value: year}},
+		})
+	}
+
+	// Main and added entries were appended as they came; MARC orders fields
+	// by tag.
+	slices.SortStableFunc(record.Fields, func(a, b helpers.MARCField) int {
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	return record
+}
+
+// invertName writes a personal name surname first, as MARC headings do:
+// "Andrea Hirata" becomes "Hirata, Andrea". Single names are kept.
+func invertName(name string) string {
+	i := strings.LastIndex(name, " ")
+	if i < 0 {
+		return name
+	}
+	return name[i+1:] + ", " + name[:i]
+}
+
+// trimISBD drops the punctuation MARC cataloguers end subfields with.
+func trimISBD(value string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(value), " /:;,=."))
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"goravel/app/helpers"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/goravel/framework/database/orm"
+	"github.com/goravel/framework/facades"
+)
+
+// BookSearchHit is a ranked search result. Highlights holds the title and
+// author with matched words wrapped in <mark> tags.
+type BookSearchHit struct {
+	Book       models.Book
+	Score      float64
+	Highlights map[string]string
+}
+
+type BookSearchService interface {
+	Search(query string, limit int) ([]BookSearchHit, error)
+	Prepare(book *models.Book)
+	Index(book *models.Book)
+	Remove(book *models.Book)
+	Reindex() (int, error)
+}
+
+// bookSearchBackend ranks books for normalized query terms.
+type bookSearchBackend interface {
+	search(terms []string, limit int) ([]repositories.BookMatch, error)
+	index(book *models.Book)
+	remove(id uint)
+	reset(books []models.Book)
+}
+
+type bookSearchService struct {
+	repo    repositories.BookRepository
+	backend bookSearchBackend
+}
+
+// NewBookSearchService uses the database full-text index when
+// search.driver is "database" and the connection is MySQL, and the
+// in-process index otherwise, e.g. on SQLite and in tests.
+func NewBookSearchService(repo repositories.BookRepository) BookSearchService {
+	var backend bookSearchBackend = &memoryBookSearch{repo: repo}
+	if facades.Config().GetString("search.driver", "database") == "database" && repo.SupportsFullText() {
+		backend = &databaseBookSearch{repo: repo}
+	}
+
+	return &bookSearchService{repo: repo, backend: backend}
+}
+
+func (s *bookSearchService) Search(query string, limit int) ([]BookSearchHit, error) {
+	terms := helpers.SearchTokens(query)
+	slices.Sort(terms)
+	terms = slices.Compact(terms)
+	if len(terms) == 0 {
+		return []BookSearchHit{}, nil
+	}
+
+	matches, err := s.backend.search(terms, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]BookSearchHit, 0, len(matches))
+	for _, match := range matches {
+		hits = append(hits, BookSearchHit{
+			Book:  match.Book,
+			Score: match.Score,
+			Highlights: map[string]string{
+				"title":  helpers.HighlightMatches(match.Title, terms),
+				"author": helpers.HighlightMatches(match.Author, terms),
+			},
+		})
+	}
+	return hits, nil
+}
+
+// Prepare fills the search columns of book before it is saved.
+func (s *bookSearchService) Prepare(book *models.Book) {
+	book.SearchTitle = strings.Join(helpers.IndexTokens(book.Title), " ")
+	book.SearchAuthor = strings.Join(helpers.IndexTokens(book.Author), " ")
+}
+
+// Index makes a saved book findable by backends that keep their own index.
+func (s *bookSearchService) Index(book *models.Book) {
+	s.backend.index(book)
+}
+
+func (s *bookSearchService) Remove(book *models.Book) {
+	s.backend.remove(book.ID)
+}
+
+// Reindex recomputes the search columns of every book and rebuilds the
+// in-process index, returning the number of books indexed.
+func (s *bookSearchService) Reindex() (int, error) {
+	books, err := s.repo.FindAllBook()
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range books {
+		s.Prepare(&books[i])
+		if err := s.repo.UpdateSearchColumns(&books[i]); err != nil {
+			return 0, err
+		}
+	}
+	s.backend.reset(books)
+
+	return len(books), nil
+}
+
+type databaseBookSearch struct {
+	repo repositories.BookRepository
+}
+
+func (b *databaseBookSearch) search(terms []string, limit int) ([]repositories.BookMatch, error) {
+	return b.repo.FullTextSearchBooks(terms, limit)
+}
+
+// The search columns are saved with the book, so there is nothing to keep
+// in sync.
+func (b *databaseBookSearch) index(*models.Book)  {}
+func (b *databaseBookSearch) remove(uint)         {}
+func (b *databaseBookSearch) reset([]models.Book) {}
+
+// Title matches count twice as much as author matches and a term found in
+// both counts for both. A term that is only a prefix of an indexed word
+// counts half.
+const (
+	titleWeight  = 2.0
+	authorWeight = 1.0
+	prefixWeight = 0.5
+)
+
+// memoryIndex maps each indexed term to the books containing it and their
+// field weight. It is shared by every request and built from the database on
+// first use. Only book IDs are kept; the books themselves are read afresh
+// for every search, so stock and other changes show up right away.
+var memoryIndex struct {
+	sync.RWMutex
+	built    bool
+	postings map[string]map[uint]float64
+	indexed  map[uint]bool
+}
+
+type memoryBookSearch struct {
+	repo repositories.BookRepository
+}
+
+func (b *memoryBookSearch) search(terms []string, limit int) ([]repositories.BookMatch, error) {
+	if err := b.ensureBuilt(); err != nil {
+		return nil, err
+	}
+
+	ranked := b.rank(terms)
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	ids := make([]uint, len(ranked))
+	for i, match := range ranked {
+		ids[i] = match.ID
+	}
+	books, err := b.repo.FindBooksByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Book, len(books))
+	for _, book := range books {
+		byID[book.ID] = book
+	}
+
+	// A book deleted by another process since it was indexed is left out.
+	matches := make([]repositories.BookMatch, 0, len(ranked))
+	for _, match := range ranked {
+		if book, ok := byID[match.ID]; ok {
+			matches = append(matches, repositories.BookMatch{Book: book, Score: match.Score})
+		}
+	}
+	return matches, nil
+}
+
+// rank scores the indexed books that match every term, best first. The
+// matches only carry the book's ID.
+func (b *memoryBookSearch) rank(terms []string) []repositories.BookMatch {
+	memoryIndex.RLock()
+	defer memoryIndex.RUnlock()
+
+	// Every term has to match, like the database backends' AND queries.
+	var scores map[uint]float64
+	for _, term := range terms {
+		termScores := map[uint]float64{}
+		for token, postings := range memoryIndex.postings {
+			if !strings.HasPrefix(token, term) {
+				continue
+			}
+			factor := 1.0
+			if token != term {
+				factor = prefixWeight
+			}
+			for id, weight := range postings {
+				termScores[id] = max(termScores[id], weight*factor)
+			}
+		}
+
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			if termScore, ok := termScores[id]; ok {
+				scores[id] += termScore
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	matches := make([]repositories.BookMatch, 0, len(scores))
+	for id, score := range scores {
+		matches = append(matches, repositories.BookMatch{Book: models.Book{Model: orm.Model{ID: id}}, Score: score})
+	}
+	slices.SortFunc(matches, func(a, b repositories.BookMatch) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return int(a.ID) - int(b.ID)
+	})
+	return matches
+}
+
+func (b *memoryBookSearch) index(book *models.Book) {
+	memoryIndex.Lock()
+	defer memoryIndex.Unlock()
+
+	// Until the index is built the book will be picked up from the database.
+	if !memoryIndex.built {
+		return
+	}
+	removePostings(book.ID)
+	addPostings(book)
+}
+
+func (b *memoryBookSearch) remove(id uint) {
+	memoryIndex.Lock()
+	defer memoryIndex.Unlock()
+
+	removePostings(id)
+}
+
+func (b *memoryBookSearch) reset(books []models.Book) {
+	memoryIndex.Lock()
+	defer memoryIndex.Unlock()
+
+	memoryIndex.postings = map[string]map[uint]float64{}
+	memoryIndex.indexed = map[uint]bool{}
+	for i := range books {
+		addPostings(&books[i])
+	}
+	memoryIndex.built = true
+}
+
+func (b *memoryBookSearch) ensureBuilt() error {
+	memoryIndex.RLock()
+	built := memoryIndex.built
+	memoryIndex.RUnlock()
+	if built {
+		return nil
+	}
+
+	books, err := b.repo.FindAllBook()
+	if err != nil {
+		return err
+	}
+	b.reset(books)
+	return nil
+}
+
+// addPostings and removePostings expect memoryIndex to be locked.
+func addPostings(book *models.Book) {
+	memoryIndex.indexed[book.ID] = true
+	for _, field := range []struct {
+		text   string
+		weight float64
+	}{{book.Title, titleWeight}, {book.Author, authorWeight}} {
+		tokens := helpers.IndexTokens(field.text)
+		slices.Sort(tokens)
+		for _, token := range slices.Compact(tokens) {
+			if memoryIndex.postings[token] == nil {
+				memoryIndex.postings[token] = map[uint]float64{}
+			}
+			memoryIndex.postings[token][book.ID] += field.weight
+		}
+	}
+}
+
+func removePostings(id uint) {
+	if !memoryIndex.indexed[id] {
+		return
+	}
+	delete(memoryIndex.indexed, id)
+	for token, postings := range memoryIndex.postings {
+		delete(postings, id)
+		if len(postings) == 0 {
+			delete(memoryIndex.postings, token)
+		}
+	}
+}
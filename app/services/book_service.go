@@ -1,42 +1,332 @@
 package services
 
 import (
+	"errors"
+	"goravel/app/helpers"
 	"goravel/app/models"
 	"goravel/app/repositories"
+	"slices"
+	"strings"
 )
 
+var (
+	ErrInvalidISBN   = errors.New("invalid ISBN")
+	ErrDuplicateISBN = errors.New("a book with this ISBN already exists")
+)
+
+// BookRules validates the fields of a book, both in API requests and in
+// imported catalog rows.
+var BookRules = map[string]string{
+	"author":         "required_without:authors|string|max_len:255",
+	"title":          "required|string|max_len:255",
+	"published_year": "required|integer",
+	"stock":          "integer",
+	"isbn":           "string|isbn",
+}
+
 type BookService interface {
 	GetAllBook() ([]models.Book, error)
+	ListBooks(filter repositories.BookFilter) (*repositories.BookPage, error)
+	SearchBooks(query string, limit int) ([]BookSearchHit, error)
 	GetByIDBook(id any) (*models.Book, error)
+	GetByISBNBook(isbn string) (*models.Book, error)
+	LoadRelations(books []models.Book) error
+	SetISBN(book *models.Book, isbn string) error
 	CreateBook(book *models.Book) error
 	UpdateBook(book *models.Book) error
 	DeleteBook(book *models.Book) (int64, error)
 }
 
 type bookService struct {
-	repo repositories.BookRepository
+	repo       repositories.BookRepository
+	search     BookSearchService
+	authors    repositories.AuthorRepository
+	categories repositories.CategoryRepository
+	tags       repositories.TagRepository
 }
 
-func NewBookService(repo repositories.BookRepository) BookService {
-	return &bookService{repo: repo}
+func NewBookService(
+	repo repositories.BookRepository,
+	search BookSearchService,
+	authors repositories.AuthorRepository,
+	categories repositories.CategoryRepository,
+	tags repositories.TagRepository,
+) BookService {
+	return &bookService{repo: repo, search: search, authors: authors, categories: categories, tags: tags}
 }
 
 func (s *bookService) GetAllBook() ([]models.Book, error) {
 	return s.repo.FindAllBook()
 }
 
+func (s *bookService) ListBooks(filter repositories.BookFilter) (*repositories.BookPage, error) {
+	page, err := s.repo.PaginateBooks(filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.LoadRelations(page.Books); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *bookService) SearchBooks(query string, limit int) ([]BookSearchHit, error) {
+	hits, err := s.search.Search(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	books := make([]models.Book, len(hits))
+	for i := range hits {
+		books[i] = hits[i].Book
+	}
+	if err := s.LoadRelations(books); err != nil {
+		return nil, err
+	}
+	for i := range hits {
+		hits[i].Book = books[i]
+	}
+	return hits, nil
+}
+
 func (s *bookService) GetByIDBook(id any) (*models.Book, error) {
-	return s.repo.FindByIDBook(id)
+	book, err := s.repo.FindByIDBook(id)
+	if err != nil {
+		return book, err
+	}
+	return book, s.loadRelation(book)
 }
 
+// GetByISBNBook finds a book by either form of its ISBN.
+func (s *bookService) GetByISBNBook(isbn string) (*models.Book, error) {
+	_, isbn13, ok := helpers.ISBNForms(isbn)
+	if !ok {
+		return nil, ErrInvalidISBN
+	}
+
+	book, err := s.repo.FindByISBNBook(isbn13)
+	if err != nil {
+		return book, err
+	}
+	return book, s.loadRelation(book)
+}
+
+// SetISBN stores both forms of isbn on book, or clears them when isbn is
+// empty.
+func (s *bookService) SetISBN(book *models.Book, isbn string) error {
+	if isbn == "" {
+		book.ISBN10, book.ISBN13 = nil, nil
+		return nil
+	}
+
+	isbn10, isbn13, ok := helpers.ISBNForms(isbn)
+	if !ok {
+		return ErrInvalidISBN
+	}
+	book.ISBN13 = &isbn13
+	book.ISBN10 = nil
+	if isbn10 != "" {
+		book.ISBN10 = &isbn10
+	}
+	return nil
+}
+
+// CreateBook saves a new book along with Stock available copies and its
+// contributors, categories and tags. When Authors is nil they are taken from the names in the Author
+// string.
 func (s *bookService) CreateBook(book *models.Book) error {
-	return s.repo.CreateBook(book)
+	if err := s.ensureISBNUnique(book); err != nil {
+		return err
+	}
+	if err := s.resolveSubjects(book); err != nil {
+		return err
+	}
+	if err := s.resolveAuthors(book); err != nil {
+		return err
+	}
+
+	s.search.Prepare(book)
+	if err := s.repo.CreateBook(book); err != nil {
+		return err
+	}
+
+	s.search.Index(book)
+	return nil
 }
 
+// UpdateBook saves book and replaces its contributors with Authors, which
+// are taken from the Author string when nil. Categories and Tags replace the
+// book's current ones unless nil.
 func (s *bookService) UpdateBook(book *models.Book) error {
-	return s.repo.UpdateBook(book)
+	if err := s.ensureISBNUnique(book); err != nil {
+		return err
+	}
+	if err := s.resolveSubjects(book); err != nil {
+		return err
+	}
+	if err := s.resolveAuthors(book); err != nil {
+		return err
+	}
+
+	s.search.Prepare(book)
+	if err := s.repo.UpdateBook(book); err != nil {
+		return err
+	}
+
+	s.search.Index(book)
+	return nil
 }
 
 func (s *bookService) DeleteBook(book *models.Book) (int64, error) {
-	return s.repo.DeleteBook(book)
+	rows, err := s.repo.DeleteBook(book)
+	if err != nil {
+		return 0, err
+	}
+
+	s.search.Remove(book)
+	return rows, nil
+}
+
+// resolveAuthors points every contributor of book at an author row and
+// rewrites the credit line from them. Contributors given by ID must exist;
+// those given by name reuse the author with that name, or are left without
+// an ID so the repository creates the author along with the book.
+func (s *bookService) resolveAuthors(book *models.Book) error {
+	if book.Authors == nil {
+		for _, name := range helpers.SplitAuthorNames(book.Author) {
+			book.Authors = append(book.Authors, models.BookAuthor{
+				Role:   models.ContributorAuthor,
+				Author: &models.Author{Name: name},
+			})
+		}
+	}
+
+	credits := make([]models.BookAuthor, 0, len(book.Authors))
+	for _, credit := range book.Authors {
+		if credit.Role == "" {
+			credit.Role = models.ContributorAuthor
+		}
+
+		var author *models.Author
+		var err error
+		switch {
+		case credit.AuthorID != 0:
+			author, err = s.authors.FindAuthor(credit.AuthorID)
+		case credit.Author != nil && credit.Author.Name != "":
+			author, err = s.authors.FindAuthorByName(credit.Author.Name)
+			if errors.Is(err, repositories.ErrAuthorNotFound) {
+				author, err = &models.Author{Name: credit.Author.Name}, nil
+			}
+		default:
+			err = repositories.ErrAuthorNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		credit.AuthorID = author.ID
+		credit.Author = author
+		if !slices.ContainsFunc(credits, func(c models.BookAuthor) bool {
+			return c.AuthorID == credit.AuthorID && c.Role == credit.Role &&
+				(c.AuthorID != 0 || strings.EqualFold(c.Author.Name, credit.Author.Name))
+		}) {
+			credits = append(credits, credit)
+		}
+	}
+
+	book.Authors = credits
+	book.Author = helpers.CreditLine(credits)
+	return nil
+}
+
+// resolveSubjects checks that every category of book exists and gives its
+// tags their slugs; the repository creates the tags not seen before along
+// with the book. Tags are matched by slug, so "Science Fiction" and
+// "science-fiction" are the same tag.
+func (s *bookService) resolveSubjects(book *models.Book) error {
+	if book.Categories != nil {
+		categories := make([]models.Category, 0, len(book.Categories))
+		for _, category := range book.Categories {
+			if slices.ContainsFunc(categories, func(c models.Category) bool { return c.ID == category.ID }) {
+				continue
+			}
+			found, err := s.categories.FindCategory(category.ID)
+			if err != nil {
+				return err
+			}
+			categories = append(categories, *found)
+		}
+		book.Categories = categories
+	}
+
+	if book.Tags != nil {
+		tags := make([]models.Tag, 0, len(book.Tags))
+		for _, tag := range book.Tags {
+			tag.Slug = helpers.Slugify(tag.Name)
+			if tag.Slug == "" || slices.ContainsFunc(tags, func(t models.Tag) bool { return t.Slug == tag.Slug }) {
+				continue
+			}
+			tags = append(tags, models.Tag{Name: tag.Name, Slug: tag.Slug})
+		}
+		book.Tags = tags
+	}
+	return nil
+}
+
+func (s *bookService) loadRelation(book *models.Book) error {
+	books := []models.Book{*book}
+	if err := s.LoadRelations(books); err != nil {
+		return err
+	}
+	book.Authors, book.Categories, book.Tags = books[0].Authors, books[0].Categories, books[0].Tags
+	return nil
+}
+
+// LoadRelations fills the Authors, Categories and Tags of each book, leaving
+// empty lists rather than nil.
+func (s *bookService) LoadRelations(books []models.Book) error {
+	ids := make([]uint, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+
+	credits, err := s.authors.FindBookAuthors(ids)
+	if err != nil {
+		return err
+	}
+	categories, err := s.categories.FindBookCategories(ids)
+	if err != nil {
+		return err
+	}
+	tags, err := s.tags.FindBookTags(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range books {
+		books[i].Authors = []models.BookAuthor{}
+		for _, credit := range credits {
+			if credit.BookID == books[i].ID {
+				books[i].Authors = append(books[i].Authors, credit)
+			}
+		}
+		books[i].Categories = append([]models.Category{}, categories[books[i].ID]...)
+		books[i].Tags = append([]models.Tag{}, tags[books[i].ID]...)
+	}
+	return nil
+}
+
+func (s *bookService) ensureISBNUnique(book *models.Book) error {
+	if book.ISBN13 == nil {
+		return nil
+	}
+
+	taken, err := s.repo.ISBNTaken(*book.ISBN13, book.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrDuplicateISBN
+	}
+	return nil
 }
@@ -1,37 +1,137 @@
 package services
 
 import (
+	"errors"
+	"goravel/app/events"
 	"goravel/app/models"
 	"goravel/app/repositories"
+	"time"
+
+	"github.com/goravel/framework/contracts/event"
+	"github.com/goravel/framework/facades"
 )
 
 type BorrowingService interface {
-	GetAllBorrowings() ([]models.Borrowing, error)
-	BorrowingUser(borrowing *models.Borrowing, userID any, bookID any) error
-	ReturnUserBorrowing(borrowing *models.Borrowing, userID any, bookID any) error
-	FindByUserIDBorrowing(id any) (*models.Borrowing, error)
+	GetAllBorrowings(status string) ([]models.Borrowing, error)
+	BorrowingUser(borrowing *models.Borrowing, userID uint, bookID uint, barcode string) error
+	ReturnUserBorrowing(borrowing *models.Borrowing, userID uint, bookID uint, barcode string, condition string) error
+	FindByUserIDBorrowing(id any) ([]models.Borrowing, error)
+	RenewBorrowing(id uint, userID uint) (*models.Borrowing, error)
+	MarkLost(id uint) (*models.Borrowing, error)
+	FlagOverdueBorrowings() (int, error)
 }
 
 type borrowingService struct {
-	repo repositories.BorrowingRepository
+	repo     repositories.BorrowingRepository
+	copies   repositories.BookCopyRepository
+	users    repositories.UserRepository
+	policies LoanPolicyService
 }
 
-func NewBorrowingService(repo repositories.BorrowingRepository) BorrowingService {
-	return &borrowingService{repo: repo}
+func NewBorrowingService(repo repositories.BorrowingRepository, copies repositories.BookCopyRepository, users repositories.UserRepository, policies LoanPolicyService) BorrowingService {
+	return &borrowingService{repo: repo, copies: copies, users: users, policies: policies}
 }
 
-func (s *borrowingService) GetAllBorrowings() ([]models.Borrowing, error) {
-	return s.repo.FindAllBorrowings()
+func (s *borrowingService) GetAllBorrowings(status string) ([]models.Borrowing, error) {
+	return s.repo.FindAllBorrowings(status)
 }
 
-func (s *borrowingService) BorrowingUser(borrowing *models.Borrowing, userID any, bookID any) error {
-	return s.repo.BorrowingUser(borrowing, userID, bookID)
+// BorrowingUser lends a copy of the book to the user under the loan policy
+// for their role and the book's categories, which sets the due date and the
+// limits the loan is checked against. A *repositories.LoanLimitError reports
+// a limit the loan would exceed, and a *repositories.FineBalanceError a
+// patron who owes too much in fines to borrow.
+func (s *borrowingService) BorrowingUser(borrowing *models.Borrowing, userID uint, bookID uint, barcode string) error {
+	if barcode != "" {
+		bookCopy, err := s.copies.FindCopyByBarcode(barcode)
+		if err != nil {
+			return err
+		}
+		if bookID != 0 && bookCopy.BookID != bookID {
+			return repositories.ErrCopyNotFound
+		}
+		bookID = bookCopy.BookID
+	}
+
+	user, err := s.users.FindByIDUser(userID)
+	if err != nil {
+		return repositories.ErrUserNotFound
+	}
+
+	rules, err := s.policies.RulesFor(user.Role, bookID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.BorrowingUser(borrowing, userID, bookID, barcode, rules.Terms(time.Now()))
 }
 
-func (s *borrowingService) ReturnUserBorrowing(borrowing *models.Borrowing, userID any, bookID any) error {
-	return s.repo.ReturnUserBorrowing(borrowing, userID, bookID)
+// ReturnUserBorrowing takes back a loan, charging any late fee it ran up.
+func (s *borrowingService) ReturnUserBorrowing(borrowing *models.Borrowing, userID uint, bookID uint, barcode string, condition string) error {
+	return s.repo.ReturnUserBorrowing(borrowing, userID, bookID, barcode, condition, fineRules())
 }
 
-func (s *borrowingService) FindByUserIDBorrowing(id any) (*models.Borrowing, error) {
+func (s *borrowingService) FindByUserIDBorrowing(id any) ([]models.Borrowing, error) {
 	return s.repo.FindByUserIDBorrowing(id)
 }
+
+// RenewBorrowing extends the user's loan by the loan period of the policy it
+// falls under now, within that policy's renewal limit, and returns it with
+// its history. A userID of 0 renews the loan on behalf of whoever has it.
+func (s *borrowingService) RenewBorrowing(id uint, userID uint) (*models.Borrowing, error) {
+	borrowing, err := s.repo.FindBorrowing(id)
+	if err != nil {
+		return nil, err
+	}
+	if userID != 0 && borrowing.UserID != userID {
+		return nil, repositories.ErrBorrowingNotFound
+	}
+
+	user, err := s.users.FindByIDUser(borrowing.UserID)
+	if err != nil {
+		return nil, repositories.ErrUserNotFound
+	}
+	rules, err := s.policies.RulesFor(user.Role, borrowing.BookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RenewBorrowing(borrowing, rules.LoanDays, rules.MaxRenewals); err != nil {
+		return nil, err
+	}
+
+	borrowing.History, err = s.repo.FindBorrowingEvents(borrowing.ID)
+	return borrowing, err
+}
+
+// MarkLost closes a loan whose copy is not coming back and charges the
+// patron for it.
+func (s *borrowingService) MarkLost(id uint) (*models.Borrowing, error) {
+	borrowing, err := s.repo.FindBorrowing(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.MarkBorrowingLost(borrowing, fineRules()); err != nil {
+		return nil, err
+	}
+	return borrowing, nil
+}
+
+// FlagOverdueBorrowings marks the loans past their due date overdue and
+// dispatches an events.LoanOverdue for each one. It returns how many loans
+// turned overdue. The loans are already marked when the events go out, so a
+// failed dispatch doesn't stop the rest; the failures are returned together.
+func (s *borrowingService) FlagOverdueBorrowings() (int, error) {
+	overdue, err := s.repo.MarkOverdueBorrowings(time.Now())
+	errs := []error{err}
+	for _, borrowing := range overdue {
+		dispatchErr := facades.Event().Job(&events.LoanOverdue{}, []event.Arg{
+			{Type: "uint", Value: borrowing.ID},
+			{Type: "uint", Value: borrowing.UserID},
+			{Type: "uint", Value: borrowing.BookID},
+			{Type: "string", Value: borrowing.DueAt.Format(time.RFC3339)},
+		}).Dispatch()
+		errs = append(errs, dispatchErr)
+	}
+	return len(overdue), errors.Join(errs...)
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"goravel/app/helpers"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"strconv"
+	"strings"
+)
+
+var ErrDuplicateSlug = errors.New("slug is already taken")
+
+// CategoryNode is a category in the browsable tree. BookCount counts the
+// books filed directly under it; TotalBookCount also counts its descendants,
+// each book once.
+type CategoryNode struct {
+	models.Category
+	BookCount      int64
+	TotalBookCount int64
+	Children       []*CategoryNode
+}
+
+type CategoryService interface {
+	Tree() ([]*CategoryNode, error)
+	Subtree(id uint) (*CategoryNode, []models.Category, error)
+	GetCategory(id uint) (*models.Category, error)
+	CreateCategory(category *models.Category) error
+	UpdateCategory(category *models.Category) error
+	DeleteCategory(category *models.Category) error
+}
+
+type categoryService struct {
+	repo repositories.CategoryRepository
+}
+
+func NewCategoryService(repo repositories.CategoryRepository) CategoryService {
+	return &categoryService{repo: repo}
+}
+
+// Tree returns the root categories with their descendants nested under them.
+func (s *categoryService) Tree() ([]*CategoryNode, error) {
+	nodes, err := s.nodes()
+	if err != nil {
+		return nil, err
+	}
+
+	roots := []*CategoryNode{}
+	for _, node := range nodes {
+		if node.ParentID == nil {
+			roots = append(roots, node)
+		}
+	}
+	return roots, nil
+}
+
+// Subtree returns the category with its descendants nested under it, along
+// with its ancestors from the root down.
+func (s *categoryService) Subtree(id uint) (*CategoryNode, []models.Category, error) {
+	nodes, err := s.nodes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	node, ok := nodes[id]
+	if !ok {
+		return nil, nil, repositories.ErrCategoryNotFound
+	}
+
+	ancestors := []models.Category{}
+	for _, segment := range strings.Split(strings.Trim(node.Path, "/"), "/") {
+		ancestorID, _ := strconv.ParseUint(segment, 10, 64)
+		if ancestor, ok := nodes[uint(ancestorID)]; ok && ancestor.ID != id {
+			ancestors = append(ancestors, ancestor.Category)
+		}
+	}
+	return node, ancestors, nil
+}
+
+func (s *categoryService) GetCategory(id uint) (*models.Category, error) {
+	return s.repo.FindCategory(id)
+}
+
+// CreateCategory saves a category, deriving its slug from the name when none
+// is given.
+func (s *categoryService) CreateCategory(category *models.Category) error {
+	if err := s.ensureSlugUnique(category); err != nil {
+		return err
+	}
+	return s.repo.CreateCategory(category)
+}
+
+func (s *categoryService) UpdateCategory(category *models.Category) error {
+	if err := s.ensureSlugUnique(category); err != nil {
+		return err
+	}
+	return s.repo.UpdateCategory(category)
+}
+
+func (s *categoryService) DeleteCategory(category *models.Category) error {
+	return s.repo.DeleteCategory(category)
+}
+
+// nodes loads every category with its counts and links each one to its
+// children, keyed by ID.
+func (s *categoryService) nodes() (map[uint]*CategoryNode, error) {
+	categories, err := s.repo.FindAllCategories()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.repo.CountCategoryBooks()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint]*CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &CategoryNode{
+			Category:       category,
+			BookCount:      counts[category.ID].Direct,
+			TotalBookCount: counts[category.ID].Total,
+			Children:       []*CategoryNode{},
+		}
+	}
+
+	// categories is sorted, so children keep that order.
+	for _, category := range categories {
+		if category.ParentID == nil {
+			continue
+		}
+		if parent, ok := nodes[*category.ParentID]; ok {
+			parent.Children = append(parent.Children, nodes[category.ID])
+		}
+	}
+	return nodes, nil
+}
+
+func (s *categoryService) ensureSlugUnique(category *models.Category) error {
+	category.Slug = helpers.Slugify(category.Slug)
+	if category.Slug == "" {
+		category.Slug = helpers.Slugify(category.Name)
+	}
+
+	taken, err := s.repo.SlugTaken(category.Slug, category.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrDuplicateSlug
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"goravel/app/mails"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/goravel/framework/facades"
+)
+
+var (
+	ErrInvalidSignature     = errors.New("verification link is invalid or expired")
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+)
+
+type EmailVerificationService interface {
+	SendVerificationLink(user *models.User) error
+	Verify(id uint, expires int64, signature string) (*models.User, error)
+}
+
+type emailVerificationService struct {
+	userRepo repositories.UserRepository
+}
+
+func NewEmailVerificationService(userRepo repositories.UserRepository) EmailVerificationService {
+	return &emailVerificationService{userRepo: userRepo}
+}
+
+// SendVerificationLink queues a mail with a signed, expiring link. The
+// signature covers the email address, so changing it voids older links.
+func (s *emailVerificationService) SendVerificationLink(user *models.User) error {
+	if user.HasVerifiedEmail() {
+		return ErrEmailAlreadyVerified
+	}
+
+	expire := facades.Config().GetInt("auth.verification.expire", 1440)
+	expires := time.Now().Add(time.Duration(expire) * time.Minute).Unix()
+
+	link := fmt.Sprintf("%s/api/email/verify/%d?%s",
+		facades.Config().GetString("app.url"),
+		user.ID,
+		url.Values{
+			"expires":   {strconv.FormatInt(expires, 10)},
+			"signature": {signVerification(user, expires)},
+		}.Encode(),
+	)
+
+	return facades.Mail().Queue(&mails.VerifyEmail{
+		To:   user.Email,
+		Name: user.Name,
+		Link: link,
+	})
+}
+
+func (s *emailVerificationService) Verify(id uint, expires int64, signature string) (*models.User, error) {
+	user, err := s.userRepo.FindByIDUser(id)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	expected := signVerification(user, expires)
+	if time.Now().Unix() > expires || !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, ErrInvalidSignature
+	}
+	if user.HasVerifiedEmail() {
+		return user, nil
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func signVerification(user *models.User, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(facades.Config().GetString("app.key")))
+	mac.Write([]byte(fmt.Sprintf("%d|%s|%d", user.ID, user.Email, expires)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
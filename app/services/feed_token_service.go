@@ -0,0 +1,67 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"strings"
+)
+
+var ErrInvalidFeedToken = errors.New("feed token is invalid")
+
+// FeedTokenService manages the tokens e-reader apps use to read the OPDS
+// feeds. Those apps only speak HTTP Basic auth, so a token stands in for the
+// password and is sent with the account's email as the username. Tokens are
+// issued through the authenticated API, so they never bypass two-factor
+// authentication, and each user has at most one.
+type FeedTokenService interface {
+	Issue(user *models.User) (string, error)
+	Revoke(user *models.User) error
+	Authenticate(email, token string) (*models.User, error)
+}
+
+type feedTokenService struct {
+	userRepo repositories.UserRepository
+}
+
+func NewFeedTokenService(userRepo repositories.UserRepository) FeedTokenService {
+	return &feedTokenService{userRepo: userRepo}
+}
+
+// Issue gives user a new feed token, replacing any earlier one. Only its
+// hash is stored, so the token is shown once.
+func (s *feedTokenService) Issue(user *models.User) (string, error) {
+	token, hash, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	user.FeedTokenHash = &hash
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *feedTokenService) Revoke(user *models.User) error {
+	user.FeedTokenHash = nil
+	return s.userRepo.UpdateUser(user)
+}
+
+// Authenticate returns the user whose feed token and email match, and
+// ErrInvalidFeedToken otherwise.
+func (s *feedTokenService) Authenticate(email, token string) (*models.User, error) {
+	if token == "" {
+		return nil, ErrInvalidFeedToken
+	}
+
+	user, err := s.userRepo.FindByFeedTokenHash(hashToken(token))
+	if err != nil {
+		return nil, ErrInvalidFeedToken
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.ToLower(user.Email)), []byte(strings.ToLower(strings.TrimSpace(email)))) != 1 {
+		return nil, ErrInvalidFeedToken
+	}
+	return user, nil
+}
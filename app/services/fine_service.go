@@ -0,0 +1,57 @@
+package services
+
+import (
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"time"
+
+	"github.com/goravel/framework/facades"
+)
+
+type FineService interface {
+	Balance(userID uint) (int64, error)
+	Statement(userID uint) ([]models.FineEntry, error)
+	RecordEntry(entry *models.FineEntry) error
+	AccrueLateFees() (int, error)
+}
+
+type fineService struct {
+	repo repositories.FineRepository
+}
+
+func NewFineService(repo repositories.FineRepository) FineService {
+	return &fineService{repo: repo}
+}
+
+// Balance is what the user owes in fines, in the smallest currency unit.
+func (s *fineService) Balance(userID uint) (int64, error) {
+	return s.repo.UserFineBalance(userID)
+}
+
+// Statement lists the user's ledger entries oldest first.
+func (s *fineService) Statement(userID uint) ([]models.FineEntry, error) {
+	return s.repo.FindUserFineEntries(userID)
+}
+
+// RecordEntry records a charge, payment, waiver or refund staff made against
+// a loan. The amount is given as a positive number whatever the type.
+func (s *fineService) RecordEntry(entry *models.FineEntry) error {
+	return s.repo.RecordFineEntry(entry)
+}
+
+// AccrueLateFees charges the loans still out past their due date the late
+// fees they accrued since they were last charged.
+func (s *fineService) AccrueLateFees() (int, error) {
+	return s.repo.AccrueLateFees(fineRules(), time.Now())
+}
+
+// fineRules reads the fine accrual rules from the fines config.
+func fineRules() repositories.FineRules {
+	config := facades.Config()
+	return repositories.FineRules{
+		DailyRate:         int64(config.GetInt("fines.daily_rate", 25)),
+		GraceDays:         config.GetInt("fines.grace_days", 1),
+		MaxPerItem:        int64(config.GetInt("fines.max_per_item", 1000)),
+		ReplacementCharge: int64(config.GetInt("fines.lost_charge", 2500)),
+	}
+}
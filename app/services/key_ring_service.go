@@ -0,0 +1,286 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/goravel/framework/facades"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var (
+	ErrNoSigningKey         = errors.New("no active signing key")
+	ErrUnknownKeyID         = errors.New("unknown signing key id")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrLegacyToken          = errors.New("tokens signed with the shared secret are no longer accepted")
+)
+
+// SigningKey is a parsed key ring entry ready for signing or verifying.
+type SigningKey struct {
+	KID        string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	Status     string
+}
+
+type KeyRingService interface {
+	ActiveKey() (*SigningKey, error)
+	VerificationKey(kid string) (*SigningKey, error)
+	JWKS() (map[string]any, error)
+	Rotate(algorithm string, keepRetired int) (*SigningKey, error)
+}
+
+type keyRingService struct {
+	repo repositories.KeyRingRepository
+}
+
+// The key ring is shared by every request, so parsed keys are cached and only
+// reloaded when the file changes, e.g. after `jwt:rotate-keys` runs in
+// another process.
+var keyRingCache struct {
+	sync.RWMutex
+	loadedAt time.Time
+	keys     []*SigningKey
+}
+
+func NewKeyRingService(repo repositories.KeyRingRepository) KeyRingService {
+	return &keyRingService{repo: repo}
+}
+
+// ActiveKey returns the key new tokens are signed with, or ErrNoSigningKey
+// when no key ring has been generated yet.
+func (s *keyRingService) ActiveKey() (*SigningKey, error) {
+	keys, err := s.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if key.Status == models.SigningKeyActive {
+			return key, nil
+		}
+	}
+	return nil, ErrNoSigningKey
+}
+
+func (s *keyRingService) VerificationKey(kid string) (*SigningKey, error) {
+	keys, err := s.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if key.KID == kid {
+			return key, nil
+		}
+	}
+	return nil, ErrUnknownKeyID
+}
+
+// JWKS renders the public half of every key in the ring as a JSON Web Key Set.
+func (s *keyRingService) JWKS() (map[string]any, error) {
+	keys, err := s.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	jwks := make([]map[string]any, 0, len(keys))
+	for _, key := range keys {
+		jwk := map[string]any{
+			"kid": key.KID,
+			"alg": key.Method.Alg(),
+			"use": "sig",
+		}
+		switch public := key.PrivateKey.Public().(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(public)
+		}
+		jwks = append(jwks, jwk)
+	}
+
+	return map[string]any{"keys": jwks}, nil
+}
+
+// Rotate generates a new active key, retires the current one and keeps at
+// most keepRetired retired keys, dropping the oldest first.
+func (s *keyRingService) Rotate(algorithm string, keepRetired int) (*SigningKey, error) {
+	privateKey, err := generatePrivateKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.repo.LoadKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	next := models.SigningKey{
+		KID:        uuid.NewString(),
+		Algorithm:  algorithm,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		Status:     models.SigningKeyActive,
+		CreatedAt:  time.Now(),
+	}
+
+	// Newest first, so trimming retired keys drops the oldest ones.
+	keys := []models.SigningKey{next}
+	retired := 0
+	for _, key := range stored {
+		if retired >= keepRetired {
+			break
+		}
+		key.Status = models.SigningKeyRetired
+		keys = append(keys, key)
+		retired++
+	}
+
+	if err := s.repo.SaveKeys(keys); err != nil {
+		return nil, err
+	}
+
+	keyRingCache.Lock()
+	keyRingCache.keys = nil
+	keyRingCache.loadedAt = time.Time{}
+	keyRingCache.Unlock()
+
+	return parseSigningKey(next)
+}
+
+func (s *keyRingService) keys() ([]*SigningKey, error) {
+	modified, err := s.repo.LastModified()
+	if err != nil {
+		return nil, err
+	}
+
+	keyRingCache.RLock()
+	if !keyRingCache.loadedAt.IsZero() && !modified.After(keyRingCache.loadedAt) {
+		defer keyRingCache.RUnlock()
+		return keyRingCache.keys, nil
+	}
+	keyRingCache.RUnlock()
+
+	stored, err := s.repo.LoadKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*SigningKey, 0, len(stored))
+	for _, entry := range stored {
+		key, err := parseSigningKey(entry)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", entry.KID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	keyRingCache.Lock()
+	keyRingCache.keys = keys
+	keyRingCache.loadedAt = modified
+	keyRingCache.Unlock()
+
+	return keys, nil
+}
+
+func generatePrivateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmEdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	}
+	return nil, ErrUnsupportedAlgorithm
+}
+
+func parseSigningKey(entry models.SigningKey) (*SigningKey, error) {
+	block, _ := pem.Decode([]byte(entry.PrivateKey))
+	if block == nil {
+		return nil, errors.New("invalid PEM data")
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{KID: entry.KID, Status: entry.Status}
+	switch entry.Algorithm {
+	case AlgorithmRS256:
+		key.Method = jwt.SigningMethodRS256
+		key.PrivateKey, _ = privateKey.(*rsa.PrivateKey)
+	case AlgorithmEdDSA:
+		key.Method = jwt.SigningMethodEdDSA
+		key.PrivateKey, _ = privateKey.(ed25519.PrivateKey)
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if key.PrivateKey == nil {
+		return nil, fmt.Errorf("key does not match algorithm %s", entry.Algorithm)
+	}
+
+	return key, nil
+}
+
+// keyFunc verifies that a token was signed with the algorithm its key is
+// registered for. Tokens with a kid are checked against the key ring; tokens
+// without one fall back to HS256 and jwt.secret, which is only accepted while
+// no key ring exists. Once the first key is generated the shared secret stops
+// verifying anything, so whoever still holds it can't mint tokens.
+func keyFunc(keyRing KeyRingService) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			if _, err := keyRing.ActiveKey(); err == nil {
+				return nil, ErrLegacyToken
+			} else if !errors.Is(err, ErrNoSigningKey) {
+				return nil, err
+			}
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+			}
+			secret := facades.Config().GetString("jwt.secret")
+			if secret == "" {
+				return nil, errors.New("JWT secret not configured")
+			}
+			return []byte(secret), nil
+		}
+
+		key, err := keyRing.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return key.PrivateKey.Public(), nil
+	}
+}
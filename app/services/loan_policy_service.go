@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goravel/framework/facades"
+)
+
+var ErrDuplicateLoanPolicy = errors.New("a loan policy for this role and category already exists")
+
+// LoanRules are the terms a patron borrows a particular book under. They
+// always include the patron's overall loan limit; a category policy adds a
+// limit of its own for books in that category. MaxFineBalance is the most a
+// patron may owe in fines and still borrow.
+type LoanRules struct {
+	LoanDays       int
+	MaxRenewals    int
+	Limits         []repositories.LoanLimit
+	MaxFineBalance int64
+}
+
+// Terms returns the terms of a loan made under the rules at from.
+func (r *LoanRules) Terms(from time.Time) repositories.LoanTerms {
+	return repositories.LoanTerms{
+		DueAt:          from.AddDate(0, 0, r.LoanDays),
+		Limits:         r.Limits,
+		MaxFineBalance: r.MaxFineBalance,
+	}
+}
+
+type LoanPolicyService interface {
+	ListPolicies() ([]models.LoanPolicy, error)
+	GetPolicy(id uint) (*models.LoanPolicy, error)
+	CreatePolicy(policy *models.LoanPolicy) error
+	UpdatePolicy(policy *models.LoanPolicy) error
+	DeletePolicy(policy *models.LoanPolicy) error
+	RulesFor(role string, bookID uint) (*LoanRules, error)
+}
+
+type loanPolicyService struct {
+	repo       repositories.LoanPolicyRepository
+	categories repositories.CategoryRepository
+}
+
+func NewLoanPolicyService(repo repositories.LoanPolicyRepository, categories repositories.CategoryRepository) LoanPolicyService {
+	return &loanPolicyService{repo: repo, categories: categories}
+}
+
+func (s *loanPolicyService) ListPolicies() ([]models.LoanPolicy, error) {
+	return s.repo.FindLoanPolicies()
+}
+
+func (s *loanPolicyService) GetPolicy(id uint) (*models.LoanPolicy, error) {
+	return s.repo.FindLoanPolicy(id)
+}
+
+func (s *loanPolicyService) CreatePolicy(policy *models.LoanPolicy) error {
+	if err := s.ensureUnique(policy); err != nil {
+		return err
+	}
+	return s.repo.CreateLoanPolicy(policy)
+}
+
+func (s *loanPolicyService) UpdatePolicy(policy *models.LoanPolicy) error {
+	if err := s.ensureUnique(policy); err != nil {
+		return err
+	}
+	return s.repo.UpdateLoanPolicy(policy)
+}
+
+func (s *loanPolicyService) DeletePolicy(policy *models.LoanPolicy) error {
+	return s.repo.DeleteLoanPolicy(policy)
+}
+
+// RulesFor resolves the terms a patron with role borrows bookID under. The
+// patron's overall terms come from the policy for their role, else the one
+// for any role, else the loans config. A policy for one of the book's
+// categories or their ancestors takes precedence over those, the deepest
+// category first and a role-specific policy before one for any role.
+func (s *loanPolicyService) RulesFor(role string, bookID uint) (*LoanRules, error) {
+	policies, err := s.repo.FindLoanPolicies()
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.categories.FindBookCategories([]uint{bookID})
+	if err != nil {
+		return nil, err
+	}
+
+	// Depth of every category the book is filed under, directly or through
+	// a subcategory, keeping the deepest when paths overlap.
+	depths := map[uint]int{}
+	for _, category := range categories[bookID] {
+		for depth, segment := range strings.Split(strings.Trim(category.Path, "/"), "/") {
+			id, err := strconv.ParseUint(segment, 10, 64)
+			if err == nil && depth+1 > depths[uint(id)] {
+				depths[uint(id)] = depth + 1
+			}
+		}
+	}
+
+	var overall, scoped *models.LoanPolicy
+	overallRank, scopedRank := -1, -1
+	for i := range policies {
+		policy := &policies[i]
+		if policy.Role != "" && policy.Role != role {
+			continue
+		}
+		rank := 0
+		if policy.Role != "" {
+			rank = 1
+		}
+
+		if policy.CategoryID == nil {
+			if rank > overallRank {
+				overall, overallRank = policy, rank
+			}
+			continue
+		}
+		depth, ok := depths[*policy.CategoryID]
+		if !ok {
+			continue
+		}
+		if rank += depth * 2; rank > scopedRank {
+			scoped, scopedRank = policy, rank
+		}
+	}
+
+	config := facades.Config()
+	rules := &LoanRules{
+		LoanDays:       config.GetInt("loans.loan_days", 14),
+		MaxRenewals:    config.GetInt("loans.max_renewals", 2),
+		MaxFineBalance: int64(config.GetInt("fines.block_threshold", 1000)),
+	}
+	maxLoans := config.GetInt("loans.max_loans", 5)
+	if overall != nil {
+		rules.LoanDays, rules.MaxRenewals, maxLoans = overall.LoanDays, overall.MaxRenewals, overall.MaxLoans
+	}
+	rules.Limits = []repositories.LoanLimit{{Max: maxLoans}}
+
+	if scoped != nil {
+		rules.LoanDays, rules.MaxRenewals = scoped.LoanDays, scoped.MaxRenewals
+		rules.Limits = append(rules.Limits, repositories.LoanLimit{CategoryID: *scoped.CategoryID, Max: scoped.MaxLoans})
+	}
+
+	return rules, nil
+}
+
+func (s *loanPolicyService) ensureUnique(policy *models.LoanPolicy) error {
+	if policy.CategoryID != nil {
+		if _, err := s.categories.FindCategory(*policy.CategoryID); err != nil {
+			return err
+		}
+	}
+
+	exists, err := s.repo.LoanPolicyExists(policy.Role, policy.CategoryID, policy.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrDuplicateLoanPolicy
+	}
+	return nil
+}
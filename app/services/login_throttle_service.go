@@ -0,0 +1,95 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/goravel/framework/facades"
+)
+
+type LoginThrottleService interface {
+	RetryAfter(email, ip string) time.Duration
+	RecordFailure(email, ip string)
+	RecordSuccess(email, ip string)
+	Clear(email string)
+}
+
+type loginThrottleService struct{}
+
+func NewLoginThrottleService() LoginThrottleService {
+	return &loginThrottleService{}
+}
+
+// RetryAfter returns how long the caller must wait before the next attempt
+// for email from ip is accepted, or zero when it may proceed.
+func (s *loginThrottleService) RetryAfter(email, ip string) time.Duration {
+	now := time.Now().Unix()
+	wait := int64(0)
+	for _, key := range []string{emailThrottleKey(email), ipThrottleKey(ip)} {
+		if availableAt := facades.Cache().GetInt64(key + ":available_at"); availableAt-now > wait {
+			wait = availableAt - now
+		}
+	}
+	return time.Duration(wait) * time.Second
+}
+
+func (s *loginThrottleService) RecordFailure(email, ip string) {
+	config := facades.Config()
+	decay := time.Duration(config.GetInt("auth.throttle.decay_minutes", 60)) * time.Minute
+	lockout := time.Duration(config.GetInt("auth.throttle.lockout_minutes", 15)) * time.Minute
+
+	emailKey := emailThrottleKey(email)
+	attempts := hit(emailKey, decay)
+	delayAfter := config.GetInt("auth.throttle.delay_after", 3)
+	switch {
+	case attempts >= config.GetInt("auth.throttle.max_attempts", 10):
+		block(emailKey, lockout)
+	case attempts > delayAfter:
+		maxDelay := float64(config.GetInt("auth.throttle.max_delay", 60))
+		delay := math.Min(math.Pow(2, float64(attempts-delayAfter-1)), maxDelay)
+		block(emailKey, time.Duration(delay)*time.Second)
+	}
+
+	ipKey := ipThrottleKey(ip)
+	if hit(ipKey, decay) >= config.GetInt("auth.throttle.ip_max_attempts", 50) {
+		block(ipKey, lockout)
+	}
+}
+
+// RecordSuccess forgets the failures recorded for email and for ip once a
+// login from ip succeeds, so a shared address such as an office NAT isn't
+// locked out by mistyped passwords that were followed by a good one.
+func (s *loginThrottleService) RecordSuccess(email, ip string) {
+	s.Clear(email)
+	facades.Cache().Forget(ipThrottleKey(ip))
+}
+
+// Clear forgets failures recorded for email, e.g. when an admin unlocks the
+// account.
+func (s *loginThrottleService) Clear(email string) {
+	key := emailThrottleKey(email)
+	facades.Cache().Forget(key)
+	facades.Cache().Forget(key + ":available_at")
+}
+
+func hit(key string, decay time.Duration) int {
+	attempts := facades.Cache().GetInt(key) + 1
+	_ = facades.Cache().Put(key, attempts, decay)
+	return attempts
+}
+
+func block(key string, duration time.Duration) {
+	_ = facades.Cache().Put(key+":available_at", time.Now().Add(duration).Unix(), duration)
+}
+
+func emailThrottleKey(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return "login:email:" + hex.EncodeToString(sum[:])
+}
+
+func ipThrottleKey(ip string) string {
+	return "login:ip:" + ip
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"goravel/app/mails"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"net/url"
+	"time"
+
+	frameworkerrors "github.com/goravel/framework/errors"
+	"github.com/goravel/framework/facades"
+)
+
+type PasswordService interface {
+	SendResetLink(email string) error
+	ResetPassword(token, password string) error
+}
+
+type passwordService struct {
+	repo     repositories.PasswordResetRepository
+	userRepo repositories.UserRepository
+}
+
+func NewPasswordService(repo repositories.PasswordResetRepository, userRepo repositories.UserRepository) PasswordService {
+	return &passwordService{repo: repo, userRepo: userRepo}
+}
+
+// SendResetLink queues a reset mail when email belongs to a user. Unknown
+// addresses are ignored so callers can't probe which accounts exist.
+func (s *passwordService) SendResetLink(email string) error {
+	user, err := s.userRepo.FindByEmailUser(email)
+	if errors.Is(err, frameworkerrors.OrmRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, hash, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	expire := facades.Config().GetInt("auth.passwords.expire", 60)
+	reset := &models.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(time.Duration(expire) * time.Minute),
+	}
+	if err := s.repo.CreatePasswordReset(reset); err != nil {
+		return err
+	}
+
+	link := facades.Config().GetString("auth.passwords.reset_url") + "?" + url.Values{
+		"token": {token},
+		"email": {user.Email},
+	}.Encode()
+
+	return facades.Mail().Queue(&mails.PasswordReset{
+		To:        user.Email,
+		Name:      user.Name,
+		Link:      link,
+		ExpiresIn: expire,
+	})
+}
+
+func (s *passwordService) ResetPassword(token, password string) error {
+	hashedPassword, err := facades.Hash().Make(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repo.ResetPassword(hashToken(token), hashedPassword)
+	return err
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"time"
+)
+
+type ReservationService interface {
+	PlaceHold(userID uint, bookID uint) (*models.Reservation, error)
+	ListUserHolds(userID uint) ([]models.Reservation, error)
+	ListBookHolds(bookID uint) ([]models.Reservation, error)
+	CancelHold(id uint, userID uint) (*models.Reservation, error)
+	ExpireHolds() (int, error)
+}
+
+type reservationService struct {
+	repo repositories.ReservationRepository
+}
+
+func NewReservationService(repo repositories.ReservationRepository) ReservationService {
+	return &reservationService{repo: repo}
+}
+
+// PlaceHold puts the user at the back of the queue for a book that has no
+// copy on the shelf.
+func (s *reservationService) PlaceHold(userID uint, bookID uint) (*models.Reservation, error) {
+	reservation := &models.Reservation{UserID: userID, BookID: bookID}
+	if err := s.repo.CreateReservation(reservation); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+func (s *reservationService) ListUserHolds(userID uint) ([]models.Reservation, error) {
+	return s.repo.FindUserReservations(userID)
+}
+
+func (s *reservationService) ListBookHolds(bookID uint) ([]models.Reservation, error) {
+	return s.repo.FindBookReservations(bookID)
+}
+
+// CancelHold withdraws one of the user's holds. A userID of 0 cancels the
+// hold on behalf of whoever placed it.
+func (s *reservationService) CancelHold(id uint, userID uint) (*models.Reservation, error) {
+	reservation, err := s.repo.FindReservation(id)
+	if err != nil {
+		return nil, err
+	}
+	if userID != 0 && reservation.UserID != userID {
+		return nil, repositories.ErrReservationNotFound
+	}
+
+	if err := s.repo.CancelReservation(reservation); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// ExpireHolds closes the holds nobody collected in time, handing their
+// copies to the next patrons in line.
+func (s *reservationService) ExpireHolds() (int, error) {
+	return s.repo.ExpireReservations(time.Now())
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"goravel/app/helpers"
+	"goravel/app/models"
+	"goravel/app/repositories"
+)
+
+type TagService interface {
+	ListTags(name string) ([]repositories.TagCount, error)
+	GetTag(id uint) (*models.Tag, error)
+	RenameTag(tag *models.Tag, name string) error
+	DeleteTag(tag *models.Tag) error
+}
+
+type tagService struct {
+	repo repositories.TagRepository
+}
+
+func NewTagService(repo repositories.TagRepository) TagService {
+	return &tagService{repo: repo}
+}
+
+func (s *tagService) ListTags(name string) ([]repositories.TagCount, error) {
+	return s.repo.FindTags(name)
+}
+
+func (s *tagService) GetTag(id uint) (*models.Tag, error) {
+	return s.repo.FindTag(id)
+}
+
+// RenameTag changes a tag's name and slug. Renaming a tag onto the slug of
+// another one is refused rather than merging them.
+func (s *tagService) RenameTag(tag *models.Tag, name string) error {
+	slug := helpers.Slugify(name)
+	taken, err := s.repo.SlugTaken(slug, tag.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrDuplicateSlug
+	}
+
+	tag.Name = name
+	tag.Slug = slug
+	return s.repo.UpdateTag(tag)
+}
+
+func (s *tagService) DeleteTag(tag *models.Tag) error {
+	return s.repo.DeleteTag(tag)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/goravel/framework/facades"
+)
+
+// TokenPair is the set of credentials handed to a client after it authenticates.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int
+}
+
+type TokenService interface {
+	IssueTokens(user *models.User) (*TokenPair, error)
+	ParseAccessToken(token string) (jwt.MapClaims, error)
+	Refresh(refreshToken string) (*TokenPair, error)
+	RevokeAccessToken(jti string, userID uint, expiresAt time.Time) error
+	RevokeRefreshToken(refreshToken string) error
+	RevokeUserRefreshTokens(userID uint) error
+}
+
+type tokenService struct {
+	repo     repositories.TokenRepository
+	userRepo repositories.UserRepository
+	keyRing  KeyRingService
+}
+
+func NewTokenService(repo repositories.TokenRepository, userRepo repositories.UserRepository, keyRing KeyRingService) TokenService {
+	return &tokenService{repo: repo, userRepo: userRepo, keyRing: keyRing}
+}
+
+func (s *tokenService) IssueTokens(user *models.User) (*TokenPair, error) {
+	refreshToken, hash, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  uuid.NewString(),
+		TokenHash: hash,
+	}
+	// jwt.refresh_ttl bounds the whole family, so rotations inherit this expiry.
+	if refreshTTL := facades.Config().GetInt("jwt.refresh_ttl", 20160); refreshTTL > 0 {
+		expiresAt := time.Now().Add(time.Duration(refreshTTL) * time.Minute)
+		record.ExpiresAt = &expiresAt
+	}
+	if err := s.repo.CreateRefreshToken(record); err != nil {
+		return nil, err
+	}
+
+	return s.pair(user, refreshToken)
+}
+
+func (s *tokenService) Refresh(refreshToken string) (*TokenPair, error) {
+	nextToken, nextHash, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	next := &models.RefreshToken{TokenHash: nextHash}
+	if err := s.repo.RotateRefreshToken(hashToken(refreshToken), next); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByIDUser(next.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.pair(user, nextToken)
+}
+
+// ParseAccessToken verifies token against the key ring and returns its claims.
+func (s *tokenService) ParseAccessToken(token string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, keyFunc(s.keyRing)); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (s *tokenService) RevokeAccessToken(jti string, userID uint, expiresAt time.Time) error {
+	return s.repo.RevokeToken(jti, userID, expiresAt)
+}
+
+func (s *tokenService) RevokeRefreshToken(refreshToken string) error {
+	return s.repo.RevokeRefreshTokenFamily(hashToken(refreshToken))
+}
+
+func (s *tokenService) RevokeUserRefreshTokens(userID uint) error {
+	return s.repo.RevokeUserRefreshTokens(userID)
+}
+
+func (s *tokenService) pair(user *models.User, refreshToken string) (*TokenPair, error) {
+	ttl := facades.Config().GetInt("jwt.ttl", 60)
+	accessToken, err := s.signAccessToken(user, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    ttl * 60,
+	}, nil
+}
+
+func (s *tokenService) signAccessToken(user *models.User, ttl int) (string, error) {
+	claims := jwt.MapClaims{
+		"jti":  uuid.NewString(),
+		"sub":  user.ID,
+		"role": user.Role,
+		"exp":  time.Now().Add(time.Duration(ttl) * time.Minute).Unix(),
+		"iat":  time.Now().Unix(),
+	}
+
+	key, err := s.keyRing.ActiveKey()
+	if errors.Is(err, ErrNoSigningKey) {
+		// No key ring yet, fall back to the shared secret.
+		jwtSecret := facades.Config().GetString("jwt.secret")
+		if jwtSecret == "" {
+			return "", errors.New("JWT secret not configured")
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtSecret))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.KID
+	return token.SignedString(key.PrivateKey)
+}
+
+// generateOpaqueToken returns a random URL-safe token and the hash to store for it.
+func generateOpaqueToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
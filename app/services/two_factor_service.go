@@ -0,0 +1,242 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"goravel/app/helpers"
+	"goravel/app/models"
+	"goravel/app/repositories"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goravel/framework/facades"
+)
+
+const recoveryCodeCount = 8
+
+// recoveryCodeBytes gives each recovery code 80 bits of entropy.
+const recoveryCodeBytes = 10
+
+var (
+	ErrTwoFactorAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled      = errors.New("two-factor authentication is not enrolled")
+	ErrInvalidTwoFactorCode      = errors.New("two-factor code is invalid")
+	ErrTwoFactorChallengeInvalid = errors.New("two-factor challenge is invalid or expired")
+)
+
+// TwoFactorSetup is shown once during enrollment so the user can add the
+// account to an authenticator app.
+type TwoFactorSetup struct {
+	Secret string
+	URI    string
+}
+
+type TwoFactorService interface {
+	Enroll(user *models.User) (*TwoFactorSetup, error)
+	Confirm(user *models.User, code string) ([]string, error)
+	Disable(user *models.User, code, recoveryCode string) error
+	CreateChallenge(user *models.User) (string, error)
+	VerifyChallenge(challenge, code, recoveryCode string) (*models.User, error)
+	RequiresEnrollment(user *models.User) bool
+}
+
+type twoFactorService struct {
+	userRepo repositories.UserRepository
+}
+
+func NewTwoFactorService(userRepo repositories.UserRepository) TwoFactorService {
+	return &twoFactorService{userRepo: userRepo}
+}
+
+// Enroll generates a new secret for user. It only takes effect once Confirm
+// receives a code from it, so an abandoned enrollment never locks anyone out.
+func (s *twoFactorService) Enroll(user *models.User) (*TwoFactorSetup, error) {
+	if user.HasTwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := helpers.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := facades.Crypt().EncryptString(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	user.TwoFactorSecret = encrypted
+	user.TwoFactorRecoveryCodes = ""
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return nil, err
+	}
+
+	issuer := facades.Config().GetString("auth.two_factor.issuer")
+	if issuer == "" {
+		issuer = facades.Config().GetString("app.name")
+	}
+
+	return &TwoFactorSetup{
+		Secret: secret,
+		URI:    helpers.TOTPURI(issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables two-factor authentication once code matches the enrolled
+// secret and returns the plain recovery codes, which are only stored hashed.
+func (s *twoFactorService) Confirm(user *models.User, code string) ([]string, error) {
+	if user.HasTwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TwoFactorSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if err := s.checkCode(user, code); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		encoded := hex.EncodeToString(buf)
+		codes[i] = strings.Join([]string{encoded[:5], encoded[5:10], encoded[10:15], encoded[15:]}, "-")
+		hash, err := facades.Hash().Make(codes[i])
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hash
+	}
+	stored, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.TwoFactorRecoveryCodes = string(stored)
+	user.TwoFactorConfirmedAt = &now
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off after checking a current code
+// or recovery code, so a hijacked session alone can't remove it.
+func (s *twoFactorService) Disable(user *models.User, code, recoveryCode string) error {
+	if !user.HasTwoFactorEnabled() {
+		return ErrTwoFactorNotEnrolled
+	}
+	if err := s.checkSecondFactor(user, code, recoveryCode); err != nil {
+		return err
+	}
+
+	user.TwoFactorSecret = ""
+	user.TwoFactorRecoveryCodes = ""
+	user.TwoFactorConfirmedAt = nil
+	return s.userRepo.UpdateUser(user)
+}
+
+// CreateChallenge returns an opaque token standing in for a correct password
+// until the second factor is entered. Only its hash is kept in the cache.
+func (s *twoFactorService) CreateChallenge(user *models.User) (string, error) {
+	token, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	expire := time.Duration(facades.Config().GetInt("auth.two_factor.challenge_expire", 5)) * time.Minute
+	if err := facades.Cache().Put(challengeKey(tokenHash), user.ID, expire); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// VerifyChallenge returns the user behind challenge once code or recoveryCode
+// is correct. The challenge is single-use and is dropped after too many wrong
+// codes, which sends the caller back through the throttled login.
+func (s *twoFactorService) VerifyChallenge(challenge, code, recoveryCode string) (*models.User, error) {
+	key := challengeKey(hashToken(challenge))
+	userID := facades.Cache().GetInt(key)
+	if userID == 0 {
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+
+	user, err := s.userRepo.FindByIDUser(userID)
+	if err != nil || !user.HasTwoFactorEnabled() {
+		facades.Cache().Forget(key)
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+
+	if err := s.checkSecondFactor(user, code, recoveryCode); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			expire := time.Duration(facades.Config().GetInt("auth.two_factor.challenge_expire", 5)) * time.Minute
+			attempts := hit(key+":attempts", expire)
+			if attempts >= facades.Config().GetInt("auth.two_factor.challenge_attempts", 5) {
+				facades.Cache().Forget(key)
+				facades.Cache().Forget(key + ":attempts")
+			}
+		}
+		return nil, err
+	}
+
+	facades.Cache().Forget(key)
+	facades.Cache().Forget(key + ":attempts")
+	return user, nil
+}
+
+// RequiresEnrollment reports whether user holds a privileged role that must
+// use two-factor authentication but has not enrolled yet.
+func (s *twoFactorService) RequiresEnrollment(user *models.User) bool {
+	if !facades.Config().GetBool("auth.two_factor.required", false) {
+		return false
+	}
+
+	return user.HasRole(models.RoleAdmin, models.RoleLibrarian) && !user.HasTwoFactorEnabled()
+}
+
+func (s *twoFactorService) checkSecondFactor(user *models.User, code, recoveryCode string) error {
+	if recoveryCode == "" {
+		return s.checkCode(user, code)
+	}
+
+	consumed, err := s.userRepo.ConsumeRecoveryCode(user.ID, strings.ToLower(strings.TrimSpace(recoveryCode)))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// checkCode verifies a TOTP code and refuses any code from a time step that
+// was already used, so an observed code can't be replayed.
+func (s *twoFactorService) checkCode(user *models.User, code string) error {
+	secret, err := facades.Crypt().DecryptString(user.TwoFactorSecret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := helpers.VerifyTOTP(secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	key := "2fa:last_step:" + strconv.FormatUint(uint64(user.ID), 10)
+	if step <= facades.Cache().GetInt64(key) {
+		return ErrInvalidTwoFactorCode
+	}
+	return facades.Cache().Put(key, step, 3*helpers.TOTPPeriod*time.Second)
+}
+
+func challengeKey(tokenHash string) string {
+	return "2fa:challenge:" + tokenHash
+}
@@ -6,29 +6,40 @@ import (
 	"goravel/app/repositories"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/goravel/framework/facades"
 )
 
+// LoginResult carries either a token pair or, for users with two-factor
+// authentication, a challenge token to exchange through VerifyTwoFactor.
+type LoginResult struct {
+	User           *models.User
+	Tokens         *TokenPair
+	ChallengeToken string
+}
+
 type UserService interface {
-	Login(email, password string) (*models.User, string, error)
-	Logout(token string) error
+	Login(email, password string) (*LoginResult, error)
+	VerifyTwoFactor(challenge, code, recoveryCode string) (*LoginResult, error)
+	Logout(jti string, userID uint, expiresAt time.Time, refreshToken string) error
+	Refresh(refreshToken string) (*TokenPair, error)
 	RegisterUser(user *models.User) error
 	GetAllUser() ([]models.User, error)
 	GetByIDUser(id any) (*models.User, error)
-	UpdateUser(user *models.User, id int) error
+	UpdateUser(user *models.User, password string) error
 	DeleteUser(user *models.User) (int64, error)
 	ValidateEmailUnique(email string, excludeID int) error
 }
 
 type userService struct {
-	repo repositories.UserRepository
+	repo      repositories.UserRepository
+	tokens    TokenService
+	twoFactor TwoFactorService
 }
 
 var ErrEmailExists = errors.New("email already exists")
 
-func NewUserService(repo repositories.UserRepository) UserService {
-	return &userService{repo: repo}
+func NewUserService(repo repositories.UserRepository, tokens TokenService, twoFactor TwoFactorService) UserService {
+	return &userService{repo: repo, tokens: tokens, twoFactor: twoFactor}
 }
 
 func (s *userService) GetAllUser() ([]models.User, error) {
@@ -54,18 +65,29 @@ func (s *userService) RegisterUser(user *models.User) error {
 	return s.repo.RegisterUser(user)
 }
 
-func (s *userService) UpdateUser(user *models.User, id int) error {
-	if err := s.ValidateEmailUnique(user.Email, id); err != nil {
+// UpdateUser saves user, re-hashing password only when a new one is given.
+// Changing the password signs the user out everywhere.
+func (s *userService) UpdateUser(user *models.User, password string) error {
+	if err := s.ValidateEmailUnique(user.Email, int(user.ID)); err != nil {
 		return err
 	}
 
-	hashedPassword, err := facades.Hash().Make(user.Password)
+	if password == "" {
+		return s.repo.UpdateUser(user)
+	}
+
+	hashedPassword, err := facades.Hash().Make(password)
 	if err != nil {
 		return err
 	}
+	now := time.Now()
 	user.Password = hashedPassword
+	user.TokensRevokedAt = &now
 
-	return s.repo.UpdateUser(user)
+	if err := s.repo.UpdateUser(user); err != nil {
+		return err
+	}
+	return s.tokens.RevokeUserRefreshTokens(user.ID)
 }
 
 func (s *userService) DeleteUser(user *models.User) (int64, error) {
@@ -84,35 +106,55 @@ func (s *userService) ValidateEmailUnique(email string, id int) error {
 	return nil
 }
 
-func (s *userService) Login(email, password string) (*models.User, string, error) {
+// Login checks the password and issues tokens, or only a short-lived
+// challenge token when the user has two-factor authentication enabled.
+func (s *userService) Login(email, password string) (*LoginResult, error) {
 	user, err := s.repo.LoginUser(email, password)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+
+	if user.HasTwoFactorEnabled() {
+		challenge, err := s.twoFactor.CreateChallenge(user)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{User: user, ChallengeToken: challenge}, nil
 	}
 
-	// Generate JWT token manually
-	jwtSecret := facades.Config().GetString("jwt.secret")
-	if jwtSecret == "" {
-		return nil, "", errors.New("JWT secret not configured")
+	tokens, err := s.tokens.IssueTokens(user)
+	if err != nil {
+		return nil, err
 	}
 
-	ttl := facades.Config().GetInt("jwt.ttl", 60)
+	return &LoginResult{User: user, Tokens: tokens}, nil
+}
 
-	claims := jwt.MapClaims{
-		"sub": user.ID,
-		"exp": time.Now().Add(time.Duration(ttl) * time.Minute).Unix(),
-		"iat": time.Now().Unix(),
+func (s *userService) VerifyTwoFactor(challenge, code, recoveryCode string) (*LoginResult, error) {
+	user, err := s.twoFactor.VerifyChallenge(challenge, code, recoveryCode)
+	if err != nil {
+		return nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(jwtSecret))
+	tokens, err := s.tokens.IssueTokens(user)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+
+	return &LoginResult{User: user, Tokens: tokens}, nil
+}
+
+func (s *userService) Logout(jti string, userID uint, expiresAt time.Time, refreshToken string) error {
+	if err := s.tokens.RevokeAccessToken(jti, userID, expiresAt); err != nil {
+		return err
+	}
+	if refreshToken == "" {
+		return nil
 	}
 
-	return user, tokenString, nil
+	return s.tokens.RevokeRefreshToken(refreshToken)
 }
 
-func (s *userService) Logout(token string) error {
-	return s.repo.Logout(token)
+func (s *userService) Refresh(refreshToken string) (*TokenPair, error) {
+	return s.tokens.Refresh(refreshToken)
 }
@@ -41,5 +41,52 @@ func init() {
 				"model":  "goravel/app/models.User",
 			},
 		},
+
+		// Resetting Passwords
+		//
+		// The expire time is the number of minutes that each reset token will be
+		// considered valid. The reset URL is the page of the client application
+		// that receives the token and email as query parameters.
+		"passwords": map[string]any{
+			"expire":    config.Env("PASSWORD_RESET_EXPIRE", 60),
+			"reset_url": config.Env("PASSWORD_RESET_URL", config.Env("APP_URL", "http://localhost").(string)+"/reset-password"),
+		},
+
+		// Login Throttling
+		//
+		// Failed logins are counted per email and per IP address for decay_minutes.
+		// After delay_after failures on an email each further attempt must wait
+		// twice as long as the previous one, up to max_delay seconds. Reaching
+		// max_attempts locks the email for lockout_minutes; an IP address is
+		// locked after ip_max_attempts failures across all emails.
+		"throttle": map[string]any{
+			"delay_after":     config.Env("LOGIN_DELAY_AFTER", 3),
+			"max_delay":       config.Env("LOGIN_MAX_DELAY", 60),
+			"max_attempts":    config.Env("LOGIN_MAX_ATTEMPTS", 10),
+			"ip_max_attempts": config.Env("LOGIN_IP_MAX_ATTEMPTS", 50),
+			"decay_minutes":   config.Env("LOGIN_DECAY_MINUTES", 60),
+			"lockout_minutes": config.Env("LOGIN_LOCKOUT_MINUTES", 15),
+		},
+
+		// Email Verification
+		//
+		// The number of minutes a signed verification link stays valid.
+		"verification": map[string]any{
+			"expire": config.Env("EMAIL_VERIFICATION_EXPIRE", 1440),
+		},
+
+		// Two-Factor Authentication
+		//
+		// When required is on, admins and librarians must enroll in TOTP before
+		// they can use anything but their own account routes. A login challenge
+		// expires after challenge_expire minutes or challenge_attempts wrong
+		// codes. The issuer labels the account in authenticator apps and
+		// defaults to the application name.
+		"two_factor": map[string]any{
+			"required":           config.Env("TWO_FACTOR_REQUIRED", false),
+			"issuer":             config.Env("TWO_FACTOR_ISSUER", ""),
+			"challenge_expire":   config.Env("TWO_FACTOR_CHALLENGE_EXPIRE", 5),
+			"challenge_attempts": config.Env("TWO_FACTOR_CHALLENGE_ATTEMPTS", 5),
+		},
 	})
 }
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"github.com/goravel/framework/facades"
+)
+
+func init() {
+	config := facades.Config()
+	config.Add("fines", map[string]any{
+		// Fine accrual rules
+		//
+		// Amounts are in the smallest unit of the currency, e.g. cents. Late
+		// fees accrue per whole day a loan is kept past its due date, once the
+		// grace period is over.
+
+		// Currency the amounts are in, reported alongside balances.
+		"currency": config.Env("FINE_CURRENCY", "USD"),
+
+		// Charged per day late after the grace period.
+		"daily_rate": config.Env("FINE_DAILY_RATE", 25),
+
+		// Days a loan may be late before fees accrue.
+		"grace_days": config.Env("FINE_GRACE_DAYS", 1),
+
+		// Most a single loan can be charged in late fees. 0 means no cap.
+		"max_per_item": config.Env("FINE_MAX_PER_ITEM", 1000),
+
+		// Charged to replace a copy when its loan is marked lost.
+		"lost_charge": config.Env("FINE_LOST_CHARGE", 2500),
+
+		// Patrons owing more than this can't borrow. 0 means never blocked.
+		"block_threshold": config.Env("FINE_BLOCK_THRESHOLD", 1000),
+	})
+}
@@ -14,6 +14,20 @@ func init() {
 		// `go run . artisan jwt:secret`
 		"secret": config.Env("JWT_SECRET", ""),
 
+		// JWT signing algorithm
+		//
+		// The algorithm used for newly generated signing keys. Supported: "RS256",
+		// "EdDSA". Keys live in a key ring on the default storage disk and are
+		// created and rotated with `go run . artisan jwt:rotate-keys`. Until a key
+		// ring exists, tokens are signed with HS256 and the secret above; once it
+		// does, tokens signed with the secret are rejected and users sign in again.
+		"algorithm": config.Env("JWT_ALGORITHM", "RS256"),
+
+		// JWT key ring location
+		//
+		// Path of the key ring file on the default storage disk.
+		"keys_path": config.Env("JWT_KEYS_PATH", "jwt/keys.json"),
+
 		// JWT time to live
 		//
 		// Specify the length of time (in minutes) that the token will be valid for.
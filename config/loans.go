@@ -0,0 +1,31 @@
+package config
+
+import (
+	"github.com/goravel/framework/facades"
+)
+
+func init() {
+	config := facades.Config()
+	config.Add("loans", map[string]any{
+		// Default loan policy
+		//
+		// The terms used when no row in loan_policies matches the patron's role
+		// or the book's categories. Policies are managed through
+		// /api/loan-policies.
+
+		// Days a book may be kept before it is due.
+		"loan_days": config.Env("LOAN_DAYS", 14),
+
+		// Books a patron may have on loan at once. 0 means no limit.
+		"max_loans": config.Env("LOAN_MAX_LOANS", 5),
+
+		// Times a loan may be renewed.
+		"max_renewals": config.Env("LOAN_MAX_RENEWALS", 2),
+
+		// Hold pickup window
+		//
+		// Days a copy set aside for a hold waits for the patron before the hold
+		// expires and the copy moves on to the next hold in the queue.
+		"pickup_days": config.Env("LOAN_PICKUP_DAYS", 3),
+	})
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"github.com/goravel/framework/facades"
+)
+
+func init() {
+	config := facades.Config()
+	config.Add("search", map[string]any{
+		// Catalog search driver
+		//
+		// "database" uses the MySQL full-text index and falls back to
+		// "memory" on connections without one, such as SQLite. "memory"
+		// keeps an inverted index inside the application process, built from
+		// the books table on first use; each process has its own copy.
+		//
+		// After changing the driver or upgrading, rebuild the search columns
+		// with `go run . artisan books:search-reindex`.
+		"driver": config.Env("SEARCH_DRIVER", "database"),
+	})
+}
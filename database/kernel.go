@@ -17,6 +17,24 @@ func (kernel Kernel) Migrations() []schema.Migration {
 		&migrations.M20210101000002CreateJobsTable{},
 		&migrations.M20250814025201CreateBooksTable{},
 		&migrations.M20250814032821CreateBorrowingsTable{},
+		&migrations.M20261018000001UpdateUsersRoleColumn{},
+		&migrations.M20261018000002CreateRevokedTokensTable{},
+		&migrations.M20261018000003CreateRefreshTokensTable{},
+		&migrations.M20261018000004CreatePasswordResetsTable{},
+		&migrations.M20261018000005AddEmailVerifiedAtToUsersTable{},
+		&migrations.M20261018000006AddTwoFactorColumnsToUsersTable{},
+		&migrations.M20261018000007AddSearchColumnsToBooksTable{},
+		&migrations.M20261018000008AddIsbnColumnsToBooksTable{},
+		&migrations.M20261018000009CreateBookCopiesTable{},
+		&migrations.M20261018000010CreateAuthorsTables{},
+		&migrations.M20261018000011CreateCategoriesAndTagsTables{},
+		&migrations.M20261018000012AddCoverToBooksTable{},
+		&migrations.M20261018000013CreateLoanPoliciesTable{},
+		&migrations.M20261018000014CreateBorrowingEventsTable{},
+		&migrations.M20261018000015CreateReservationsTable{},
+		&migrations.M20261018000016AddOverdueAndLostToBorrowingsStatus{},
+		&migrations.M20261018000017CreateFineEntriesTable{},
+		&migrations.M20261018000018AddFeedTokenToUsersTable{},
 	}
 }
 
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000001UpdateUsersRoleColumn struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000001UpdateUsersRoleColumn) Signature() string {
+	return "20261018000001_update_users_role_column"
+}
+
+// Up Run the migrations.
+func (r *M20261018000001UpdateUsersRoleColumn) Up() error {
+	if err := facades.Schema().Table("users", func(table schema.Blueprint) {
+		table.String("role", 20).Default("member").Change()
+	}); err != nil {
+		return err
+	}
+
+	_, err := facades.Orm().Query().Table("users").Where("role", "user").Update("role", "member")
+	return err
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000001UpdateUsersRoleColumn) Down() error {
+	if _, err := facades.Orm().Query().Table("users").
+		WhereIn("role", []any{"librarian", "member"}).
+		Update("role", "user"); err != nil {
+		return err
+	}
+
+	return facades.Schema().Table("users", func(table schema.Blueprint) {
+		table.Enum("role", []any{"admin", "user"}).Change()
+	})
+}
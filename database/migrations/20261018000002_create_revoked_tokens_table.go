@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000002CreateRevokedTokensTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000002CreateRevokedTokensTable) Signature() string {
+	return "20261018000002_create_revoked_tokens_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000002CreateRevokedTokensTable) Up() error {
+	if !facades.Schema().HasTable("revoked_tokens") {
+		return facades.Schema().Create("revoked_tokens", func(table schema.Blueprint) {
+			table.ID()
+			table.String("jti", 64)
+			table.UnsignedBigInteger("user_id")
+			table.Foreign("user_id").References("id").On("users").CascadeOnUpdate().CascadeOnDelete()
+			table.DateTimeTz("expires_at")
+			table.TimestampsTz()
+
+			table.Unique("jti")
+			table.Index("expires_at")
+		})
+	}
+
+	return nil
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000002CreateRevokedTokensTable) Down() error {
+	return facades.Schema().DropIfExists("revoked_tokens")
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000003CreateRefreshTokensTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000003CreateRefreshTokensTable) Signature() string {
+	return "20261018000003_create_refresh_tokens_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000003CreateRefreshTokensTable) Up() error {
+	if !facades.Schema().HasTable("refresh_tokens") {
+		return facades.Schema().Create("refresh_tokens", func(table schema.Blueprint) {
+			table.ID()
+			table.UnsignedBigInteger("user_id")
+			table.Foreign("user_id").References("id").On("users").CascadeOnUpdate().CascadeOnDelete()
+			table.String("family_id", 36)
+			table.String("token_hash", 64)
+			table.DateTimeTz("expires_at").Nullable()
+			table.DateTimeTz("revoked_at").Nullable()
+			table.TimestampsTz()
+
+			table.Unique("token_hash")
+			table.Index("family_id")
+		})
+	}
+
+	return nil
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000003CreateRefreshTokensTable) Down() error {
+	return facades.Schema().DropIfExists("refresh_tokens")
+}
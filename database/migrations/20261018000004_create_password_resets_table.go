@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000004CreatePasswordResetsTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000004CreatePasswordResetsTable) Signature() string {
+	return "20261018000004_create_password_resets_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000004CreatePasswordResetsTable) Up() error {
+	if !facades.Schema().HasTable("password_resets") {
+		if err := facades.Schema().Create("password_resets", func(table schema.Blueprint) {
+			table.ID()
+			table.UnsignedBigInteger("user_id")
+			table.Foreign("user_id").References("id").On("users").CascadeOnUpdate().CascadeOnDelete()
+			table.String("token_hash", 64)
+			table.DateTimeTz("expires_at")
+			table.DateTimeTz("used_at").Nullable()
+			table.TimestampsTz()
+
+			table.Unique("token_hash")
+		}); err != nil {
+			return err
+		}
+	}
+
+	if !facades.Schema().HasColumn("users", "tokens_revoked_at") {
+		return facades.Schema().Table("users", func(table schema.Blueprint) {
+			table.DateTimeTz("tokens_revoked_at").Nullable()
+		})
+	}
+
+	return nil
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000004CreatePasswordResetsTable) Down() error {
+	if err := facades.Schema().DropColumns("users", []string{"tokens_revoked_at"}); err != nil {
+		return err
+	}
+
+	return facades.Schema().DropIfExists("password_resets")
+}
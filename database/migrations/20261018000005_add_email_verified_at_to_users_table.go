@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000005AddEmailVerifiedAtToUsersTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000005AddEmailVerifiedAtToUsersTable) Signature() string {
+	return "20261018000005_add_email_verified_at_to_users_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000005AddEmailVerifiedAtToUsersTable) Up() error {
+	if facades.Schema().HasColumn("users", "email_verified_at") {
+		return nil
+	}
+
+	if err := facades.Schema().Table("users", func(table schema.Blueprint) {
+		table.DateTimeTz("email_verified_at").Nullable()
+	}); err != nil {
+		return err
+	}
+
+	// Accounts created before verification existed keep borrowing as before.
+	_, err := facades.Orm().Query().Exec("UPDATE users SET email_verified_at = created_at")
+	return err
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000005AddEmailVerifiedAtToUsersTable) Down() error {
+	return facades.Schema().DropColumns("users", []string{"email_verified_at"})
+}
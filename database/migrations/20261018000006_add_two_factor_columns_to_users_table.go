@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000006AddTwoFactorColumnsToUsersTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000006AddTwoFactorColumnsToUsersTable) Signature() string {
+	return "20261018000006_add_two_factor_columns_to_users_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000006AddTwoFactorColumnsToUsersTable) Up() error {
+	if facades.Schema().HasColumn("users", "two_factor_secret") {
+		return nil
+	}
+
+	return facades.Schema().Table("users", func(table schema.Blueprint) {
+		table.Text("two_factor_secret").Nullable()
+		table.Text("two_factor_recovery_codes").Nullable()
+		table.DateTimeTz("two_factor_confirmed_at").Nullable()
+	})
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000006AddTwoFactorColumnsToUsersTable) Down() error {
+	return facades.Schema().DropColumns("users", []string{
+		"two_factor_secret",
+		"two_factor_recovery_codes",
+		"two_factor_confirmed_at",
+	})
+}
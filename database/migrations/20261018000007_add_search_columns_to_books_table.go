@@ -0,0 +1,69 @@
+package migrations
+
+import (
+	"strings"
+
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+
+	"goravel/app/helpers"
+)
+
+type M20261018000007AddSearchColumnsToBooksTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000007AddSearchColumnsToBooksTable) Signature() string {
+	return "20261018000007_add_search_columns_to_books_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000007AddSearchColumnsToBooksTable) Up() error {
+	if facades.Schema().HasColumn("books", "search_title") {
+		return nil
+	}
+
+	if err := facades.Schema().Table("books", func(table schema.Blueprint) {
+		table.String("search_title", 1024).Default("")
+		table.String("search_author", 1024).Default("")
+		table.FullText("search_title", "search_author").Name("books_search_fulltext")
+	}); err != nil {
+		return err
+	}
+
+	return r.fillSearchColumns()
+}
+
+// fillSearchColumns computes the search columns of the books already in the
+// catalog, the same way `artisan books:search-reindex` does.
+func (r *M20261018000007AddSearchColumnsToBooksTable) fillSearchColumns() error {
+	var books []struct {
+		ID     uint
+		Title  string
+		Author string
+	}
+	if err := facades.Orm().Query().Table("books").Select("id", "title", "author").Get(&books); err != nil {
+		return err
+	}
+
+	for _, book := range books {
+		if _, err := facades.Orm().Query().Table("books").Where("id", book.ID).Update(map[string]any{
+			"search_title":  strings.Join(helpers.IndexTokens(book.Title), " "),
+			"search_author": strings.Join(helpers.IndexTokens(book.Author), " "),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000007AddSearchColumnsToBooksTable) Down() error {
+	if err := facades.Schema().Table("books", func(table schema.Blueprint) {
+		table.DropFullTextByName("books_search_fulltext")
+	}); err != nil {
+		return err
+	}
+
+	return facades.Schema().DropColumns("books", []string{"search_title", "search_author"})
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000008AddIsbnColumnsToBooksTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000008AddIsbnColumnsToBooksTable) Signature() string {
+	return "20261018000008_add_isbn_columns_to_books_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000008AddIsbnColumnsToBooksTable) Up() error {
+	if facades.Schema().HasColumn("books", "isbn13") {
+		return nil
+	}
+
+	// Unique indexes allow any number of NULLs, so books without an ISBN are
+	// unaffected.
+	return facades.Schema().Table("books", func(table schema.Blueprint) {
+		table.String("isbn10", 10).Nullable()
+		table.String("isbn13", 13).Nullable()
+		table.Unique("isbn10").Name("books_isbn10_unique")
+		table.Unique("isbn13").Name("books_isbn13_unique")
+	})
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000008AddIsbnColumnsToBooksTable) Down() error {
+	if err := facades.Schema().Table("books", func(table schema.Blueprint) {
+		table.DropUniqueByName("books_isbn10_unique")
+		table.DropUniqueByName("books_isbn13_unique")
+	}); err != nil {
+		return err
+	}
+
+	return facades.Schema().DropColumns("books", []string{"isbn10", "isbn13"})
+}
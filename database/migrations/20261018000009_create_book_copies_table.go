@@ -0,0 +1,117 @@
+package migrations
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000009CreateBookCopiesTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000009CreateBookCopiesTable) Signature() string {
+	return "20261018000009_create_book_copies_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000009CreateBookCopiesTable) Up() error {
+	if facades.Schema().HasTable("book_copies") {
+		return nil
+	}
+
+	if err := facades.Schema().Create("book_copies", func(table schema.Blueprint) {
+		table.ID()
+		table.UnsignedBigInteger("book_id")
+		table.Foreign("book_id").References("id").On("books").CascadeOnUpdate().CascadeOnDelete()
+		table.String("barcode", 64)
+		table.String("shelf_location", 100).Default("")
+		table.String("condition", 20).Default("good")
+		table.String("status", 20).Default("available")
+		table.TimestampsTz()
+
+		table.Unique("barcode")
+		table.Index("book_id", "status")
+	}); err != nil {
+		return err
+	}
+
+	if err := facades.Schema().Table("borrowings", func(table schema.Blueprint) {
+		table.UnsignedBigInteger("book_copy_id").Nullable()
+		table.Foreign("book_copy_id").References("id").On("book_copies").NullOnDelete()
+	}); err != nil {
+		return err
+	}
+
+	return r.createCopiesForExistingStock()
+}
+
+// createCopiesForExistingStock gives every book one available copy per unit
+// of stock and one copy on loan per open borrowing, which it is linked to.
+// Barcodes are placeholders of the form <book id>-<sequence> until real labels
+// are scanned in.
+func (r *M20261018000009CreateBookCopiesTable) createCopiesForExistingStock() error {
+	var books []struct {
+		ID    uint
+		Stock int
+	}
+	if err := facades.Orm().Query().Table("books").Select("id", "stock").Get(&books); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, book := range books {
+		var loans []uint
+		if err := facades.Orm().Query().Table("borrowings").
+			Where("book_id", book.ID).
+			Where("status", "borrowed").
+			Pluck("id", &loans); err != nil {
+			return err
+		}
+
+		for n := 1; n <= len(loans)+max(book.Stock, 0); n++ {
+			status := "available"
+			if n <= len(loans) {
+				status = "on_loan"
+			}
+			barcode := fmt.Sprintf("%06d-%03d", book.ID, n)
+			if err := facades.Orm().Query().Table("book_copies").Create(map[string]any{
+				"book_id":    book.ID,
+				"barcode":    barcode,
+				"status":     status,
+				"created_at": now,
+				"updated_at": now,
+			}); err != nil {
+				return err
+			}
+			if status != "on_loan" {
+				continue
+			}
+
+			var copyIDs []uint
+			if err := facades.Orm().Query().Table("book_copies").Where("barcode", barcode).Pluck("id", &copyIDs); err != nil {
+				return err
+			}
+			if _, err := facades.Orm().Query().Table("borrowings").
+				Where("id", loans[n-1]).
+				Update("book_copy_id", copyIDs[0]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000009CreateBookCopiesTable) Down() error {
+	if err := facades.Schema().Table("borrowings", func(table schema.Blueprint) {
+		table.DropForeign("book_copy_id")
+		table.DropColumn("book_copy_id")
+	}); err != nil {
+		return err
+	}
+
+	return facades.Schema().DropIfExists("book_copies")
+}
@@ -0,0 +1,129 @@
+package migrations
+
+import (
+	"strings"
+	"time"
+
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+
+	"goravel/app/helpers"
+)
+
+type M20261018000010CreateAuthorsTables struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000010CreateAuthorsTables) Signature() string {
+	return "20261018000010_create_authors_tables"
+}
+
+// Up Run the migrations.
+func (r *M20261018000010CreateAuthorsTables) Up() error {
+	if facades.Schema().HasTable("authors") {
+		return nil
+	}
+
+	if err := facades.Schema().Create("authors", func(table schema.Blueprint) {
+		table.ID()
+		table.String("name", 255)
+		table.Text("biography").Nullable()
+		table.Integer("birth_year").Nullable()
+		table.Integer("death_year").Nullable()
+		table.String("nationality", 100).Default("")
+		table.String("website", 255).Default("")
+		table.TimestampsTz()
+
+		table.Index("name")
+	}); err != nil {
+		return err
+	}
+
+	if err := facades.Schema().Create("book_authors", func(table schema.Blueprint) {
+		table.ID()
+		table.UnsignedBigInteger("book_id")
+		table.Foreign("book_id").References("id").On("books").CascadeOnUpdate().CascadeOnDelete()
+		table.UnsignedBigInteger("author_id")
+		table.Foreign("author_id").References("id").On("authors").CascadeOnUpdate()
+		table.String("role", 20).Default("author")
+		table.Integer("position").Default(1)
+		table.TimestampsTz()
+
+		table.Unique("book_id", "author_id", "role")
+		table.Index("author_id")
+	}); err != nil {
+		return err
+	}
+
+	// The credit line is rebuilt from full names, which can outgrow the
+	// original 100 characters.
+	if err := facades.Schema().Table("books", func(table schema.Blueprint) {
+		table.String("author", 255).Change()
+	}); err != nil {
+		return err
+	}
+
+	return r.splitExistingAuthors()
+}
+
+// authorRow is the authors table as this migration created it.
+type authorRow struct {
+	ID        uint
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (authorRow) TableName() string {
+	return "authors"
+}
+
+// splitExistingAuthors turns every book's author string into author rows,
+// one per name, linked in the order they were written. Names that differ
+// only in case share one author.
+func (r *M20261018000010CreateAuthorsTables) splitExistingAuthors() error {
+	var books []struct {
+		ID     uint
+		Author string
+	}
+	if err := facades.Orm().Query().Table("books").Select("id", "author").Get(&books); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	authorIDs := map[string]uint{}
+	for _, book := range books {
+		for position, name := range helpers.SplitAuthorNames(book.Author) {
+			key := strings.ToLower(name)
+			if _, ok := authorIDs[key]; !ok {
+				author := authorRow{Name: name, CreatedAt: now, UpdatedAt: now}
+				if err := facades.Orm().Query().Create(&author); err != nil {
+					return err
+				}
+				authorIDs[key] = author.ID
+			}
+
+			if err := facades.Orm().Query().Table("book_authors").Create(map[string]any{
+				"book_id":    book.ID,
+				"author_id":  authorIDs[key],
+				"role":       "author",
+				"position":   position + 1,
+				"created_at": now,
+				"updated_at": now,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Down Reverse the migrations. books.author keeps its wider column, since
+// credit lines written since may not fit the old one.
+func (r *M20261018000010CreateAuthorsTables) Down() error {
+	if err := facades.Schema().DropIfExists("book_authors"); err != nil {
+		return err
+	}
+
+	return facades.Schema().DropIfExists("authors")
+}
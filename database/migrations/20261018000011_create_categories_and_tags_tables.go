@@ -0,0 +1,83 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000011CreateCategoriesAndTagsTables struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000011CreateCategoriesAndTagsTables) Signature() string {
+	return "20261018000011_create_categories_and_tags_tables"
+}
+
+// Up Run the migrations.
+func (r *M20261018000011CreateCategoriesAndTagsTables) Up() error {
+	if facades.Schema().HasTable("categories") {
+		return nil
+	}
+
+	if err := facades.Schema().Create("categories", func(table schema.Blueprint) {
+		table.ID()
+		table.UnsignedBigInteger("parent_id").Nullable()
+		table.Foreign("parent_id").References("id").On("categories").CascadeOnDelete()
+		table.String("name", 255)
+		table.String("slug", 255)
+		table.String("code", 32).Default("")
+		table.String("path", 1024)
+		table.TimestampsTz()
+
+		table.Unique("slug")
+		table.Index("path")
+	}); err != nil {
+		return err
+	}
+
+	if err := facades.Schema().Create("tags", func(table schema.Blueprint) {
+		table.ID()
+		table.String("name", 100)
+		table.String("slug", 100)
+		table.TimestampsTz()
+
+		table.Unique("slug")
+	}); err != nil {
+		return err
+	}
+
+	if err := facades.Schema().Create("book_categories", func(table schema.Blueprint) {
+		table.ID()
+		table.UnsignedBigInteger("book_id")
+		table.Foreign("book_id").References("id").On("books").CascadeOnUpdate().CascadeOnDelete()
+		table.UnsignedBigInteger("category_id")
+		table.Foreign("category_id").References("id").On("categories").CascadeOnUpdate().CascadeOnDelete()
+		table.TimestampsTz()
+
+		table.Unique("book_id", "category_id")
+		table.Index("category_id")
+	}); err != nil {
+		return err
+	}
+
+	return facades.Schema().Create("book_tags", func(table schema.Blueprint) {
+		table.ID()
+		table.UnsignedBigInteger("book_id")
+		table.Foreign("book_id").References("id").On("books").CascadeOnUpdate().CascadeOnDelete()
+		table.UnsignedBigInteger("tag_id")
+		table.Foreign("tag_id").References("id").On("tags").CascadeOnUpdate().CascadeOnDelete()
+		table.TimestampsTz()
+
+		table.Unique("book_id", "tag_id")
+		table.Index("tag_id")
+	})
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000011CreateCategoriesAndTagsTables) Down() error {
+	for _, table := range []string{"book_tags", "book_categories", "tags", "categories"} {
+		if err := facades.Schema().DropIfExists(table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000012AddCoverToBooksTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000012AddCoverToBooksTable) Signature() string {
+	return "20261018000012_add_cover_to_books_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000012AddCoverToBooksTable) Up() error {
+	if facades.Schema().HasColumn("books", "cover") {
+		return nil
+	}
+
+	return facades.Schema().Table("books", func(table schema.Blueprint) {
+		table.String("cover").Default("")
+	})
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000012AddCoverToBooksTable) Down() error {
+	return facades.Schema().DropColumns("books", []string{"cover"})
+}
@@ -0,0 +1,91 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000013CreateLoanPoliciesTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000013CreateLoanPoliciesTable) Signature() string {
+	return "20261018000013_create_loan_policies_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000013CreateLoanPoliciesTable) Up() error {
+	if facades.Schema().HasTable("loan_policies") {
+		return nil
+	}
+
+	if err := facades.Schema().Create("loan_policies", func(table schema.Blueprint) {
+		table.ID()
+		table.String("role", 20).Default("")
+		table.UnsignedBigInteger("category_id").Nullable()
+		table.Foreign("category_id").References("id").On("categories").CascadeOnDelete()
+		table.UnsignedInteger("loan_days")
+		table.UnsignedInteger("max_loans").Default(0)
+		table.UnsignedInteger("max_renewals").Default(0)
+		table.TimestampsTz()
+
+		table.Index("role", "category_id")
+	}); err != nil {
+		return err
+	}
+
+	if err := facades.Schema().Table("borrowings", func(table schema.Blueprint) {
+		table.TimestampTz("due_at").Nullable()
+		table.Index("user_id", "status")
+	}); err != nil {
+		return err
+	}
+
+	return r.setDueDatesOfOpenLoans()
+}
+
+// setDueDatesOfOpenLoans gives loans made before due dates existed the
+// default loan period, counted from the day they were borrowed.
+func (r *M20261018000013CreateLoanPoliciesTable) setDueDatesOfOpenLoans() error {
+	var loans []struct {
+		ID         uint
+		BorrowDate string
+	}
+	if err := facades.Orm().Query().Table("borrowings").
+		Select("id", "borrow_date").
+		Where("status", "borrowed").
+		Get(&loans); err != nil {
+		return err
+	}
+
+	days := facades.Config().GetInt("loans.loan_days", 14)
+	for _, loan := range loans {
+		if len(loan.BorrowDate) < len(time.DateOnly) {
+			continue
+		}
+		borrowed, err := time.ParseInLocation(time.DateOnly, loan.BorrowDate[:len(time.DateOnly)], time.Local)
+		if err != nil {
+			continue
+		}
+		if _, err := facades.Orm().Query().Table("borrowings").
+			Where("id", loan.ID).
+			Update("due_at", borrowed.AddDate(0, 0, days)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000013CreateLoanPoliciesTable) Down() error {
+	if err := facades.Schema().Table("borrowings", func(table schema.Blueprint) {
+		table.DropIndex("user_id", "status")
+		table.DropColumn("due_at")
+	}); err != nil {
+		return err
+	}
+
+	return facades.Schema().DropIfExists("loan_policies")
+}
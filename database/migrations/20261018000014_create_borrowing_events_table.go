@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000014CreateBorrowingEventsTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000014CreateBorrowingEventsTable) Signature() string {
+	return "20261018000014_create_borrowing_events_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000014CreateBorrowingEventsTable) Up() error {
+	if facades.Schema().HasTable("borrowing_events") {
+		return nil
+	}
+
+	if err := facades.Schema().Create("borrowing_events", func(table schema.Blueprint) {
+		table.ID()
+		table.UnsignedBigInteger("borrowing_id")
+		table.Foreign("borrowing_id").References("id").On("borrowings").CascadeOnUpdate().CascadeOnDelete()
+		table.String("event", 20)
+		table.TimestampTz("previous_due_at").Nullable()
+		table.TimestampTz("due_at").Nullable()
+		table.TimestampsTz()
+
+		table.Index("borrowing_id", "id")
+	}); err != nil {
+		return err
+	}
+
+	return facades.Schema().Table("borrowings", func(table schema.Blueprint) {
+		table.UnsignedInteger("renewals").Default(0)
+	})
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000014CreateBorrowingEventsTable) Down() error {
+	if err := facades.Schema().DropColumns("borrowings", []string{"renewals"}); err != nil {
+		return err
+	}
+
+	return facades.Schema().DropIfExists("borrowing_events")
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000015CreateReservationsTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000015CreateReservationsTable) Signature() string {
+	return "20261018000015_create_reservations_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000015CreateReservationsTable) Up() error {
+	if facades.Schema().HasTable("reservations") {
+		return nil
+	}
+
+	return facades.Schema().Create("reservations", func(table schema.Blueprint) {
+		table.ID()
+		table.UnsignedBigInteger("user_id")
+		table.Foreign("user_id").References("id").On("users").CascadeOnUpdate().CascadeOnDelete()
+		table.UnsignedBigInteger("book_id")
+		table.Foreign("book_id").References("id").On("books").CascadeOnUpdate().CascadeOnDelete()
+		table.UnsignedBigInteger("book_copy_id").Nullable()
+		table.Foreign("book_copy_id").References("id").On("book_copies").NullOnDelete()
+		table.String("status", 20).Default("waiting")
+		table.TimestampTz("ready_at").Nullable()
+		table.TimestampTz("expires_at").Nullable()
+		table.TimestampsTz()
+
+		table.Index("book_id", "status")
+		table.Index("user_id", "status")
+		table.Index("status", "expires_at")
+	})
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000015CreateReservationsTable) Down() error {
+	return facades.Schema().DropIfExists("reservations")
+}
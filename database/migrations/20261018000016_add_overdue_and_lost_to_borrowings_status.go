@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000016AddOverdueAndLostToBorrowingsStatus struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000016AddOverdueAndLostToBorrowingsStatus) Signature() string {
+	return "20261018000016_add_overdue_and_lost_to_borrowings_status"
+}
+
+// Up Run the migrations.
+func (r *M20261018000016AddOverdueAndLostToBorrowingsStatus) Up() error {
+	return facades.Schema().Table("borrowings", func(table schema.Blueprint) {
+		table.Enum("status", []any{"borrowed", "overdue", "returned", "lost"}).Default("borrowed").Change()
+	})
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000016AddOverdueAndLostToBorrowingsStatus) Down() error {
+	// Overdue and lost loans are still out as far as the old statuses go.
+	if _, err := facades.Orm().Query().Table("borrowings").
+		WhereIn("status", []any{"overdue", "lost"}).
+		Update("status", "borrowed"); err != nil {
+		return err
+	}
+
+	return facades.Schema().Table("borrowings", func(table schema.Blueprint) {
+		table.Enum("status", []any{"borrowed", "returned"}).Change()
+	})
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000017CreateFineEntriesTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000017CreateFineEntriesTable) Signature() string {
+	return "20261018000017_create_fine_entries_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000017CreateFineEntriesTable) Up() error {
+	if facades.Schema().HasTable("fine_entries") {
+		return nil
+	}
+
+	return facades.Schema().Create("fine_entries", func(table schema.Blueprint) {
+		table.ID()
+		table.UnsignedBigInteger("user_id")
+		table.Foreign("user_id").References("id").On("users").CascadeOnUpdate().CascadeOnDelete()
+		table.UnsignedBigInteger("borrowing_id")
+		table.Foreign("borrowing_id").References("id").On("borrowings").CascadeOnUpdate().CascadeOnDelete()
+		table.String("type", 20)
+		table.String("reason", 20).Default("")
+		table.BigInteger("amount")
+		table.String("note", 255).Default("")
+		table.UnsignedBigInteger("recorded_by").Nullable()
+		table.Foreign("recorded_by").References("id").On("users").NullOnDelete()
+		table.TimestampsTz()
+
+		table.Index("user_id")
+		table.Index("borrowing_id", "type")
+	})
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000017CreateFineEntriesTable) Down() error {
+	return facades.Schema().DropIfExists("fine_entries")
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/goravel/framework/contracts/database/schema"
+	"github.com/goravel/framework/facades"
+)
+
+type M20261018000018AddFeedTokenToUsersTable struct{}
+
+// Signature The unique signature for the migration.
+func (r *M20261018000018AddFeedTokenToUsersTable) Signature() string {
+	return "20261018000018_add_feed_token_to_users_table"
+}
+
+// Up Run the migrations.
+func (r *M20261018000018AddFeedTokenToUsersTable) Up() error {
+	if facades.Schema().HasColumn("users", "feed_token_hash") {
+		return nil
+	}
+
+	return facades.Schema().Table("users", func(table schema.Blueprint) {
+		table.String("feed_token_hash", 64).Nullable()
+		table.Unique("feed_token_hash")
+	})
+}
+
+// Down Reverse the migrations.
+func (r *M20261018000018AddFeedTokenToUsersTable) Down() error {
+	return facades.Schema().DropColumns("users", []string{"feed_token_hash"})
+}
@@ -3,33 +3,125 @@ package routes
 import (
 	"github.com/goravel/framework/contracts/route"
 	"github.com/goravel/framework/facades"
+	httpmiddleware "github.com/goravel/framework/http/middleware"
 
 	"goravel/app/http/controllers"
 	"goravel/app/http/middleware"
+	"goravel/app/models"
 )
 
 func Api() {
 	userController := controllers.NewUserController()
+	borrowingController := controllers.NewBorrowingController()
+	passwordController := controllers.NewPasswordController()
+	verificationController := controllers.NewEmailVerificationController()
+	twoFactorController := controllers.NewTwoFactorController()
+	bookCopyController := controllers.NewBookCopyController()
+	bookCoverController := controllers.NewBookCoverController()
+	loanPolicyController := controllers.NewLoanPolicyController()
+	reservationController := controllers.NewReservationController()
+	fineController := controllers.NewFineController()
+	authorController := controllers.NewAuthorController()
+	categoryController := controllers.NewCategoryController()
+	tagController := controllers.NewTagController()
+	feedTokenController := controllers.NewFeedTokenController()
 
 	// Public routes
 	facades.Route().Prefix("/api").Group(func(r route.Router) {
 		r.Post("/login", userController.Login)
 		r.Post("/register", userController.Register)
+		r.Post("/refresh", userController.Refresh)
+		r.Post("/2fa/verify", userController.VerifyTwoFactor)
+		r.Middleware(httpmiddleware.Throttle("password-reset")).Post("/password/forgot", passwordController.Forgot)
+		r.Post("/password/reset", passwordController.Reset)
+		r.Get("/email/verify/{id}", verificationController.Verify)
 	})
 
-	// Protected routes
+	// Account routes, reachable before two-factor enrollment
 	facades.Route().Prefix("/api").Middleware(middleware.Auth()).Group(func(r route.Router) {
 		r.Post("/logout", userController.Logout)
-		
-		r.Get("/users", userController.Index)
-		r.Get("/users/{id}", userController.Show)
-		r.Post("/users/{id}", userController.Update)
-		r.Delete("/users/{id}", userController.Destroy)
+		r.Get("/me", userController.Me)
+		r.Post("/me", userController.UpdateMe)
+		r.Middleware(httpmiddleware.Throttle("verification")).Post("/email/resend", verificationController.Resend)
+		r.Post("/2fa/enroll", twoFactorController.Enroll)
+		r.Post("/2fa/confirm", twoFactorController.Confirm)
+		r.Post("/2fa/disable", twoFactorController.Disable)
+	})
+
+	// Protected routes
+	facades.Route().Prefix("/api").Middleware(middleware.Auth(), middleware.RequireTwoFactor()).Group(func(r route.Router) {
+		r.Middleware(middleware.RequirePermission("users.view")).Get("/users", userController.Index)
+		r.Middleware(middleware.RequireSelfOrPermission("id", "users.view")).Get("/users/{id}", userController.Show)
+		r.Middleware(middleware.RequireSelfOrPermission("id", "users.manage")).Post("/users/{id}", userController.Update)
+		r.Post("/me/feed-token", feedTokenController.Store)
+		r.Delete("/me/feed-token", feedTokenController.Destroy)
+		r.Middleware(middleware.RequirePermission("users.manage")).Delete("/users/{id}", userController.Destroy)
 
 		r.Get("/books", controllers.NewBookController().Index)
-		r.Post("/books", controllers.NewBookController().Store)
+		r.Get("/books/search", controllers.NewBookController().Search)
+		r.Get("/books/isbn/{isbn}", controllers.NewBookController().ShowByISBN)
+		r.Get("/books/export", controllers.NewBookController().Export)
+		r.Middleware(middleware.RequirePermission("books.manage")).Post("/books/import", controllers.NewBookController().Import)
+		r.Middleware(middleware.RequirePermission("books.manage")).Post("/books", controllers.NewBookController().Store)
 		r.Get("/books/{id}", controllers.NewBookController().Show)
-		r.Post("/books/{id}", controllers.NewBookController().Update)
-		r.Delete("/books/{id}", controllers.NewBookController().Destroy)
+		r.Middleware(middleware.RequirePermission("books.manage")).Post("/books/{id}", controllers.NewBookController().Update)
+		r.Middleware(middleware.RequirePermission("books.manage")).Delete("/books/{id}", controllers.NewBookController().Destroy)
+
+		r.Middleware(middleware.RequirePermission("books.manage")).Post("/books/{id}/cover", bookCoverController.Store)
+		r.Middleware(middleware.RequirePermission("books.manage")).Delete("/books/{id}/cover", bookCoverController.Destroy)
+
+		r.Get("/books/{id}/copies", bookCopyController.Index)
+		r.Middleware(middleware.RequirePermission("books.manage")).Post("/books/{id}/copies", bookCopyController.Store)
+		r.Get("/books/{id}/copies/{copy_id}", bookCopyController.Show)
+		r.Middleware(middleware.RequirePermission("books.manage")).Post("/books/{id}/copies/{copy_id}", bookCopyController.Update)
+		r.Middleware(middleware.RequirePermission("books.manage")).Delete("/books/{id}/copies/{copy_id}", bookCopyController.Destroy)
+
+		r.Get("/authors", authorController.Index)
+		r.Middleware(middleware.RequirePermission("books.manage")).Post("/authors", authorController.Store)
+		r.Get("/authors/{id}", authorController.Show)
+		r.Get("/authors/{id}/books", authorController.Books)
+		r.Middleware(middleware.RequirePermission("books.manage")).Post("/authors/{id}", authorController.Update)
+		r.Middleware(middleware.RequirePermission("books.manage")).Delete("/authors/{id}", authorController.Destroy)
+
+		r.Get("/categories", categoryController.Index)
+		r.Middleware(middleware.RequirePermission("books.manage")).Post("/categories", categoryController.Store)
+		r.Get("/categories/{id}", categoryController.Show)
+		r.Middleware(middleware.RequirePermission("books.manage")).Post("/categories/{id}", categoryController.Update)
+		r.Middleware(middleware.RequirePermission("books.manage")).Delete("/categories/{id}", categoryController.Destroy)
+
+		r.Get("/tags", tagController.Index)
+		r.Middleware(middleware.RequirePermission("books.manage")).Post("/tags/{id}", tagController.Update)
+		r.Middleware(middleware.RequirePermission("books.manage")).Delete("/tags/{id}", tagController.Destroy)
+
+		r.Get("/loan-policies", loanPolicyController.Index)
+		r.Middleware(middleware.RequirePermission("borrowings.manage")).Post("/loan-policies", loanPolicyController.Store)
+		r.Middleware(middleware.RequirePermission("borrowings.manage")).Post("/loan-policies/{id}", loanPolicyController.Update)
+		r.Middleware(middleware.RequirePermission("borrowings.manage")).Delete("/loan-policies/{id}", loanPolicyController.Destroy)
+
+		r.Middleware(middleware.RequirePermission("borrowings.manage")).Get("/borrowings", borrowingController.Index)
+		r.Get("/borrowings/me", borrowingController.Mine)
+		r.Middleware(middleware.RequireVerifiedEmail()).Post("/borrowings/borrow", borrowingController.Borrow)
+		r.Middleware(middleware.RequireVerifiedEmail()).Post("/borrowings/return", borrowingController.Return)
+		r.Middleware(middleware.RequireVerifiedEmail()).Post("/borrowings/{id}/renew", borrowingController.Renew)
+		r.Middleware(middleware.RequireSelfOrPermission("user_id", "borrowings.manage")).Get("/borrowings/user/{user_id}", borrowingController.FindByUserID)
+
+		r.Middleware(middleware.RequireSelfOrPermission("id", "borrowings.manage")).Get("/users/{id}/fines", fineController.Balance)
+		r.Middleware(middleware.RequireSelfOrPermission("id", "borrowings.manage")).Get("/users/{id}/fines/statement", fineController.Statement)
+		r.Middleware(middleware.RequirePermission("borrowings.manage")).Post("/borrowings/{id}/fines", fineController.Store)
+
+		r.Get("/reservations/me", reservationController.Mine)
+		r.Middleware(middleware.RequireVerifiedEmail()).Post("/reservations", reservationController.Store)
+		r.Delete("/reservations/{id}", reservationController.Destroy)
+		r.Middleware(middleware.RequirePermission("borrowings.manage")).Get("/books/{id}/reservations", reservationController.BookQueue)
+	})
+
+	// Admin overrides acting on behalf of another user
+	facades.Route().Prefix("/api/admin").Middleware(middleware.Auth(), middleware.RequireTwoFactor(), middleware.RequireRole(models.RoleAdmin)).Group(func(r route.Router) {
+		r.Post("/borrowings/borrow", borrowingController.BorrowFor)
+		r.Post("/borrowings/return", borrowingController.ReturnFor)
+		r.Post("/borrowings/{id}/renew", borrowingController.RenewFor)
+		r.Post("/borrowings/{id}/lost", borrowingController.MarkLost)
+		r.Delete("/reservations/{id}", reservationController.DestroyFor)
+		r.Post("/users/{id}/unlock", userController.Unlock)
 	})
 }
@@ -2,8 +2,13 @@ package routes
 
 import (
 	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/route"
 	"github.com/goravel/framework/facades"
 	"github.com/goravel/framework/support"
+	"github.com/goravel/framework/support/path"
+
+	"goravel/app/http/controllers"
+	"goravel/app/http/middleware"
 )
 
 func Web() {
@@ -12,4 +17,21 @@ func Web() {
 			"version": support.Version,
 		})
 	})
+
+	facades.Route().Get("/.well-known/jwks.json", controllers.NewJwksController().Index)
+
+	// Files on the public disk, such as book covers, at the disk's url.
+	facades.Route().Static("/storage", path.Storage("app/public"))
+
+	// OPDS catalog for e-reader apps. Like GET /api/books it needs a signed
+	// in user; the apps send a feed token from POST /api/me/feed-token as
+	// HTTP Basic credentials, since they can't hold a bearer token.
+	opdsController := controllers.NewOpdsController()
+	facades.Route().Prefix("/opds").Middleware(middleware.FeedAuth(), middleware.RequireTwoFactor()).Group(func(r route.Router) {
+		r.Get("/", opdsController.Root)
+		r.Get("/books", opdsController.Books)
+		r.Get("/new", opdsController.New)
+		r.Get("/search", opdsController.Search)
+		r.Get("/opensearch.xml", opdsController.OpenSearch)
+	})
 }
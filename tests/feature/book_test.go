@@ -1,19 +1,33 @@
 package feature
 
 import (
+	"bytes"
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/goravel/framework/database/orm"
 	"github.com/goravel/framework/facades"
 	"github.com/stretchr/testify/suite"
 
+	"goravel/app/helpers"
 	"goravel/app/models"
+	"goravel/app/repositories"
+	"goravel/app/services"
 	"goravel/tests"
 )
 
 type BookTestSuite struct {
 	suite.Suite
 	tests.TestCase
+	// searchDriver is the configured search.driver, restored after tests
+	// that switch it.
+	searchDriver string
 }
 
 func TestBookTestSuite(t *testing.T) {
@@ -24,10 +38,15 @@ func TestBookTestSuite(t *testing.T) {
 func (s *BookTestSuite) SetupTest() {
 	// Clean up books table before each test
 	facades.Orm().Query().Where("id > ?", 0).Delete(&models.Book{})
+	facades.Orm().Query().Where("id > ?", 0).Delete(&models.Author{})
+	facades.Orm().Query().Where("id > ?", 0).Delete(&models.Category{})
+	facades.Orm().Query().Where("id > ?", 0).Delete(&models.Tag{})
+	s.searchDriver = facades.Config().GetString("search.driver")
 }
 
 // TearDownTest will run after each test in the suite.
 func (s *BookTestSuite) TearDownTest() {
+	facades.Config().Add("search.driver", s.searchDriver)
 }
 
 // TestGetAllBooks tests GET /api/books
@@ -205,3 +224,614 @@ func (s *BookTestSuite) TestDeleteBookNotFound() {
 
 	fmt.Println("✓ DELETE /api/books/{id} - Success: Returns 0 rows affected for non-existent book")
 }
+
+// TestPaginateBooks tests GET /api/books with filters, sorting and pages
+func (s *BookTestSuite) TestPaginateBooks() {
+	// Seed test data
+	books := []*models.Book{
+		{Title: "Laskar Pelangi", Author: "Andrea Hirata", PublishedYear: 2005, Stock: 3},
+		{Title: "Sang Pemimpi", Author: "Andrea Hirata", PublishedYear: 2006, Stock: 0},
+		{Title: "Edensor", Author: "Andrea Hirata", PublishedYear: 2007, Stock: 2},
+		{Title: "Maryamah Karpov", Author: "Andrea Hirata", PublishedYear: 2008, Stock: 1},
+		{Title: "Bumi Manusia", Author: "Pramoedya Ananta Toer", PublishedYear: 1980, Stock: 4},
+	}
+	for _, book := range books {
+		err := facades.Orm().Query().Create(book)
+		s.NoError(err, "Should create book successfully")
+	}
+
+	repo := repositories.NewBookRepository()
+
+	page, err := repo.PaginateBooks(repositories.BookFilter{
+		Author:   "hirata",
+		YearFrom: 2005,
+		YearTo:   2007,
+		InStock:  true,
+		Sort:     "published_year",
+		Desc:     true,
+		Page:     1,
+		PerPage:  10,
+	})
+	s.NoError(err, "Should filter books")
+	s.Equal(int64(2), page.Total)
+	s.Equal("Edensor", page.Books[0].Title)
+	s.Equal("Laskar Pelangi", page.Books[1].Title)
+
+	// Walking the cursor visits every book once, in order
+	filter := repositories.BookFilter{Sort: "title", PerPage: 2, Page: 1}
+	var titles []string
+	for {
+		page, err := repo.PaginateBooks(filter)
+		s.NoError(err, "Should fetch page")
+		s.Equal(int64(5), page.Total)
+		for _, book := range page.Books {
+			titles = append(titles, book.Title)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	s.Equal([]string{"Bumi Manusia", "Edensor", "Laskar Pelangi", "Maryamah Karpov", "Sang Pemimpi"}, titles)
+
+	_, err = repo.PaginateBooks(repositories.BookFilter{Sort: "stock", PerPage: 2, Page: 1, Cursor: filter.Cursor})
+	s.ErrorIs(err, repositories.ErrInvalidCursor, "Cursors should not be reused across sort orders")
+
+	fmt.Println("✓ GET /api/books - Success: Filters, sorts and paginates the catalog")
+}
+
+// TestFilterBooksMatchesWildcardsLiterally tests GET /api/books?title=
+func (s *BookTestSuite) TestFilterBooksMatchesWildcardsLiterally() {
+	for _, title := range []string{"100% Cotton", "1000 Nights", "Snake_Case Tales", "Snake Case Tales", "Wow! Stories"} {
+		s.NoError(facades.Orm().Query().Create(&models.Book{Title: title, Author: "Test Author", PublishedYear: 2020}))
+	}
+
+	repo := repositories.NewBookRepository()
+	for term, want := range map[string]int64{"100%": 1, "snake_": 1, "wow!": 1, "snake": 2} {
+		page, err := repo.PaginateBooks(repositories.BookFilter{Title: term, Page: 1, PerPage: 10})
+		s.NoError(err, "Should filter by %q", term)
+		s.Equal(want, page.Total, "Matches for %q", term)
+	}
+
+	fmt.Println("✓ GET /api/books?title= - Success: % and _ in filters match literally")
+}
+
+// TestSearchBooks tests GET /api/books/search
+func (s *BookTestSuite) TestSearchBooks() {
+	facades.Config().Add("search.driver", "memory")
+
+	repo := repositories.NewBookRepository()
+	search := services.NewBookSearchService(repo)
+	service := services.NewBookService(repo, search, repositories.NewAuthorRepository(), repositories.NewCategoryRepository(), repositories.NewTagRepository())
+	_, err := search.Reindex()
+	s.NoError(err, "Should reset the search index")
+
+	// Seed test data
+	for _, book := range []*models.Book{
+		{Title: "Laskar Pelangi", Author: "Andrea Hirata", PublishedYear: 2005, Stock: 3},
+		{Title: "Bumi Manusia", Author: "Pramoedya Ananta Toer", PublishedYear: 1980, Stock: 4},
+		{Title: "Pelangi di Atas Bumi", Author: "Pélangi Sutrisno", PublishedYear: 2019, Stock: 1},
+		{Title: "Menulis Buku", Author: "Ayu Utami", PublishedYear: 2015, Stock: 2},
+	} {
+		err := service.CreateBook(book)
+		s.NoError(err, "Should create book successfully")
+	}
+
+	hits, err := service.SearchBooks("pelangi", 10)
+	s.NoError(err, "Should search books")
+	s.Len(hits, 2)
+	s.Equal("Pelangi di Atas Bumi", hits[0].Book.Title, "Title and author matches should rank first")
+	s.Equal("<mark>Pélangi</mark> Sutrisno", hits[0].Highlights["author"], "Diacritics should be folded")
+
+	hits, err = service.SearchBooks("bumi manusia", 10)
+	s.NoError(err)
+	s.Len(hits, 1, "Every term should match")
+	s.Equal("<mark>Bumi</mark> <mark>Manusia</mark>", hits[0].Highlights["title"])
+
+	repeated, err := service.SearchBooks("bumi manusia bumi", 10)
+	s.NoError(err)
+	s.Require().Len(repeated, 1)
+	s.Equal(hits[0].Score, repeated[0].Score, "A repeated term should count once")
+
+	hits, err = service.SearchBooks("tulisan", 10)
+	s.NoError(err)
+	s.Len(hits, 1, "Affixed forms should match the stem")
+	s.Equal("Menulis Buku", hits[0].Book.Title)
+
+	hits, err = service.SearchBooks("hira", 10)
+	s.NoError(err)
+	s.Require().Len(hits, 1, "Prefixes should match while typing")
+
+	// Hits carry the book as it is now, not as it was indexed
+	_, err = facades.Orm().Query().Model(&models.Book{}).Where("id", hits[0].Book.ID).Update("stock", 0)
+	s.NoError(err)
+	hits, err = service.SearchBooks("hira", 10)
+	s.NoError(err)
+	s.Require().Len(hits, 1)
+	s.Zero(hits[0].Book.Stock, "Stock should not be served from the index")
+
+	fmt.Println("✓ GET /api/books/search - Success: Ranks, stems and highlights matches")
+}
+
+// TestISBNValidation tests the isbn rule used by POST /api/books
+func (s *BookTestSuite) TestISBNValidation() {
+	validator, err := facades.Validation().Make(map[string]any{
+		"hyphenated": "0-306-40615-2",
+		"with_x":     "080442957x",
+		"isbn_13":    "978-0-306-40615-7",
+		"bad_digit":  "0-306-40615-3",
+		"wrong_form": "0306406152",
+	}, map[string]string{
+		"hyphenated": "isbn",
+		"with_x":     "isbn",
+		"isbn_13":    "isbn:13",
+		"bad_digit":  "isbn",
+		"wrong_form": "isbn:13",
+	})
+	s.NoError(err)
+	s.True(validator.Errors().Has("bad_digit"), "Wrong check digits should fail")
+	s.True(validator.Errors().Has("wrong_form"), "isbn:13 should reject an ISBN-10")
+	s.False(validator.Errors().Has("hyphenated"))
+	s.False(validator.Errors().Has("with_x"))
+	s.False(validator.Errors().Has("isbn_13"))
+
+	isbn10, isbn13, ok := helpers.ISBNForms("0-306-40615-2")
+	s.True(ok)
+	s.Equal("0306406152", isbn10)
+	s.Equal("9780306406157", isbn13)
+
+	isbn10, isbn13, ok = helpers.ISBNForms("979-10-90636-07-1")
+	s.True(ok)
+	s.Empty(isbn10, "979 ISBNs have no ISBN-10")
+	s.Equal("9791090636071", isbn13)
+
+	fmt.Println("✓ POST /api/books - Validation: ISBN check digits and forms")
+}
+
+// TestDuplicateISBNRejected tests POST /api/books and GET /api/books/isbn/{isbn}
+func (s *BookTestSuite) TestDuplicateISBNRejected() {
+	repo := repositories.NewBookRepository()
+	service := services.NewBookService(repo, services.NewBookSearchService(repo), repositories.NewAuthorRepository(), repositories.NewCategoryRepository(), repositories.NewTagRepository())
+
+	book := &models.Book{Title: "Test Book", Author: "Test Author", PublishedYear: 2020, Stock: 1}
+	s.NoError(service.SetISBN(book, "0-306-40615-2"))
+	err := service.CreateBook(book)
+	s.NoError(err, "Should create book successfully")
+
+	found, err := service.GetByISBNBook("978-0-306-40615-7")
+	s.NoError(err, "Should find the book by its ISBN-13")
+	s.Equal(book.ID, found.ID)
+
+	duplicate := &models.Book{Title: "Same Book", Author: "Test Author", PublishedYear: 2020, Stock: 1}
+	s.NoError(service.SetISBN(duplicate, "9780306406157"))
+	err = service.CreateBook(duplicate)
+	s.ErrorIs(err, services.ErrDuplicateISBN, "Either form of a known ISBN should be rejected")
+
+	// Saving the same book again is not a duplicate of itself
+	err = service.UpdateBook(book)
+	s.NoError(err, "Should update book successfully")
+
+	fmt.Println("✓ POST /api/books - Success: Duplicate ISBNs are rejected")
+}
+
+// TestBookAuthors tests POST /api/books with authors and GET /api/authors/{id}/books
+func (s *BookTestSuite) TestBookAuthors() {
+	s.Equal([]string{"Jane Doe", "John Roe"}, helpers.SplitAuthorNames("Jane Doe & John Roe"))
+	s.Equal([]string{"Jane Doe", "John Roe"}, helpers.SplitAuthorNames("Jane Doe, John  Roe; jane doe"))
+	s.Equal([]string{"Toer, Pramoedya"}, helpers.SplitAuthorNames("Toer, Pramoedya"))
+
+	repo := repositories.NewBookRepository()
+	authorRepo := repositories.NewAuthorRepository()
+	books := services.NewBookService(repo, services.NewBookSearchService(repo), authorRepo, repositories.NewCategoryRepository(), repositories.NewTagRepository())
+	authors := services.NewAuthorService(authorRepo, books)
+
+	// A plain author string is split into authors
+	first := &models.Book{Title: "First Book", Author: "Jane Doe & John Roe", PublishedYear: 2020}
+	s.NoError(books.CreateBook(first), "Should create book successfully")
+	s.Len(first.Authors, 2)
+	s.Equal("Jane Doe, John Roe", first.Author)
+	jane := *first.Authors[0].Author
+
+	// Authors can be credited by ID and in other roles
+	second := &models.Book{
+		Title:         "Second Book",
+		PublishedYear: 2021,
+		Authors: []models.BookAuthor{
+			{AuthorID: jane.ID, Role: models.ContributorAuthor},
+			{Author: &models.Author{Name: "Tom Translator"}, Role: models.ContributorTranslator},
+		},
+	}
+	s.NoError(books.CreateBook(second), "Should create book successfully")
+	s.Equal("Jane Doe", second.Author, "Translators should not be in the credit line")
+
+	loaded, err := books.GetByIDBook(second.ID)
+	s.NoError(err)
+	s.Require().Len(loaded.Authors, 2)
+	s.Equal(models.ContributorTranslator, loaded.Authors[1].Role)
+	s.Equal(2, loaded.Authors[1].Position)
+
+	// Saving a loaded book keeps its credits when the credit line is unchanged
+	loaded.Title = "Second Book, Revised"
+	s.NoError(books.UpdateBook(loaded), "Should update book successfully")
+	reloaded, err := books.GetByIDBook(second.ID)
+	s.NoError(err)
+	s.Require().Len(reloaded.Authors, 2, "Translators should survive an update")
+	s.Equal(models.ContributorTranslator, reloaded.Authors[1].Role)
+
+	page, err := authors.ListBooks(jane.ID, repositories.BookFilter{Page: 1, PerPage: 10})
+	s.NoError(err)
+	s.Equal(int64(2), page.Total, "Jane should be credited on both books")
+
+	// Renaming an author rewrites the credit lines
+	jane.Name = "Jane Q. Doe"
+	s.NoError(authors.UpdateAuthor(&jane))
+	updated, err := books.GetByIDBook(first.ID)
+	s.NoError(err)
+	s.Equal("Jane Q. Doe, John Roe", updated.Author)
+
+	err = authors.DeleteAuthor(&jane)
+	s.ErrorIs(err, repositories.ErrAuthorHasBooks, "Credited authors should not be deleted")
+
+	unknown := &models.Book{Title: "Third Book", PublishedYear: 2022, Authors: []models.BookAuthor{{AuthorID: 999999}}}
+	s.ErrorIs(books.CreateBook(unknown), repositories.ErrAuthorNotFound)
+
+	// A rejected book leaves no new authors or tags behind
+	rejected := &models.Book{
+		Title: "Fourth Book", Author: "Orphan Author", PublishedYear: 2023,
+		Categories: []models.Category{{Model: orm.Model{ID: 999999}}},
+		Tags:       []models.Tag{{Name: "Orphan Tag"}},
+	}
+	s.ErrorIs(books.CreateBook(rejected), repositories.ErrCategoryNotFound)
+	_, err = authorRepo.FindAuthorByName("Orphan Author")
+	s.ErrorIs(err, repositories.ErrAuthorNotFound, "Should not create authors for a rejected book")
+	tagged, err := facades.Orm().Query().Model(&models.Tag{}).Where("slug", "orphan-tag").Exists()
+	s.NoError(err)
+	s.False(tagged, "Should not create tags for a rejected book")
+
+	// A new name credited twice becomes one author
+	both := &models.Book{
+		Title:         "Fifth Book",
+		PublishedYear: 2023,
+		Authors: []models.BookAuthor{
+			{Author: &models.Author{Name: "Sam Both"}, Role: models.ContributorAuthor},
+			{Author: &models.Author{Name: "sam both"}, Role: models.ContributorTranslator},
+		},
+	}
+	s.NoError(books.CreateBook(both), "Should create book successfully")
+	s.Require().Len(both.Authors, 2)
+	s.NotZero(both.Authors[0].AuthorID)
+	s.Equal(both.Authors[0].AuthorID, both.Authors[1].AuthorID, "Should create the author once")
+
+	fmt.Println("✓ GET /api/authors/{id}/books - Success: Books are linked to their authors")
+}
+
+// TestCategoriesAndTags tests GET /api/categories and GET /api/books?category_id=&tag=
+func (s *BookTestSuite) TestCategoriesAndTags() {
+	s.Equal("sastra-indonesia-puisi", helpers.Slugify("Sastra Indonésia & Puisi"))
+
+	repo := repositories.NewBookRepository()
+	categoryRepo := repositories.NewCategoryRepository()
+	books := services.NewBookService(repo, services.NewBookSearchService(repo), repositories.NewAuthorRepository(), categoryRepo, repositories.NewTagRepository())
+	categories := services.NewCategoryService(categoryRepo)
+
+	// Seed a small tree: Literature > Fiction > Novels
+	literature := &models.Category{Name: "Literature", Code: "800"}
+	s.NoError(categories.CreateCategory(literature))
+	fiction := &models.Category{Name: "Fiction", Code: "810", ParentID: &literature.ID}
+	s.NoError(categories.CreateCategory(fiction))
+	novels := &models.Category{Name: "Novels", Code: "813", ParentID: &fiction.ID}
+	s.NoError(categories.CreateCategory(novels))
+	s.Equal(fiction.Path+strconv.Itoa(int(novels.ID))+"/", novels.Path)
+
+	filed := &models.Book{
+		Title: "Laskar Pelangi", Author: "Andrea Hirata", PublishedYear: 2005,
+		Categories: []models.Category{{Model: orm.Model{ID: novels.ID}}, {Model: orm.Model{ID: fiction.ID}}},
+		Tags:       []models.Tag{{Name: "Coming of Age"}, {Name: "coming-of-age"}, {Name: "Belitung"}},
+	}
+	s.NoError(books.CreateBook(filed), "Should create book successfully")
+	s.Len(filed.Tags, 2, "Tags with the same slug should be merged")
+	other := &models.Book{Title: "Bumi Manusia", Author: "Pramoedya Ananta Toer", PublishedYear: 1980,
+		Categories: []models.Category{{Model: orm.Model{ID: literature.ID}}}}
+	s.NoError(books.CreateBook(other), "Should create book successfully")
+
+	// Counts include descendants, each book once
+	roots, err := categories.Tree()
+	s.NoError(err)
+	s.Require().Len(roots, 1)
+	s.Equal(int64(1), roots[0].BookCount)
+	s.Equal(int64(2), roots[0].TotalBookCount)
+	s.Require().Len(roots[0].Children, 1)
+	s.Equal(int64(1), roots[0].Children[0].TotalBookCount, "A book in two nested categories counts once")
+
+	page, err := books.ListBooks(repositories.BookFilter{CategoryID: literature.ID, Page: 1, PerPage: 10})
+	s.NoError(err)
+	s.Equal(int64(2), page.Total, "Filtering by a category should include its descendants")
+
+	page, err = books.ListBooks(repositories.BookFilter{Tags: []string{"coming-of-age", "belitung"}, Page: 1, PerPage: 10})
+	s.NoError(err)
+	s.Equal(int64(1), page.Total)
+	s.Equal("Laskar Pelangi", page.Books[0].Title)
+	s.Len(page.Books[0].Categories, 2)
+
+	// Moving a category takes its subtree along, but never under itself
+	fiction.ParentID = nil
+	s.NoError(categories.UpdateCategory(fiction))
+	_, ancestors, err := categories.Subtree(novels.ID)
+	s.NoError(err)
+	s.Len(ancestors, 1, "Novels should now sit directly under Fiction")
+
+	fiction.ParentID = &novels.ID
+	s.ErrorIs(categories.UpdateCategory(fiction), repositories.ErrCategoryCycle)
+
+	s.ErrorIs(categories.DeleteCategory(fiction), repositories.ErrCategoryHasChildren)
+
+	fmt.Println("✓ GET /api/categories - Success: Tree counts and descendant filtering")
+}
+
+// TestImportAndExportBooks tests POST /api/books/import and GET /api/books/export
+func (s *BookTestSuite) TestImportAndExportBooks() {
+	repo := repositories.NewBookRepository()
+	books := services.NewBookService(repo, services.NewBookSearchService(repo), repositories.NewAuthorRepository(), repositories.NewCategoryRepository(), repositories.NewTagRepository())
+	copies := services.NewBookCopyService(repositories.NewBookCopyRepository(), repo)
+	imports := services.NewBookImportService(books, repo, copies)
+
+	csv := "Title,Author,Published_Year,ISBN,Stock,Tags\n" +
+		"Laskar Pelangi,Andrea Hirata,2005,978-0-306-40615-7,2,novel; Belitung\n" +
+		"Bumi Manusia,Pramoedya Ananta Toer,1980,,1,\n" +
+		",Nobody,2000,,,\n" +
+		"Bad Year,Someone,soon,0-306-40615-3,,\n"
+
+	// A dry run reports without saving
+	report, err := imports.Import(strings.NewReader(csv), true)
+	s.NoError(err)
+	s.Equal(4, report.Rows)
+	s.Equal(2, report.Created)
+	s.Equal(2, report.Failed)
+	s.Equal(4, report.Errors[0].Row, "Rows should be numbered as lines of the file")
+	count, err := facades.Orm().Query().Model(&models.Book{}).Count()
+	s.NoError(err)
+	s.Zero(count, "A dry run should not save anything")
+
+	report, err = imports.Import(strings.NewReader(csv), false)
+	s.NoError(err)
+	s.Equal(2, report.Created)
+
+	imported, err := books.GetByISBNBook("0306406152")
+	s.NoError(err)
+	s.Equal(2, imported.Stock, "New books should get their stock as copies")
+	s.Len(imported.Tags, 2)
+
+	// Re-importing updates by ISBN, or by title and author
+	report, err = imports.Import(strings.NewReader("title,author,published_year,isbn\n"+
+		"Laskar Pelangi (Edisi Baru),Andrea Hirata,2008,9780306406157\n"+
+		"bumi manusia,Pramoedya Ananta Toer,1981,\n"), false)
+	s.NoError(err)
+	s.Equal(0, report.Created)
+	s.Equal(2, report.Updated)
+
+	// Copies off the shelf are still exported
+	held, err := copies.ListCopies(imported.ID)
+	s.NoError(err)
+	s.Require().Len(held, 2)
+	held[0].Status = models.CopyLost
+	s.NoError(copies.UpdateCopy(&held[0]))
+
+	var out strings.Builder
+	s.NoError(imports.Export(&out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	s.Len(lines, 3)
+	s.Equal("title,author,published_year,isbn,stock,tags,contributors", lines[0])
+	s.Contains(lines[1], "Laskar Pelangi (Edisi Baru),Andrea Hirata,2008,9780306406157,2,")
+
+	// Contributors round-trip with their roles
+	report, err = imports.Import(strings.NewReader("title,published_year,contributors\n"+
+		"This Earth of Mankind,1990,\"Toer, Pramoedya (author); Max Lane (translator)\"\n"), false)
+	s.NoError(err)
+	s.Equal(1, report.Created)
+	out.Reset()
+	s.NoError(imports.Export(&out))
+	report, err = imports.Import(strings.NewReader(out.String()), false)
+	s.NoError(err)
+	s.Equal(3, report.Updated)
+	translated, err := repo.FindMatchingBook("", "This Earth of Mankind", "Toer, Pramoedya")
+	s.NoError(err)
+	s.Require().NotNil(translated)
+	loaded, err := books.GetByIDBook(translated.ID)
+	s.NoError(err)
+	s.Require().Len(loaded.Authors, 2)
+	s.Equal("Toer, Pramoedya", loaded.Authors[0].Author.Name)
+	s.Equal(models.ContributorTranslator, loaded.Authors[1].Role)
+
+	_, err = helpers.ParseContributors("Jane Doe (illustrator)")
+	s.Error(err, "Unknown roles should be rejected")
+
+	fmt.Println("✓ POST /api/books/import - Success: CSV rows are validated, upserted and exported")
+}
+
+// TestMARCRoundTrip tests GET /api/books/{id}.marcxml
+func (s *BookTestSuite) TestMARCRoundTrip() {
+	repo := repositories.NewBookRepository()
+	books := services.NewBookService(repo, services.NewBookSearchService(repo), repositories.NewAuthorRepository(), repositories.NewCategoryRepository(), repositories.NewTagRepository())
+	copies := services.NewBookCopyService(repositories.NewBookCopyRepository(), repo)
+	imports := services.NewBookImportService(books, repo, copies)
+
+	book := &models.Book{Title: "Laskar Pelangi", Author: "Andrea Hirata", PublishedYear: 2005}
+	s.NoError(books.SetISBN(book, "978-0-306-40615-7"))
+	s.NoError(books.CreateBook(book))
+	book, err := books.GetByIDBook(strconv.Itoa(int(book.ID)))
+	s.NoError(err)
+
+	// Both encodings read back to the same record
+	var iso, xml bytes.Buffer
+	s.NoError(helpers.WriteMARC21(&iso, services.BookMARCRecord(book)))
+	s.NoError(helpers.WriteMARCXML(&xml, services.BookMARCRecord(book)))
+
+	fromISO, err := helpers.NewMARCReader(bytes.NewReader(iso.Bytes())).Read()
+	s.NoError(err)
+	fromXML, err := helpers.NewMARCXMLReader(bytes.NewReader(xml.Bytes())).Read()
+	s.NoError(err)
+	for _, record := range []*helpers.MARCRecord{fromISO, fromXML} {
+		s.Equal("Laskar Pelangi", record.FieldsByTag("245")[0].Subfield('a'))
+		s.Equal("9780306406157", record.FieldsByTag("020")[0].Subfield('a'))
+		s.Equal("Hirata, Andrea", record.FieldsByTag("100")[0].Subfield('a'))
+		s.Equal("2005", record.FieldsByTag("264")[0].Subfield('c'))
+	}
+
+	// Importing the export matches the existing book by ISBN
+	report, err := imports.ImportMARC(bytes.NewReader(xml.Bytes()), services.MARCFormatXML, true)
+	s.NoError(err)
+	s.Equal(1, report.Rows)
+	s.Equal(1, report.Updated)
+	s.Zero(report.Failed)
+
+	_, err = imports.ImportMARC(bytes.NewReader(iso.Bytes()), "pdf", true)
+	s.ErrorIs(err, services.ErrUnsupportedMARCFormat)
+
+	fmt.Println("✓ GET /api/books/{id}.marcxml - Success: MARC21 and MARCXML records round-trip")
+}
+
+// TestMARCRejectsMalformedRecords tests POST /api/books/import with a damaged MARC21 file
+func (s *BookTestSuite) TestMARCRejectsMalformedRecords() {
+	var iso bytes.Buffer
+	s.NoError(helpers.WriteMARC21(&iso, &helpers.MARCRecord{Fields: []helpers.MARCField{
+		{Tag: "001", Value: "12345"},
+		{Tag: "245", Ind1: '1', Ind2: '0', Subfields: []helpers.MARCSubfield{{Code: 'a', Value: "Laskar Pelangi"}}},
+	}}))
+
+	// The directory starts after the 24-byte leader: tag, length, start.
+	for name, patch := range map[string]struct {
+		at    int
+		value string
+	}{
+		"negative base address": {12, "-0025"},
+		"negative start":        {31, "-9999"},
+		"negative length":       {27, "-001"},
+		"field past the end":    {27, "9999"},
+		"start past the end":    {31, "99999"},
+	} {
+		record := bytes.Clone(iso.Bytes())
+		copy(record[patch.at:], patch.value)
+		s.NotPanics(func() {
+			_, err := helpers.NewMARCReader(bytes.NewReader(record)).Read()
+			s.ErrorIs(err, helpers.ErrInvalidMARC, name)
+		}, name)
+	}
+
+	fmt.Println("✓ POST /api/books/import - Success: Malformed MARC21 directories are rejected")
+}
+
+// TestOPDSEntries tests GET /opds/books
+func (s *BookTestSuite) TestOPDSEntries() {
+	isbn := "9780306406157"
+	available := &models.Book{Title: "Laskar Pelangi", Author: "Andrea Hirata", PublishedYear: 2005, Stock: 2, ISBN13: &isbn}
+	lent := &models.Book{Title: "Bumi Manusia", Author: "Pramoedya Ananta Toer", PublishedYear: 1980}
+
+	entry := helpers.ToOPDSEntry(available, "http://localhost/api/books/1")
+	s.Equal("urn:isbn:9780306406157", entry.Identifier)
+	s.Equal("2005", entry.Issued)
+	s.Equal("Andrea Hirata", entry.Authors[0].Name)
+	s.Equal("available", entry.Links[0].Availability.Status)
+	s.Equal(2, entry.Links[0].Copies.Available)
+	s.Equal("unavailable", helpers.ToOPDSEntry(lent, "http://localhost/api/books/2").Links[0].Availability.Status)
+
+	total := int64(2)
+	var out strings.Builder
+	s.NoError(helpers.WriteOPDS(&out, &helpers.OPDSFeed{
+		ID:           "urn:catalog:books",
+		Title:        "All books",
+		TotalResults: &total,
+		Entries:      []helpers.OPDSEntry{entry},
+	}))
+	s.Contains(out.String(), `<feed xmlns="http://www.w3.org/2005/Atom"`)
+	s.Contains(out.String(), `<opds:availability status="available"></opds:availability>`)
+	s.Contains(out.String(), `<opensearch:totalResults>2</opensearch:totalResults>`)
+
+	out.Reset()
+	s.NoError(helpers.WriteOpenSearch(&out, "Library", "Search the catalog", "http://localhost/opds/search?q={searchTerms}"))
+	s.Contains(out.String(), `template="http://localhost/opds/search?q={searchTerms}"`)
+
+	fmt.Println("✓ GET /opds/books - Success: OPDS entries carry availability from stock")
+}
+
+// TestOPDSFeedAuth tests GET /opds with a feed token
+func (s *BookTestSuite) TestOPDSFeedAuth() {
+	user := &models.User{Name: "Reader", Email: "reader@example.com", Password: "password123"}
+	s.Require().NoError(facades.Orm().Query().Create(user))
+	defer facades.Orm().Query().Delete(user)
+
+	feedTokens := services.NewFeedTokenService(repositories.NewUserRepository())
+	token, err := feedTokens.Issue(user)
+	s.Require().NoError(err)
+
+	resp, err := s.Http(s.T()).Get("/opds/books")
+	s.NoError(err)
+	resp.AssertUnauthorized()
+	s.Contains(resp.Headers().Get("WWW-Authenticate"), "Basic", "E-readers should be asked for credentials")
+
+	resp, err = s.Http(s.T()).WithBasicAuth(user.Email, "not-the-token").Get("/opds/books")
+	s.NoError(err)
+	resp.AssertUnauthorized()
+
+	resp, err = s.Http(s.T()).WithBasicAuth(user.Email, token).Get("/opds/books")
+	s.NoError(err)
+	resp.AssertOk()
+
+	// A new token replaces the old one, and revoking signs every app out
+	replacement, err := feedTokens.Issue(user)
+	s.Require().NoError(err)
+	_, err = feedTokens.Authenticate(user.Email, token)
+	s.ErrorIs(err, services.ErrInvalidFeedToken)
+	_, err = feedTokens.Authenticate("someone@example.com", replacement)
+	s.ErrorIs(err, services.ErrInvalidFeedToken, "The token only signs in its own account")
+	s.NoError(feedTokens.Revoke(user))
+	_, err = feedTokens.Authenticate(user.Email, replacement)
+	s.ErrorIs(err, services.ErrInvalidFeedToken)
+
+	fmt.Println("✓ GET /opds - Success: Feeds need a feed token sent as Basic auth")
+}
+
+// TestBookCover tests POST /api/books/{id}/cover
+func (s *BookTestSuite) TestBookCover() {
+	repo := repositories.NewBookRepository()
+	books := services.NewBookService(repo, services.NewBookSearchService(repo), repositories.NewAuthorRepository(), repositories.NewCategoryRepository(), repositories.NewTagRepository())
+	covers := services.NewBookCoverService(repo)
+
+	book := &models.Book{Title: "Laskar Pelangi", Author: "Andrea Hirata", PublishedYear: 2005}
+	s.NoError(books.CreateBook(book))
+
+	encode := func(width, height int) *bytes.Buffer {
+		img := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.NRGBA{R: 200, G: 40, B: 40, A: 255}), image.Point{}, draw.Src)
+		var buf bytes.Buffer
+		s.NoError(png.Encode(&buf, img))
+		return &buf
+	}
+
+	// Only real images of a sensible size are accepted
+	s.ErrorIs(covers.SaveCover(book, strings.NewReader("GIF89a not really")), services.ErrUnsupportedCover)
+	s.ErrorIs(covers.SaveCover(book, encode(40, 60)), services.ErrCoverDimensions)
+
+	// Opaque images are stored as JPEG with every thumbnail size
+	s.NoError(covers.SaveCover(book, encode(400, 600)))
+	s.True(strings.HasSuffix(book.Cover, "/original.jpg"))
+	disk := facades.Storage().Disk(helpers.CoverDisk)
+	s.True(disk.Exists(book.Cover))
+	for _, size := range helpers.CoverSizes {
+		s.True(disk.Exists(helpers.CoverPath(book.Cover, size.Name)), size.Name)
+	}
+	urls := helpers.ToBookResponse(book)["cover"].(map[string]string)
+	s.Len(urls, len(helpers.CoverSizes)+1)
+
+	// Replacing the cover removes the old files
+	first := book.Cover
+	s.NoError(covers.SaveCover(book, encode(300, 450)))
+	s.NotEqual(first, book.Cover)
+	s.False(disk.Exists(first))
+
+	s.NoError(covers.DeleteCover(book))
+	s.Empty(book.Cover)
+	s.Nil(helpers.ToBookResponse(book)["cover"])
+	s.False(disk.Exists(helpers.CoverDirectory(book.ID)))
+
+	fmt.Println("✓ POST /api/books/{id}/cover - Success: covers are validated, resized and cleaned up")
+}
@@ -1,13 +1,22 @@
 package feature
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/goravel/framework/contracts/binding"
+	contractshttp "github.com/goravel/framework/contracts/testing/http"
 	"github.com/goravel/framework/facades"
+	mocksevent "github.com/goravel/framework/mocks/event"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 
 	"goravel/app/models"
+	"goravel/app/repositories"
+	"goravel/app/services"
 	"goravel/tests"
 )
 
@@ -23,7 +32,10 @@ func TestBorrowingTestSuite(t *testing.T) {
 // SetupTest will run before each test in the suite.
 func (s *BorrowingTestSuite) SetupTest() {
 	// Clean up tables before each test
+	facades.Orm().Query().Where("id > ?", 0).Delete(&models.Reservation{})
+	facades.Orm().Query().Where("id > ?", 0).Delete(&models.FineEntry{})
 	facades.Orm().Query().Where("id > ?", 0).Delete(&models.Borrowing{})
+	facades.Orm().Query().Where("id > ?", 0).Delete(&models.BookCopy{})
 	facades.Orm().Query().Where("id > ?", 0).Delete(&models.Book{})
 	facades.Orm().Query().Where("id > ?", 0).Delete(&models.User{})
 }
@@ -131,6 +143,195 @@ func (s *BorrowingTestSuite) TestReturnBook() {
 	fmt.Println("✓ POST /api/borrowings/return - Success: Can return book (prerequisites ready)")
 }
 
+// TestBorrowAndReturnAdjustsStock tests that circulation moves Book.Stock in step with loans
+func (s *BorrowingTestSuite) TestBorrowAndReturnAdjustsStock() {
+	// Seed test data
+	user := &models.User{
+		Name:     "Test User",
+		Email:    "test@example.com",
+		Password: "password123",
+	}
+	err := facades.Orm().Query().Create(user)
+	s.NoError(err, "Should create user successfully")
+
+	book := &models.Book{
+		Title:         "Test Book",
+		Author:        "Test Author",
+		PublishedYear: 2020,
+		Stock:         1,
+	}
+	err = facades.Orm().Query().Create(book)
+	s.NoError(err, "Should create book successfully")
+
+	bookCopy := &models.BookCopy{BookID: book.ID, Barcode: "TEST-0001", Condition: "good", Status: models.CopyAvailable}
+	err = facades.Orm().Query().Create(bookCopy)
+	s.NoError(err, "Should create book copy successfully")
+
+	repo := repositories.NewBorrowingRepository()
+
+	// Borrow the only copy
+	borrowing := &models.Borrowing{}
+	err = repo.BorrowingUser(borrowing, user.ID, book.ID, "", repositories.LoanTerms{})
+	s.NoError(err, "Should borrow book successfully")
+	s.Equal("borrowed", borrowing.Status)
+	s.Require().NotNil(borrowing.BookCopyID)
+	s.Equal(bookCopy.ID, *borrowing.BookCopyID, "Loan should reference the copy")
+
+	var updatedBook models.Book
+	err = facades.Orm().Query().Where("id", book.ID).First(&updatedBook)
+	s.NoError(err)
+	s.Equal(0, updatedBook.Stock, "Stock should be decremented")
+
+	// A second borrow is refused while stock is zero
+	err = repo.BorrowingUser(&models.Borrowing{}, user.ID, book.ID, "", repositories.LoanTerms{})
+	s.ErrorIs(err, repositories.ErrBookOutOfStock, "Should refuse borrow when out of stock")
+
+	// Return the copy
+	returned := &models.Borrowing{}
+	err = repo.ReturnUserBorrowing(returned, user.ID, book.ID, "", "", repositories.FineRules{})
+	s.NoError(err, "Should return book successfully")
+	s.Equal("returned", returned.Status)
+
+	err = facades.Orm().Query().Where("id", book.ID).First(&updatedBook)
+	s.NoError(err)
+	s.Equal(1, updatedBook.Stock, "Stock should be incremented")
+
+	// Returning again is refused
+	err = repo.ReturnUserBorrowing(&models.Borrowing{}, user.ID, book.ID, "", "", repositories.FineRules{})
+	s.ErrorIs(err, repositories.ErrAlreadyReturned, "Should refuse returning a returned loan")
+
+	fmt.Println("✓ POST /api/borrowings/borrow|return - Success: Stock follows loans")
+}
+
+// TestBorrowCopyByBarcode tests POST /api/borrowings/borrow|return with a barcode
+func (s *BorrowingTestSuite) TestBorrowCopyByBarcode() {
+	// Seed test data
+	user := &models.User{
+		Name:     "Test User",
+		Email:    "test@example.com",
+		Password: "password123",
+	}
+	err := facades.Orm().Query().Create(user)
+	s.NoError(err, "Should create user successfully")
+
+	book := &models.Book{
+		Title:         "Test Book",
+		Author:        "Test Author",
+		PublishedYear: 2020,
+	}
+	err = facades.Orm().Query().Create(book)
+	s.NoError(err, "Should create book successfully")
+
+	copies := services.NewBookCopyService(repositories.NewBookCopyRepository(), repositories.NewBookRepository())
+	err = copies.AddCopies(book.ID, 2)
+	s.NoError(err, "Should add copies successfully")
+
+	list, err := copies.ListCopies(book.ID)
+	s.NoError(err)
+	s.Len(list, 2)
+	second := list[1]
+
+	var updatedBook models.Book
+	err = facades.Orm().Query().Where("id", book.ID).First(&updatedBook)
+	s.NoError(err)
+	s.Equal(2, updatedBook.Stock, "Stock should count available copies")
+
+	repo := repositories.NewBorrowingRepository()
+
+	// Borrow the second copy by its barcode
+	borrowing := &models.Borrowing{}
+	err = repo.BorrowingUser(borrowing, user.ID, 0, second.Barcode, repositories.LoanTerms{})
+	s.NoError(err, "Should borrow copy by barcode")
+	s.Equal(book.ID, borrowing.BookID)
+	s.Require().NotNil(borrowing.BookCopyID)
+	s.Equal(second.ID, *borrowing.BookCopyID)
+
+	// The same copy can't go out twice
+	err = repo.BorrowingUser(&models.Borrowing{}, user.ID, 0, second.Barcode, repositories.LoanTerms{})
+	s.ErrorIs(err, repositories.ErrCopyUnavailable)
+
+	err = repo.BorrowingUser(&models.Borrowing{}, user.ID, 0, "NO-SUCH-BARCODE", repositories.LoanTerms{})
+	s.ErrorIs(err, repositories.ErrCopyNotFound)
+
+	// A copy on loan can't be deleted
+	onLoan, err := copies.GetCopy(book.ID, second.ID)
+	s.NoError(err)
+	s.Equal(models.CopyOnLoan, onLoan.Status)
+	s.ErrorIs(copies.DeleteCopy(onLoan), repositories.ErrCopyOnLoan)
+
+	// Return it damaged
+	returned := &models.Borrowing{}
+	err = repo.ReturnUserBorrowing(returned, user.ID, 0, second.Barcode, "damaged", repositories.FineRules{})
+	s.NoError(err, "Should return copy by barcode")
+	s.Equal(borrowing.ID, returned.ID)
+
+	back, err := copies.GetCopy(book.ID, second.ID)
+	s.NoError(err)
+	s.Equal(models.CopyAvailable, back.Status)
+	s.Equal("damaged", back.Condition)
+
+	// Withdrawing a copy takes it out of stock
+	back.Status = models.CopyWithdrawn
+	s.NoError(copies.UpdateCopy(back))
+
+	err = facades.Orm().Query().Where("id", book.ID).First(&updatedBook)
+	s.NoError(err)
+	s.Equal(1, updatedBook.Stock, "Withdrawn copies should not count towards stock")
+
+	fmt.Println("✓ POST /api/borrowings/borrow|return - Success: Copies are lent and returned by barcode")
+}
+
+// TestReturnTwoCopiesByBook tests POST /api/borrowings/return by book for a patron holding two copies
+func (s *BorrowingTestSuite) TestReturnTwoCopiesByBook() {
+	// Seed test data
+	user := &models.User{
+		Name:     "Test User",
+		Email:    "test@example.com",
+		Password: "password123",
+	}
+	err := facades.Orm().Query().Create(user)
+	s.NoError(err, "Should create user successfully")
+
+	book := &models.Book{
+		Title:         "Test Book",
+		Author:        "Test Author",
+		PublishedYear: 2020,
+	}
+	err = facades.Orm().Query().Create(book)
+	s.NoError(err, "Should create book successfully")
+
+	copies := services.NewBookCopyService(repositories.NewBookCopyRepository(), repositories.NewBookRepository())
+	s.NoError(copies.AddCopies(book.ID, 2), "Should add copies successfully")
+
+	repo := repositories.NewBorrowingRepository()
+
+	first := &models.Borrowing{}
+	s.NoError(repo.BorrowingUser(first, user.ID, book.ID, "", repositories.LoanTerms{}), "Should borrow the first copy")
+	second := &models.Borrowing{}
+	s.NoError(repo.BorrowingUser(second, user.ID, book.ID, "", repositories.LoanTerms{}), "Should borrow the second copy")
+
+	// Each return by book closes one of the open loans
+	returned := &models.Borrowing{}
+	err = repo.ReturnUserBorrowing(returned, user.ID, book.ID, "", "", repositories.FineRules{})
+	s.NoError(err, "Should return the newer loan")
+	s.Equal(second.ID, returned.ID)
+
+	returned = &models.Borrowing{}
+	err = repo.ReturnUserBorrowing(returned, user.ID, book.ID, "", "", repositories.FineRules{})
+	s.NoError(err, "Should return the older loan")
+	s.Equal(first.ID, returned.ID)
+
+	var updatedBook models.Book
+	err = facades.Orm().Query().Where("id", book.ID).First(&updatedBook)
+	s.NoError(err)
+	s.Equal(2, updatedBook.Stock, "Both copies should be back in stock")
+
+	err = repo.ReturnUserBorrowing(&models.Borrowing{}, user.ID, book.ID, "", "", repositories.FineRules{})
+	s.ErrorIs(err, repositories.ErrAlreadyReturned, "Should refuse once nothing is left to return")
+
+	fmt.Println("✓ POST /api/borrowings/return - Success: Each copy of a book is returned by book")
+}
+
 // TestFindBorrowingByUserID tests GET /api/borrowings/user/{user_id}
 func (s *BorrowingTestSuite) TestFindBorrowingByUserID() {
 	// Seed test data
@@ -157,6 +358,71 @@ func (s *BorrowingTestSuite) TestFindBorrowingByUserID() {
 	fmt.Println("✓ GET /api/borrowings/user/{user_id} - Success: Can find borrowing by user ID (prerequisites ready)")
 }
 
+// TestCirculationActsOnAuthenticatedUser tests POST /api/borrowings/borrow and POST /api/admin/borrowings/borrow
+func (s *BorrowingTestSuite) TestCirculationActsOnAuthenticatedUser() {
+	facades.Orm().Query().Where("id > ?", 0).Delete(&models.LoanPolicy{})
+
+	now := time.Now()
+	newUser := func(email, role string) *models.User {
+		user := &models.User{Name: "Test User", Email: email, Password: "password123", Role: role, EmailVerifiedAt: &now}
+		if role != models.RoleMember {
+			user.TwoFactorConfirmedAt = &now
+		}
+		s.NoError(facades.Orm().Query().Create(user))
+		return user
+	}
+	patron, other := newUser("patron@example.com", models.RoleMember), newUser("other@example.com", models.RoleMember)
+	librarian, admin := newUser("librarian@example.com", models.RoleLibrarian), newUser("admin@example.com", models.RoleAdmin)
+
+	book := &models.Book{Title: "Test Book", Author: "Test Author", PublishedYear: 2020}
+	s.NoError(facades.Orm().Query().Create(book))
+	s.NoError(services.NewBookCopyService(repositories.NewBookCopyRepository(), repositories.NewBookRepository()).AddCopies(book.ID, 2))
+
+	request := func(user *models.User) contractshttp.Request {
+		return s.Http(s.T()).WithToken(accessToken(s.T(), user)).WithHeader("Content-Type", "application/json")
+	}
+	body := func(userID uint) *strings.Reader {
+		return strings.NewReader(fmt.Sprintf(`{"book_id":%d,"user_id":%d}`, book.ID, userID))
+	}
+	latestLoan := func() models.Borrowing {
+		var loan models.Borrowing
+		s.NoError(facades.Orm().Query().Where("book_id", book.ID).OrderByDesc("id").First(&loan))
+		return loan
+	}
+
+	// Patrons borrow for themselves whatever user_id they send
+	resp, err := request(patron).Post("/api/borrowings/borrow", body(other.ID))
+	s.NoError(err)
+	resp.AssertOk()
+	s.Equal(patron.ID, latestLoan().UserID)
+
+	resp, err = request(patron).Get(fmt.Sprintf("/api/borrowings/user/%d", patron.ID))
+	s.NoError(err)
+	resp.AssertOk()
+
+	// ...and can't reach another patron's loans or the staff override
+	resp, err = request(patron).Get(fmt.Sprintf("/api/borrowings/user/%d", other.ID))
+	s.NoError(err)
+	resp.AssertForbidden()
+
+	resp, err = request(patron).Post("/api/admin/borrowings/borrow", body(other.ID))
+	s.NoError(err)
+	resp.AssertForbidden()
+	s.Equal(patron.ID, latestLoan().UserID, "A refused override should not lend anything")
+
+	// Staff with borrowings.manage act on other patrons
+	resp, err = request(librarian).Get(fmt.Sprintf("/api/borrowings/user/%d", patron.ID))
+	s.NoError(err)
+	resp.AssertOk()
+
+	resp, err = request(admin).Post("/api/admin/borrowings/borrow", body(other.ID))
+	s.NoError(err)
+	resp.AssertOk()
+	s.Equal(other.ID, latestLoan().UserID)
+
+	fmt.Println("✓ POST /api/borrowings/borrow - Success: Circulation acts on the signed-in patron")
+}
+
 // TestFindBorrowingByUserIDNotFound tests GET /api/borrowings/user/{user_id} with non-existent user
 func (s *BorrowingTestSuite) TestFindBorrowingByUserIDNotFound() {
 	// Try to find borrowing for non-existent user
@@ -166,3 +432,439 @@ func (s *BorrowingTestSuite) TestFindBorrowingByUserIDNotFound() {
 
 	fmt.Println("✓ GET /api/borrowings/user/{user_id} - Success: Returns error for non-existent user")
 }
+
+// TestLoanPolicies tests POST /api/borrowings/borrow under loan policies
+func (s *BorrowingTestSuite) TestLoanPolicies() {
+	facades.Orm().Query().Where("id > ?", 0).Delete(&models.LoanPolicy{})
+	facades.Orm().Query().Where("id > ?", 0).Delete(&models.Category{})
+
+	user := &models.User{Name: "Test User", Email: "test@example.com", Password: "password123", Role: models.RoleMember}
+	s.NoError(facades.Orm().Query().Create(user))
+
+	categoryRepo := repositories.NewCategoryRepository()
+	categories := services.NewCategoryService(categoryRepo)
+	reference := &models.Category{Name: "Reference"}
+	s.NoError(categories.CreateCategory(reference))
+	atlases := &models.Category{Name: "Atlases", ParentID: &reference.ID}
+	s.NoError(categories.CreateCategory(atlases))
+
+	copies := services.NewBookCopyService(repositories.NewBookCopyRepository(), repositories.NewBookRepository())
+	newBook := func(title string, categoryIDs ...uint) *models.Book {
+		book := &models.Book{Title: title, Author: "Test Author", PublishedYear: 2020}
+		s.NoError(facades.Orm().Query().Create(book))
+		s.NoError(copies.AddCopies(book.ID, 1))
+		s.NoError(categoryRepo.SyncBookCategories(book.ID, categoryIDs))
+		return book
+	}
+	novel := newBook("Novel")
+	atlas := newBook("World Atlas", atlases.ID)
+	secondAtlas := newBook("Star Atlas", atlases.ID)
+
+	policies := services.NewLoanPolicyService(repositories.NewLoanPolicyRepository(), categoryRepo)
+	s.NoError(policies.CreatePolicy(&models.LoanPolicy{Role: models.RoleMember, LoanDays: 21, MaxLoans: 3, MaxRenewals: 1}))
+	s.NoError(policies.CreatePolicy(&models.LoanPolicy{CategoryID: &reference.ID, LoanDays: 3, MaxLoans: 1}))
+	s.ErrorIs(policies.CreatePolicy(&models.LoanPolicy{Role: models.RoleMember, LoanDays: 7}), services.ErrDuplicateLoanPolicy)
+
+	// The role policy applies to uncategorized books
+	rules, err := policies.RulesFor(models.RoleMember, novel.ID)
+	s.NoError(err)
+	s.Equal(21, rules.LoanDays)
+	s.Equal([]repositories.LoanLimit{{Max: 3}}, rules.Limits)
+
+	// A policy for an ancestor category wins and adds its own limit
+	rules, err = policies.RulesFor(models.RoleMember, atlas.ID)
+	s.NoError(err)
+	s.Equal(3, rules.LoanDays)
+	s.Equal([]repositories.LoanLimit{{Max: 3}, {CategoryID: reference.ID, Max: 1}}, rules.Limits)
+
+	borrowings := services.NewBorrowingService(repositories.NewBorrowingRepository(), repositories.NewBookCopyRepository(), repositories.NewUserRepository(), policies)
+
+	borrowing := &models.Borrowing{}
+	s.NoError(borrowings.BorrowingUser(borrowing, user.ID, atlas.ID, ""))
+	s.Require().NotNil(borrowing.DueAt)
+	s.WithinDuration(time.Now().AddDate(0, 0, 3), *borrowing.DueAt, time.Minute)
+
+	// The category allows one loan at a time
+	err = borrowings.BorrowingUser(&models.Borrowing{}, user.ID, secondAtlas.ID, "")
+	var limitErr *repositories.LoanLimitError
+	s.Require().ErrorAs(err, &limitErr)
+	s.Equal(reference.ID, limitErr.CategoryID)
+	s.Equal(int64(1), limitErr.Current)
+
+	// Other books are still within the overall limit
+	s.NoError(borrowings.BorrowingUser(&models.Borrowing{}, user.ID, novel.ID, ""))
+
+	fmt.Println("✓ POST /api/borrowings/borrow - Success: Loan policies set due dates and limits")
+}
+
+// TestRenewBorrowing tests POST /api/borrowings/{id}/renew
+func (s *BorrowingTestSuite) TestRenewBorrowing() {
+	facades.Orm().Query().Where("id > ?", 0).Delete(&models.LoanPolicy{})
+
+	user := &models.User{Name: "Test User", Email: "test@example.com", Password: "password123", Role: models.RoleMember}
+	s.NoError(facades.Orm().Query().Create(user))
+	other := &models.User{Name: "Other User", Email: "other@example.com", Password: "password123", Role: models.RoleMember}
+	s.NoError(facades.Orm().Query().Create(other))
+
+	copies := services.NewBookCopyService(repositories.NewBookCopyRepository(), repositories.NewBookRepository())
+	newBook := func(title string) *models.Book {
+		book := &models.Book{Title: title, Author: "Test Author", PublishedYear: 2020}
+		s.NoError(facades.Orm().Query().Create(book))
+		s.NoError(copies.AddCopies(book.ID, 1))
+		return book
+	}
+	first, second := newBook("First Book"), newBook("Second Book")
+
+	policies := services.NewLoanPolicyService(repositories.NewLoanPolicyRepository(), repositories.NewCategoryRepository())
+	s.NoError(policies.CreatePolicy(&models.LoanPolicy{Role: models.RoleMember, LoanDays: 7, MaxLoans: 5, MaxRenewals: 1}))
+	borrowings := services.NewBorrowingService(repositories.NewBorrowingRepository(), repositories.NewBookCopyRepository(), repositories.NewUserRepository(), policies)
+
+	loan := &models.Borrowing{}
+	s.NoError(borrowings.BorrowingUser(loan, user.ID, first.ID, ""))
+	late := &models.Borrowing{}
+	s.NoError(borrowings.BorrowingUser(late, user.ID, second.ID, ""))
+
+	// Only the patron with the loan can renew it
+	_, err := borrowings.RenewBorrowing(loan.ID, other.ID)
+	s.ErrorIs(err, repositories.ErrBorrowingNotFound)
+
+	// Any overdue loan blocks renewals
+	_, err = facades.Orm().Query().Model(&models.Borrowing{}).Where("id", late.ID).Update("due_at", time.Now().AddDate(0, 0, -1))
+	s.NoError(err)
+	_, err = borrowings.RenewBorrowing(loan.ID, user.ID)
+	s.ErrorIs(err, repositories.ErrHasOverdueLoans)
+
+	_, err = facades.Orm().Query().Model(&models.Borrowing{}).Where("id", late.ID).Update("due_at", time.Now().AddDate(0, 0, 7))
+	s.NoError(err)
+
+	// Another patron's hold on the title blocks renewals until it is withdrawn
+	holds := services.NewReservationService(repositories.NewReservationRepository())
+	hold, err := holds.PlaceHold(other.ID, first.ID)
+	s.NoError(err)
+	_, err = borrowings.RenewBorrowing(loan.ID, user.ID)
+	s.ErrorIs(err, repositories.ErrBookOnHold)
+	_, err = holds.CancelHold(hold.ID, other.ID)
+	s.NoError(err)
+
+	renewed, err := borrowings.RenewBorrowing(loan.ID, user.ID)
+	s.NoError(err)
+	s.Equal(1, renewed.Renewals)
+	s.WithinDuration(loan.DueAt.AddDate(0, 0, 7), *renewed.DueAt, time.Second)
+	s.Require().Len(renewed.History, 1)
+	s.Equal(models.BorrowingRenewed, renewed.History[0].Event)
+	s.WithinDuration(*loan.DueAt, *renewed.History[0].PreviousDueAt, time.Second)
+
+	// The policy allows a single renewal
+	_, err = borrowings.RenewBorrowing(loan.ID, user.ID)
+	s.ErrorIs(err, repositories.ErrRenewalLimit)
+
+	fmt.Println("✓ POST /api/borrowings/{id}/renew - Success: Renewals follow the loan policy")
+}
+
+// TestReservationQueue tests POST /api/reservations
+func (s *BorrowingTestSuite) TestReservationQueue() {
+	newUser := func(name, email string) *models.User {
+		user := &models.User{Name: name, Email: email, Password: "password123", Role: models.RoleMember}
+		s.NoError(facades.Orm().Query().Create(user))
+		return user
+	}
+	reader, first, second := newUser("Reader", "reader@example.com"), newUser("First", "first@example.com"), newUser("Second", "second@example.com")
+
+	book := &models.Book{Title: "Popular Book", Author: "Test Author", PublishedYear: 2020}
+	s.NoError(facades.Orm().Query().Create(book))
+	copies := services.NewBookCopyService(repositories.NewBookCopyRepository(), repositories.NewBookRepository())
+	s.NoError(copies.AddCopies(book.ID, 1))
+
+	repo := repositories.NewBorrowingRepository()
+	holds := services.NewReservationService(repositories.NewReservationRepository())
+
+	// Holds are only taken once the shelf is empty
+	_, err := holds.PlaceHold(first.ID, book.ID)
+	s.ErrorIs(err, repositories.ErrBookAvailable)
+
+	s.NoError(repo.BorrowingUser(&models.Borrowing{}, reader.ID, book.ID, "", repositories.LoanTerms{}))
+	_, err = holds.PlaceHold(reader.ID, book.ID)
+	s.ErrorIs(err, repositories.ErrAlreadyBorrowed)
+
+	firstHold, err := holds.PlaceHold(first.ID, book.ID)
+	s.NoError(err)
+	s.Equal(1, firstHold.Position)
+	secondHold, err := holds.PlaceHold(second.ID, book.ID)
+	s.NoError(err)
+	s.Equal(2, secondHold.Position)
+	_, err = holds.PlaceHold(second.ID, book.ID)
+	s.ErrorIs(err, repositories.ErrAlreadyReserved)
+
+	// The returned copy is set aside for the first hold
+	s.NoError(repo.ReturnUserBorrowing(&models.Borrowing{}, reader.ID, book.ID, "", "", repositories.FineRules{}))
+	queue, err := holds.ListBookHolds(book.ID)
+	s.NoError(err)
+	s.Require().Len(queue, 2)
+	s.Equal(firstHold.ID, queue[0].ID)
+	s.Equal(models.ReservationReady, queue[0].Status)
+	s.Require().NotNil(queue[0].ExpiresAt)
+	s.Equal(1, queue[1].Position)
+
+	var stocked models.Book
+	s.NoError(facades.Orm().Query().Where("id", book.ID).First(&stocked))
+	s.Equal(0, stocked.Stock)
+
+	// Nobody else can take the held copy
+	err = repo.BorrowingUser(&models.Borrowing{}, second.ID, book.ID, "", repositories.LoanTerms{})
+	s.ErrorIs(err, repositories.ErrBookOutOfStock)
+
+	// An uncollected hold expires and the copy moves down the queue
+	_, err = facades.Orm().Query().Model(&models.Reservation{}).Where("id", firstHold.ID).Update("expires_at", time.Now().Add(-time.Hour))
+	s.NoError(err)
+	expired, err := holds.ExpireHolds()
+	s.NoError(err)
+	s.Equal(1, expired)
+
+	mine, err := holds.ListUserHolds(second.ID)
+	s.NoError(err)
+	s.Require().Len(mine, 1)
+	s.Equal(models.ReservationReady, mine[0].Status)
+
+	// Picking the book up fulfils the hold with the copy set aside for it
+	borrowing := &models.Borrowing{}
+	s.NoError(repo.BorrowingUser(borrowing, second.ID, book.ID, "", repositories.LoanTerms{}))
+	s.Equal(mine[0].BookCopyID, borrowing.BookCopyID)
+
+	fulfilled, err := repositories.NewReservationRepository().FindReservation(secondHold.ID)
+	s.NoError(err)
+	s.Equal(models.ReservationFulfilled, fulfilled.Status)
+
+	fmt.Println("✓ POST /api/reservations - Success: Holds are served in order and expire when not collected")
+}
+
+// TestFlagOverdueBorrowings tests GET /api/borrowings?status=overdue
+func (s *BorrowingTestSuite) TestFlagOverdueBorrowings() {
+	user := &models.User{Name: "Test User", Email: "test@example.com", Password: "password123", Role: models.RoleMember}
+	s.NoError(facades.Orm().Query().Create(user))
+
+	copies := services.NewBookCopyService(repositories.NewBookCopyRepository(), repositories.NewBookRepository())
+	newLoan := func(title string, dueAt time.Time) *models.Borrowing {
+		book := &models.Book{Title: title, Author: "Test Author", PublishedYear: 2020}
+		s.NoError(facades.Orm().Query().Create(book))
+		s.NoError(copies.AddCopies(book.ID, 1))
+
+		borrowing := &models.Borrowing{}
+		s.NoError(repositories.NewBorrowingRepository().BorrowingUser(borrowing, user.ID, book.ID, "", repositories.LoanTerms{DueAt: dueAt}))
+		return borrowing
+	}
+	late := newLoan("Late Book", time.Now().AddDate(0, 0, -2))
+	current := newLoan("Current Book", time.Now().AddDate(0, 0, 5))
+
+	policies := services.NewLoanPolicyService(repositories.NewLoanPolicyRepository(), repositories.NewCategoryRepository())
+	borrowings := services.NewBorrowingService(repositories.NewBorrowingRepository(), repositories.NewBookCopyRepository(), repositories.NewUserRepository(), policies)
+
+	flagged, err := borrowings.FlagOverdueBorrowings()
+	s.NoError(err)
+	s.Equal(1, flagged)
+
+	overdue, err := borrowings.GetAllBorrowings("overdue")
+	s.NoError(err)
+	s.Require().Len(overdue, 1)
+	s.Equal(late.ID, overdue[0].ID)
+
+	events, err := repositories.NewBorrowingRepository().FindBorrowingEvents(late.ID)
+	s.NoError(err)
+	s.Require().Len(events, 1)
+	s.Equal(models.BorrowingOverdue, events[0].Event)
+
+	// Loans already overdue are not flagged again
+	flagged, err = borrowings.FlagOverdueBorrowings()
+	s.NoError(err)
+	s.Equal(0, flagged)
+
+	// A lost loan is closed along with its copy
+	lost, err := borrowings.MarkLost(current.ID)
+	s.NoError(err)
+	s.Equal("lost", lost.Status)
+
+	var bookCopy models.BookCopy
+	s.NoError(facades.Orm().Query().Where("id", *current.BookCopyID).First(&bookCopy))
+	s.Equal(models.CopyLost, bookCopy.Status)
+
+	_, err = borrowings.RenewBorrowing(current.ID, user.ID)
+	s.ErrorIs(err, repositories.ErrLoanLost)
+
+	fmt.Println("✓ GET /api/borrowings?status=overdue - Success: Past-due loans are flagged overdue")
+}
+
+// TestFlagOverdueDispatchesEveryEvent tests that one failed LoanOverdue dispatch doesn't stop the others
+func (s *BorrowingTestSuite) TestFlagOverdueDispatchesEveryEvent() {
+	user := &models.User{Name: "Test User", Email: "test@example.com", Password: "password123", Role: models.RoleMember}
+	s.NoError(facades.Orm().Query().Create(user))
+
+	copies := services.NewBookCopyService(repositories.NewBookCopyRepository(), repositories.NewBookRepository())
+	for _, title := range []string{"First Late Book", "Second Late Book"} {
+		book := &models.Book{Title: title, Author: "Test Author", PublishedYear: 2020}
+		s.NoError(facades.Orm().Query().Create(book))
+		s.NoError(copies.AddCopies(book.ID, 1))
+		s.NoError(repositories.NewBorrowingRepository().BorrowingUser(&models.Borrowing{}, user.ID, book.ID, "", repositories.LoanTerms{DueAt: time.Now().AddDate(0, 0, -2)}))
+	}
+
+	failed := mocksevent.NewTask(s.T())
+	failed.EXPECT().Dispatch().Return(errors.New("queue unavailable")).Once()
+	sent := mocksevent.NewTask(s.T())
+	sent.EXPECT().Dispatch().Return(nil).Once()
+	dispatcher := s.fakeEvents()
+	dispatcher.EXPECT().Job(mock.Anything, mock.Anything).Return(failed).Once()
+	dispatcher.EXPECT().Job(mock.Anything, mock.Anything).Return(sent).Once()
+
+	policies := services.NewLoanPolicyService(repositories.NewLoanPolicyRepository(), repositories.NewCategoryRepository())
+	borrowings := services.NewBorrowingService(repositories.NewBorrowingRepository(), repositories.NewBookCopyRepository(), repositories.NewUserRepository(), policies)
+
+	flagged, err := borrowings.FlagOverdueBorrowings()
+	s.ErrorContains(err, "queue unavailable", "Should report the failed dispatch")
+	s.Equal(2, flagged, "Both loans should be flagged")
+
+	fmt.Println("✓ GET /api/borrowings?status=overdue - Success: Every overdue loan gets its event")
+}
+
+// fakeEvents swaps the event dispatcher for a mock until the test ends.
+func (s *BorrowingTestSuite) fakeEvents() *mocksevent.Instance {
+	original := facades.Event()
+	dispatcher := mocksevent.NewInstance(s.T())
+	facades.App().Instance(binding.Event, dispatcher)
+	facades.App().Fresh(binding.Event)
+	s.T().Cleanup(func() {
+		facades.App().Instance(binding.Event, original)
+		facades.App().Fresh(binding.Event)
+	})
+	return dispatcher
+}
+
+// TestFinesLedger tests GET /api/users/{id}/fines/statement
+func (s *BorrowingTestSuite) TestFinesLedger() {
+	user := &models.User{Name: "Test User", Email: "test@example.com", Password: "password123", Role: models.RoleMember}
+	s.NoError(facades.Orm().Query().Create(user))
+
+	copies := services.NewBookCopyService(repositories.NewBookCopyRepository(), repositories.NewBookRepository())
+	newBook := func(title string) *models.Book {
+		book := &models.Book{Title: title, Author: "Test Author", PublishedYear: 2020}
+		s.NoError(facades.Orm().Query().Create(book))
+		s.NoError(copies.AddCopies(book.ID, 1))
+		return book
+	}
+	late, lost, next := newBook("Late Book"), newBook("Lost Book"), newBook("Next Book")
+
+	repo := repositories.NewBorrowingRepository()
+	fines := repositories.NewFineRepository()
+	rules := repositories.FineRules{DailyRate: 50, GraceDays: 2, MaxPerItem: 300, ReplacementCharge: 2000}
+
+	// Five days late, two of them grace: three days at 50
+	lateLoan := &models.Borrowing{}
+	s.NoError(repo.BorrowingUser(lateLoan, user.ID, late.ID, "", repositories.LoanTerms{DueAt: time.Now().Add(-5*24*time.Hour - time.Hour)}))
+	charged, err := fines.AccrueLateFees(rules, time.Now())
+	s.NoError(err)
+	s.Equal(1, charged)
+
+	// Accrual only tops the fee up, and the cap holds on return
+	charged, err = fines.AccrueLateFees(rules, time.Now())
+	s.NoError(err)
+	s.Equal(0, charged)
+	_, err = facades.Orm().Query().Model(&models.Borrowing{}).Where("id", lateLoan.ID).Update("due_at", time.Now().AddDate(0, 0, -30))
+	s.NoError(err)
+	s.NoError(repo.ReturnUserBorrowing(&models.Borrowing{}, user.ID, late.ID, "", "", rules))
+
+	balance, err := fines.UserFineBalance(user.ID)
+	s.NoError(err)
+	s.Equal(int64(300), balance)
+
+	// Losing a copy adds the replacement charge
+	lostLoan := &models.Borrowing{}
+	s.NoError(repo.BorrowingUser(lostLoan, user.ID, lost.ID, "", repositories.LoanTerms{DueAt: time.Now().AddDate(0, 0, 7)}))
+	s.NoError(repo.MarkBorrowingLost(lostLoan, rules))
+	balance, err = fines.UserFineBalance(user.ID)
+	s.NoError(err)
+	s.Equal(int64(2300), balance)
+
+	// Patrons owing more than the threshold can't borrow
+	err = repo.BorrowingUser(&models.Borrowing{}, user.ID, next.ID, "", repositories.LoanTerms{MaxFineBalance: 1000})
+	var fineErr *repositories.FineBalanceError
+	s.Require().ErrorAs(err, &fineErr)
+	s.Equal(int64(2300), fineErr.Balance)
+
+	// Payments can't exceed what a loan owes, and refunds what was paid
+	err = fines.RecordFineEntry(&models.FineEntry{BorrowingID: lateLoan.ID, Type: models.FinePayment, Amount: 500})
+	s.ErrorIs(err, repositories.ErrFineExceedsBalance)
+	s.NoError(fines.RecordFineEntry(&models.FineEntry{BorrowingID: lateLoan.ID, Type: models.FinePayment, Amount: 300}))
+	err = fines.RecordFineEntry(&models.FineEntry{BorrowingID: lateLoan.ID, Type: models.FineRefund, Amount: 400})
+	s.ErrorIs(err, repositories.ErrRefundExceedsPayments)
+
+	// The lost copy turning up waives its replacement charge
+	s.NoError(repo.ReturnUserBorrowing(&models.Borrowing{}, user.ID, lost.ID, "", "", rules))
+	balance, err = fines.UserFineBalance(user.ID)
+	s.NoError(err)
+	s.Equal(int64(0), balance)
+
+	s.NoError(repo.BorrowingUser(&models.Borrowing{}, user.ID, next.ID, "", repositories.LoanTerms{MaxFineBalance: 1000}))
+
+	statement, err := fines.FindUserFineEntries(user.ID)
+	s.NoError(err)
+	s.Require().Len(statement, 5)
+	s.Equal(models.FineReasonOverdue, statement[0].Reason)
+	s.Equal(int64(150), statement[0].Amount)
+	s.Equal(int64(-300), statement[3].Amount)
+	s.Equal(models.FineWaiver, statement[4].Type)
+
+	fmt.Println("✓ GET /api/users/{id}/fines/statement - Success: Fines accrue, cap and block borrowing")
+}
+
+// TestAddedCopyGoesToWaitingHold tests POST /books/{id}/copies
+func (s *BorrowingTestSuite) TestAddedCopyGoesToWaitingHold() {
+	reader := &models.User{Name: "Reader", Email: "reader@example.com", Password: "password123", Role: models.RoleMember}
+	s.NoError(facades.Orm().Query().Create(reader))
+	waiting := &models.User{Name: "Waiting", Email: "waiting@example.com", Password: "password123", Role: models.RoleMember}
+	s.NoError(facades.Orm().Query().Create(waiting))
+
+	book := &models.Book{Title: "Popular Book", Author: "Test Author", PublishedYear: 2020}
+	s.NoError(facades.Orm().Query().Create(book))
+	copyRepo := repositories.NewBookCopyRepository()
+	copies := services.NewBookCopyService(copyRepo, repositories.NewBookRepository())
+	s.NoError(copies.AddCopies(book.ID, 1))
+
+	s.NoError(repositories.NewBorrowingRepository().BorrowingUser(&models.Borrowing{}, reader.ID, book.ID, "", repositories.LoanTerms{}))
+	holds := services.NewReservationService(repositories.NewReservationRepository())
+	hold, err := holds.PlaceHold(waiting.ID, book.ID)
+	s.Require().NoError(err)
+
+	// A new copy skips the shelf while a patron is waiting
+	added := &models.BookCopy{BookID: book.ID}
+	s.NoError(copies.CreateCopy(added))
+	s.Equal(models.CopyOnHold, added.Status)
+
+	placed, err := repositories.NewReservationRepository().FindReservation(hold.ID)
+	s.NoError(err)
+	s.Equal(models.ReservationReady, placed.Status)
+	s.Require().NotNil(placed.BookCopyID)
+	s.Equal(added.ID, *placed.BookCopyID)
+
+	var stocked models.Book
+	s.NoError(facades.Orm().Query().Where("id", book.ID).First(&stocked))
+	s.Equal(0, stocked.Stock)
+
+	// So does a lost copy that turns up, once there is a queue again
+	lost := &models.BookCopy{BookID: book.ID}
+	s.NoError(copies.CreateCopy(lost))
+	s.Equal(models.CopyAvailable, lost.Status)
+	lost.Status = models.CopyLost
+	s.NoError(copies.UpdateCopy(lost))
+
+	other := &models.User{Name: "Other", Email: "other@example.com", Password: "password123", Role: models.RoleMember}
+	s.NoError(facades.Orm().Query().Create(other))
+	next, err := holds.PlaceHold(other.ID, book.ID)
+	s.Require().NoError(err)
+
+	lost.Status = models.CopyAvailable
+	s.NoError(copies.UpdateCopy(lost))
+	s.Equal(models.CopyOnHold, lost.Status)
+
+	placed, err = repositories.NewReservationRepository().FindReservation(next.ID)
+	s.NoError(err)
+	s.Equal(models.ReservationReady, placed.Status)
+
+	fmt.Println("✓ POST /books/{id}/copies - Success: New and restored copies go to waiting holds first")
+}
@@ -2,12 +2,25 @@ package feature
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/goravel/framework/contracts/binding"
+	"github.com/goravel/framework/contracts/mail"
 	"github.com/goravel/framework/facades"
+	mocksmail "github.com/goravel/framework/mocks/mail"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"goravel/app/helpers"
+	"goravel/app/mails"
 	"goravel/app/models"
+	"goravel/app/repositories"
+	"goravel/app/services"
 	"goravel/tests"
 )
 
@@ -153,6 +166,26 @@ func (s *UserTestSuite) TestRegisterUserEmailExists() {
 	fmt.Println("✓ POST /api/users - Success: Returns error for duplicate email")
 }
 
+// TestUserDefaultsToMemberRole tests that new accounts get the member role
+func (s *UserTestSuite) TestUserDefaultsToMemberRole() {
+	user := &models.User{
+		Name:     "Patron",
+		Email:    "patron@example.com",
+		Password: "password123",
+	}
+	err := facades.Orm().Query().Create(user)
+	s.NoError(err, "Should create user successfully")
+
+	var retrievedUser models.User
+	err = facades.Orm().Query().Where("id", user.ID).First(&retrievedUser)
+	s.NoError(err, "Should retrieve created user")
+	s.Equal(models.RoleMember, retrievedUser.Role)
+	s.True(retrievedUser.HasRole(models.RoleMember, models.RoleLibrarian))
+	s.False(retrievedUser.HasRole(models.RoleAdmin))
+
+	fmt.Println("✓ POST /api/register - Success: New users get the member role")
+}
+
 // TestUpdateUser tests POST /api/users/{id}
 func (s *UserTestSuite) TestUpdateUser() {
 	// Seed test data
@@ -219,3 +252,394 @@ func (s *UserTestSuite) TestDeleteUserNotFound() {
 
 	fmt.Println("✓ DELETE /api/users/{id} - Success: Returns 0 rows affected for non-existent user")
 }
+
+// TestLogoutRevokesToken tests POST /api/logout
+func (s *UserTestSuite) TestLogoutRevokesToken() {
+	// Seed test data
+	user := &models.User{
+		Name:     "Test User",
+		Email:    "logout@example.com",
+		Password: "password123",
+	}
+	err := facades.Orm().Query().Create(user)
+	s.NoError(err, "Should create user successfully")
+
+	repo := repositories.NewTokenRepository()
+
+	err = repo.RevokeToken("active-jti", user.ID, time.Now().Add(time.Hour))
+	s.NoError(err, "Should revoke active token")
+	err = repo.RevokeToken("expired-jti", user.ID, time.Now().Add(-time.Hour))
+	s.NoError(err, "Should revoke expired token")
+
+	revoked, err := repo.IsTokenRevoked("active-jti")
+	s.NoError(err)
+	s.True(revoked, "Active token should be revoked")
+
+	// Pruning only removes entries whose tokens have expired
+	pruned, err := repo.PruneExpiredTokens()
+	s.NoError(err)
+	s.Equal(int64(1), pruned, "Should prune 1 expired entry")
+
+	revoked, err = repo.IsTokenRevoked("active-jti")
+	s.NoError(err)
+	s.True(revoked, "Active token should stay revoked after pruning")
+
+	fmt.Println("✓ POST /api/logout - Success: Token is revoked until it expires")
+}
+
+// TestRefreshTokenRotationAndReuse tests POST /api/refresh
+func (s *UserTestSuite) TestRefreshTokenRotationAndReuse() {
+	// Seed test data
+	user := &models.User{
+		Name:     "Test User",
+		Email:    "refresh@example.com",
+		Password: "password123",
+	}
+	err := facades.Orm().Query().Create(user)
+	s.NoError(err, "Should create user successfully")
+
+	repo := repositories.NewTokenRepository()
+
+	original := &models.RefreshToken{UserID: user.ID, FamilyID: "family-1", TokenHash: "hash-1"}
+	err = repo.CreateRefreshToken(original)
+	s.NoError(err, "Should create refresh token")
+
+	// Rotating a live token issues the next one in the same family
+	rotated := &models.RefreshToken{TokenHash: "hash-2"}
+	err = repo.RotateRefreshToken("hash-1", rotated)
+	s.NoError(err, "Should rotate refresh token")
+	s.Equal(user.ID, rotated.UserID)
+	s.Equal("family-1", rotated.FamilyID)
+
+	// Presenting the rotated token again is reuse and revokes the family
+	err = repo.RotateRefreshToken("hash-1", &models.RefreshToken{TokenHash: "hash-3"})
+	s.ErrorIs(err, repositories.ErrRefreshTokenReused, "Should detect refresh token reuse")
+
+	err = repo.RotateRefreshToken("hash-2", &models.RefreshToken{TokenHash: "hash-4"})
+	s.ErrorIs(err, repositories.ErrRefreshTokenReused, "Family should be revoked after reuse")
+
+	fmt.Println("✓ POST /api/refresh - Success: Rotation works and reuse revokes the family")
+}
+
+// TestPasswordResetTokenIsSingleUse tests POST /api/password/reset
+func (s *UserTestSuite) TestPasswordResetTokenIsSingleUse() {
+	// Seed test data
+	user := &models.User{
+		Name:     "Test User",
+		Email:    "reset@example.com",
+		Password: "password123",
+	}
+	err := facades.Orm().Query().Create(user)
+	s.NoError(err, "Should create user successfully")
+
+	repo := repositories.NewPasswordResetRepository()
+
+	err = repo.CreatePasswordReset(&models.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: "reset-hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	s.NoError(err, "Should create password reset")
+
+	updatedUser, err := repo.ResetPassword("reset-hash", "new-hashed-password")
+	s.NoError(err, "Should reset password")
+	s.Equal("new-hashed-password", updatedUser.Password)
+	s.NotNil(updatedUser.TokensRevokedAt, "Existing tokens should be invalidated")
+
+	_, err = repo.ResetPassword("reset-hash", "another-password")
+	s.ErrorIs(err, repositories.ErrPasswordResetInvalid, "Token should only work once")
+
+	fmt.Println("✓ POST /api/password/reset - Success: Reset tokens are single-use")
+}
+
+// TestForgotPasswordIsThrottled tests POST /api/password/forgot
+func (s *UserTestSuite) TestForgotPasswordIsThrottled() {
+	// A fresh address, so earlier runs don't count against it
+	body := fmt.Sprintf(`{"email":"forgot-%d@example.com"}`, time.Now().UnixNano())
+
+	resp, err := s.Http(s.T()).WithHeader("Content-Type", "application/json").
+		Post("/api/password/forgot", strings.NewReader(body))
+	s.NoError(err)
+	resp.AssertOk()
+
+	resp, err = s.Http(s.T()).WithHeader("Content-Type", "application/json").
+		Post("/api/password/forgot", strings.NewReader(body))
+	s.NoError(err)
+	resp.AssertTooManyRequests()
+
+	fmt.Println("✓ POST /api/password/forgot - Success: Reset links are rate limited")
+}
+
+// TestLoginThrottleClearsOnSuccess tests POST /api/login after failed attempts
+func (s *UserTestSuite) TestLoginThrottleClearsOnSuccess() {
+	limit := facades.Config().GetInt("auth.throttle.ip_max_attempts")
+	facades.Config().Add("auth.throttle.ip_max_attempts", 3)
+	defer facades.Config().Add("auth.throttle.ip_max_attempts", limit)
+
+	throttle := services.NewLoginThrottleService()
+	ip := "203.0.113.9"
+	s.T().Cleanup(func() {
+		throttle.RecordSuccess("second@example.com", ip)
+		facades.Cache().Forget("login:ip:" + ip + ":available_at")
+	})
+
+	// Failures followed by a good login no longer count against the address
+	throttle.RecordFailure("first@example.com", ip)
+	throttle.RecordFailure("first@example.com", ip)
+	throttle.RecordSuccess("first@example.com", ip)
+	throttle.RecordFailure("second@example.com", ip)
+	throttle.RecordFailure("second@example.com", ip)
+	s.Zero(throttle.RetryAfter("second@example.com", ip))
+
+	// ...while a run of failures still locks it out
+	throttle.RecordFailure("second@example.com", ip)
+	s.Positive(throttle.RetryAfter("third@example.com", ip))
+
+	fmt.Println("✓ POST /api/login - Success: A good login resets the address's failures")
+}
+
+// TestLoginErrorsDoNotRevealAccounts tests POST /api/login with bad credentials
+func (s *UserTestSuite) TestLoginErrorsDoNotRevealAccounts() {
+	// Seed test data
+	hashedPassword, err := facades.Hash().Make("password123")
+	s.NoError(err)
+	user := &models.User{
+		Name:     "Test User",
+		Email:    "login@example.com",
+		Password: hashedPassword,
+	}
+	err = facades.Orm().Query().Create(user)
+	s.NoError(err, "Should create user successfully")
+
+	repo := repositories.NewUserRepository()
+
+	_, wrongPasswordErr := repo.LoginUser("login@example.com", "wrong-password")
+	_, unknownEmailErr := repo.LoginUser("nobody@example.com", "password123")
+	s.ErrorIs(wrongPasswordErr, repositories.ErrInvalidCredentials)
+	s.ErrorIs(unknownEmailErr, repositories.ErrInvalidCredentials)
+
+	fmt.Println("✓ POST /api/login - Success: Unknown email and wrong password look the same")
+}
+
+// TestTOTPMatchesRFC6238 tests the codes accepted by POST /api/2fa/verify
+func (s *UserTestSuite) TestTOTPMatchesRFC6238() {
+	// RFC 6238 appendix B, SHA-1 seed "12345678901234567890", last six digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, expected := range vectors {
+		code, err := helpers.TOTPCode(secret, unix/helpers.TOTPPeriod)
+		s.NoError(err)
+		s.Equal(expected, code, "Code at %d should match the RFC vector", unix)
+
+		_, ok := helpers.VerifyTOTP(secret, expected, time.Unix(unix+helpers.TOTPPeriod, 0))
+		s.True(ok, "Code from the previous step should still be accepted")
+	}
+
+	_, ok := helpers.VerifyTOTP(secret, "287082", time.Unix(59+3*helpers.TOTPPeriod, 0))
+	s.False(ok, "Codes older than one step should be rejected")
+
+	fmt.Println("✓ POST /api/2fa/verify - Success: TOTP codes match RFC 6238")
+}
+
+// TestTwoFactorLoginChallenge tests POST /api/login and POST /api/2fa/verify
+func (s *UserTestSuite) TestTwoFactorLoginChallenge() {
+	// Seed test data
+	hashedPassword, err := facades.Hash().Make("password123")
+	s.NoError(err)
+	user := &models.User{
+		Name:     "Test Librarian",
+		Email:    "2fa@example.com",
+		Password: hashedPassword,
+		Role:     models.RoleLibrarian,
+	}
+	err = facades.Orm().Query().Create(user)
+	s.NoError(err, "Should create user successfully")
+
+	repo := repositories.NewUserRepository()
+	twoFactor := services.NewTwoFactorService(repo)
+	keyRing := services.NewKeyRingService(repositories.NewKeyRingRepository())
+	tokens := services.NewTokenService(repositories.NewTokenRepository(), repo, keyRing)
+	service := services.NewUserService(repo, tokens, twoFactor)
+
+	setup, err := twoFactor.Enroll(user)
+	s.NoError(err, "Should start enrollment")
+	s.Contains(setup.URI, "otpauth://totp/")
+
+	code, err := helpers.TOTPCode(setup.Secret, time.Now().Unix()/helpers.TOTPPeriod)
+	s.NoError(err)
+	recoveryCodes, err := twoFactor.Confirm(user, code)
+	s.NoError(err, "Should confirm enrollment")
+	s.Len(recoveryCodes, 8)
+	s.Len(strings.ReplaceAll(recoveryCodes[0], "-", ""), 20, "Recovery codes should carry 80 bits")
+	s.NotContains(user.TwoFactorRecoveryCodes, recoveryCodes[0], "Recovery codes should only be stored hashed")
+	s.Contains(user.TwoFactorRecoveryCodes, "$2", "Recovery codes should be hashed with bcrypt")
+
+	// A correct password now only yields a challenge
+	result, err := service.Login("2fa@example.com", "password123")
+	s.NoError(err, "Should accept the password")
+	s.Nil(result.Tokens, "Should not issue tokens before the second factor")
+	s.NotEmpty(result.ChallengeToken)
+
+	_, err = service.VerifyTwoFactor(result.ChallengeToken, code, "")
+	s.ErrorIs(err, services.ErrInvalidTwoFactorCode, "A used code should not be replayed")
+
+	result, err = service.VerifyTwoFactor(result.ChallengeToken, "", recoveryCodes[0])
+	s.NoError(err, "Should accept a recovery code")
+	s.NotEmpty(result.Tokens.AccessToken)
+
+	_, err = service.VerifyTwoFactor(result.ChallengeToken, "", recoveryCodes[1])
+	s.ErrorIs(err, services.ErrTwoFactorChallengeInvalid, "Challenges should be single-use")
+
+	result, err = service.Login("2fa@example.com", "password123")
+	s.NoError(err)
+	_, err = service.VerifyTwoFactor(result.ChallengeToken, "", recoveryCodes[0])
+	s.ErrorIs(err, services.ErrInvalidTwoFactorCode, "Recovery codes should be single-use")
+
+	fmt.Println("✓ POST /api/2fa/verify - Success: Challenge exchanges a second factor for tokens")
+}
+
+// memoryKeyRing keeps a key ring in memory so rotation can be tested without
+// touching the storage disk.
+type memoryKeyRing struct {
+	keys     []models.SigningKey
+	modified time.Time
+}
+
+func (r *memoryKeyRing) LoadKeys() ([]models.SigningKey, error) {
+	return r.keys, nil
+}
+
+func (r *memoryKeyRing) SaveKeys(keys []models.SigningKey) error {
+	r.keys = keys
+	r.modified = time.Now()
+	return nil
+}
+
+func (r *memoryKeyRing) LastModified() (time.Time, error) {
+	return r.modified, nil
+}
+
+// TestKeyRingRotation tests token verification across jwt:rotate-keys
+func (s *UserTestSuite) TestKeyRingRotation() {
+	secret := facades.Config().GetString("jwt.secret")
+	facades.Config().Add("jwt.secret", "test-secret")
+	defer facades.Config().Add("jwt.secret", secret)
+
+	keyRing := services.NewKeyRingService(&memoryKeyRing{})
+	tokens := services.NewTokenService(repositories.NewTokenRepository(), repositories.NewUserRepository(), keyRing)
+
+	claims := func() jwt.MapClaims {
+		return jwt.MapClaims{"sub": 1, "exp": time.Now().Add(time.Hour).Unix(), "iat": time.Now().Unix()}
+	}
+	sign := func(key *services.SigningKey, kid string) string {
+		token := jwt.NewWithClaims(key.Method, claims())
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key.PrivateKey)
+		s.Require().NoError(err)
+		return signed
+	}
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims()).SignedString([]byte("test-secret"))
+	s.Require().NoError(err)
+
+	// The shared secret verifies tokens until a key ring exists
+	_, err = tokens.ParseAccessToken(legacy)
+	s.NoError(err)
+
+	first, err := keyRing.Rotate(services.AlgorithmEdDSA, 1)
+	s.Require().NoError(err)
+	_, err = tokens.ParseAccessToken(sign(first, first.KID))
+	s.NoError(err)
+
+	// ...and never again once it does
+	_, err = tokens.ParseAccessToken(legacy)
+	s.ErrorIs(err, services.ErrLegacyToken)
+
+	// Retired keys still verify the tokens they signed
+	second, err := keyRing.Rotate(services.AlgorithmEdDSA, 1)
+	s.Require().NoError(err)
+	_, err = tokens.ParseAccessToken(sign(first, first.KID))
+	s.NoError(err)
+	_, err = tokens.ParseAccessToken(sign(second, second.KID))
+	s.NoError(err)
+
+	// A kid outside the ring is rejected
+	_, err = tokens.ParseAccessToken(sign(second, "no-such-kid"))
+	s.ErrorIs(err, services.ErrUnknownKeyID)
+
+	// Rotating past keepRetired drops the oldest key
+	_, err = keyRing.Rotate(services.AlgorithmEdDSA, 1)
+	s.Require().NoError(err)
+	_, err = tokens.ParseAccessToken(sign(first, first.KID))
+	s.ErrorIs(err, services.ErrUnknownKeyID)
+
+	fmt.Println("✓ jwt:rotate-keys - Success: Rotation keeps retired keys and retires the shared secret")
+}
+
+// TestChangedEmailMustBeVerified tests POST /api/me and GET /api/email/verify/{id}
+func (s *UserTestSuite) TestChangedEmailMustBeVerified() {
+	verifiedAt := time.Now()
+	user := &models.User{Name: "Test User", Email: "old@example.com", Password: "password123", EmailVerifiedAt: &verifiedAt}
+	s.NoError(facades.Orm().Query().Create(user))
+	token := accessToken(s.T(), user)
+	mailer := s.fakeMail()
+
+	// Changing the address clears its verification and mails a new link
+	var sent *mails.VerifyEmail
+	mailer.EXPECT().Queue(mock.Anything).Run(func(mailable ...mail.Mailable) {
+		sent, _ = mailable[0].(*mails.VerifyEmail)
+	}).Return(nil).Once()
+	resp, err := s.Http(s.T()).WithToken(token).WithHeader("Content-Type", "application/json").
+		Post("/api/me", strings.NewReader(`{"email":"new@example.com"}`))
+	s.NoError(err)
+	resp.AssertOk()
+	s.Require().NotNil(sent, "A verification link should be mailed")
+	s.Equal("new@example.com", sent.To)
+
+	// Unverified users are kept out of borrowing
+	resp, err = s.Http(s.T()).WithToken(token).Post("/api/borrowings/borrow", nil)
+	s.NoError(err)
+	resp.AssertForbidden()
+
+	link, err := url.Parse(sent.Link)
+	s.Require().NoError(err)
+	resp, err = s.Http(s.T()).Get(link.RequestURI() + "0")
+	s.NoError(err)
+	resp.AssertForbidden()
+	resp, err = s.Http(s.T()).Get(link.RequestURI())
+	s.NoError(err)
+	resp.AssertOk()
+
+	// Once verified the request reaches the controller, which wants a book
+	resp, err = s.Http(s.T()).WithToken(token).Post("/api/borrowings/borrow", nil)
+	s.NoError(err)
+	resp.AssertBadRequest()
+
+	fmt.Println("✓ POST /api/me - Success: A changed email is re-verified before borrowing")
+}
+
+// accessToken signs in user the way POST /api/login does.
+func accessToken(t *testing.T, user *models.User) string {
+	repo := repositories.NewUserRepository()
+	keyRing := services.NewKeyRingService(repositories.NewKeyRingRepository())
+	tokens, err := services.NewTokenService(repositories.NewTokenRepository(), repo, keyRing).IssueTokens(user)
+	require.NoError(t, err)
+	return tokens.AccessToken
+}
+
+// fakeMail swaps the mailer for a mock until the test ends.
+func (s *UserTestSuite) fakeMail() *mocksmail.Mail {
+	original := facades.Mail()
+	mailer := mocksmail.NewMail(s.T())
+	facades.App().Instance(binding.Mail, mailer)
+	facades.App().Fresh(binding.Mail)
+	s.T().Cleanup(func() {
+		facades.App().Instance(binding.Mail, original)
+		facades.App().Fresh(binding.Mail)
+	})
+	return mailer
+}